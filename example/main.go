@@ -97,4 +97,4 @@ func main() {
 	fmt.Printf("JSON output length: %d characters\n", len(jsonString))
 	// Uncomment the line below to see the full JSON output
 	// fmt.Println(jsonString)
-}
\ No newline at end of file
+}
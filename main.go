@@ -1,14 +1,21 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 
 	"github.com/ardanlabs/conf/v3"
 	"github.com/joho/godotenv"
-	
+
 	"pull-metrics/pullmetrics"
 )
 
@@ -18,6 +25,16 @@ type Config struct {
 	Repository   string `conf:"pos:1,env:REPOSITORY,help:Repository name"`
 	PRNumber     int    `conf:"pos:2,env:PR_NUMBER,help:Pull Request number"`
 	GitHubToken  string `conf:"env:GITHUB_TOKEN,help:GitHub Personal Access Token"`
+	Timezone     string `conf:"env:OUTPUT_TIMEZONE,help:IANA timezone for output timestamps (default UTC)"`
+	Concurrency  int    `conf:"default:4,env:CONCURRENCY,help:Number of PRs analyzed in parallel when reading PR URLs from stdin"`
+	Compact      bool   `conf:"env:COMPACT_OUTPUT,help:Leave empty arrays and maps out of the output"`
+}
+
+// prRef identifies a pull request parsed from a URL
+type prRef struct {
+	org    string
+	repo   string
+	number int
 }
 
 func main() {
@@ -43,7 +60,18 @@ func main() {
 
 	// Create pullmetrics config
 	pmConfig := pullmetrics.Config{
-		GitHubToken: cfg.GitHubToken,
+		GitHubToken:    cfg.GitHubToken,
+		OutputTimezone: cfg.Timezone,
+		CompactOutput:  cfg.Compact,
+	}
+
+	// Without a PR number, analyze the PR URLs piped on stdin
+	if cfg.PRNumber == 0 {
+		if err := runStdinBatch(pmConfig, cfg.Concurrency, os.Stdin, os.Stdout, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error analyzing PRs: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	// Use the convenience function to get JSON output
@@ -55,4 +83,124 @@ func main() {
 	}
 
 	fmt.Println(jsonOutput)
-}
\ No newline at end of file
+}
+
+// runStdinBatch analyzes every PR URL read from in and writes the results to out as a
+// JSON array in input order. PRs that fail are left out of the array and reported in
+// the returned error after the successful results have been written.
+func runStdinBatch(pmConfig pullmetrics.Config, concurrency int, in io.Reader, out, warn io.Writer) error {
+	refs, err := parsePRURLs(in, warn)
+	if err != nil {
+		return err
+	}
+
+	analyzer, err := pullmetrics.NewAnalyzer(pmConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create analyzer: %w", err)
+	}
+
+	// Ctrl-C stops starting new PRs but still prints what was completed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results, batchErr := analyzeRefs(ctx, analyzer, refs, concurrency)
+
+	completed := make([]*pullmetrics.PRDetails, 0, len(results))
+	for _, details := range results {
+		if details != nil {
+			completed = append(completed, details)
+		}
+	}
+
+	jsonOutput, err := json.Marshal(completed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Fprintln(out, string(jsonOutput))
+
+	return batchErr
+}
+
+// parsePRURLs reads newline-separated PR URLs such as https://github.com/org/repo/pull/123.
+// Blank lines are ignored and malformed lines are skipped with a warning written to warn.
+func parsePRURLs(r io.Reader, warn io.Writer) ([]prRef, error) {
+	var refs []prRef
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		ref, err := parsePRURL(line)
+		if err != nil {
+			fmt.Fprintf(warn, "Warning: skipping line %d: %v\n", lineNumber, err)
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read PR URLs: %w", err)
+	}
+	return refs, nil
+}
+
+// parsePRURL extracts the organization, repository and PR number from a PR web URL
+func parsePRURL(raw string) (prRef, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return prRef{}, fmt.Errorf("invalid PR URL %q", raw)
+	}
+
+	// Expected path: /org/repo/pull/123, optionally followed by a tab such as /files
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] == "" || parts[1] == "" || parts[2] != "pull" {
+		return prRef{}, fmt.Errorf("invalid PR URL %q", raw)
+	}
+	number, err := strconv.Atoi(parts[3])
+	if err != nil || number <= 0 {
+		return prRef{}, fmt.Errorf("invalid PR number in URL %q", raw)
+	}
+
+	return prRef{org: parts[0], repo: parts[1], number: number}, nil
+}
+
+// analyzeRefs analyzes the PRs with the batch API, one batch per repository, and returns
+// the results in input order. Entries for PRs that failed or were not started are nil.
+func analyzeRefs(ctx context.Context, analyzer *pullmetrics.Analyzer, refs []prRef, concurrency int) ([]*pullmetrics.PRDetails, error) {
+	type repoKey struct{ org, repo string }
+	var order []repoKey
+	indexes := make(map[repoKey][]int)
+	for i, ref := range refs {
+		key := repoKey{ref.org, ref.repo}
+		if _, seen := indexes[key]; !seen {
+			order = append(order, key)
+		}
+		indexes[key] = append(indexes[key], i)
+	}
+
+	results := make([]*pullmetrics.PRDetails, len(refs))
+	var errs []error
+	for _, key := range order {
+		numbers := make([]int, len(indexes[key]))
+		for j, i := range indexes[key] {
+			numbers[j] = refs[i].number
+		}
+
+		details, err := analyzer.AnalyzePRs(ctx, key.org, key.repo, numbers, concurrency)
+		for j, i := range indexes[key] {
+			if j < len(details) {
+				results[i] = details[j]
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", key.org, key.repo, err))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParsePRURLs(t *testing.T) {
+	input := strings.Join([]string{
+		"https://github.com/microsoft/vscode/pull/123",
+		"",
+		"   ",
+		"https://github.com/octocat/hello-world/pull/42/files",
+		"not a url",
+		"https://github.com/microsoft/vscode/issues/7",
+		"https://github.com/microsoft/vscode/pull/abc",
+		"  https://github.com/microsoft/vscode/pull/124  ",
+	}, "\n")
+
+	var warnings bytes.Buffer
+	refs, err := parsePRURLs(strings.NewReader(input), &warnings)
+	if err != nil {
+		t.Fatalf("parsePRURLs() error = %v", err)
+	}
+
+	expected := []prRef{
+		{org: "microsoft", repo: "vscode", number: 123},
+		{org: "octocat", repo: "hello-world", number: 42},
+		{org: "microsoft", repo: "vscode", number: 124},
+	}
+	if len(refs) != len(expected) {
+		t.Fatalf("parsePRURLs() returned %d refs, want %d: %v", len(refs), len(expected), refs)
+	}
+	for i, ref := range refs {
+		if ref != expected[i] {
+			t.Errorf("parsePRURLs()[%d] = %+v, want %+v", i, ref, expected[i])
+		}
+	}
+
+	// One warning per malformed line, none for blank lines
+	if got := strings.Count(warnings.String(), "Warning:"); got != 3 {
+		t.Errorf("parsePRURLs() wrote %d warnings, want 3:\n%s", got, warnings.String())
+	}
+	if !strings.Contains(warnings.String(), "line 5") {
+		t.Errorf("parsePRURLs() warnings = %q, want the malformed line number", warnings.String())
+	}
+}
+
+func TestParsePRURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected prRef
+		wantErr  bool
+	}{
+		{
+			name:     "standard URL",
+			raw:      "https://github.com/org/repo/pull/1",
+			expected: prRef{org: "org", repo: "repo", number: 1},
+		},
+		{
+			name:     "trailing slash",
+			raw:      "https://github.com/org/repo/pull/2/",
+			expected: prRef{org: "org", repo: "repo", number: 2},
+		},
+		{
+			name:    "missing number",
+			raw:     "https://github.com/org/repo/pull",
+			wantErr: true,
+		},
+		{
+			name:    "zero number",
+			raw:     "https://github.com/org/repo/pull/0",
+			wantErr: true,
+		},
+		{
+			name:    "no scheme",
+			raw:     "github.com/org/repo/pull/1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := parsePRURL(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePRURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ref != tt.expected {
+				t.Errorf("parsePRURL() = %+v, want %+v", ref, tt.expected)
+			}
+		})
+	}
+}
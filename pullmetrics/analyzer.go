@@ -2,8 +2,11 @@ package pullmetrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -14,63 +17,351 @@ import (
 
 // NewAnalyzer creates a new PR analyzer with the given configuration
 func NewAnalyzer(config Config) (*Analyzer, error) {
-	if config.GitHubToken == "" {
+	tokens := githubTokens(config)
+	if len(tokens) == 0 {
 		return nil, fmt.Errorf("GitHub token is required")
 	}
 
-	// Create GitHub client with OAuth2 token
+	location := time.UTC
+	if config.OutputTimezone != "" {
+		loc, err := time.LoadLocation(config.OutputTimezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output timezone %q: %w", config.OutputTimezone, err)
+		}
+		location = loc
+	}
+
+	switch config.ReviewStartAnchor {
+	case "", ReviewStartFirstReviewRequest, ReviewStartCreation, ReviewStartReadyForReview:
+	default:
+		return nil, fmt.Errorf("invalid review start anchor %q", config.ReviewStartAnchor)
+	}
+
+	autoGeneratedPatterns, err := compilePatterns(config.AutoGeneratedPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto-generated pattern: %w", err)
+	}
+
+	sensitivePathPatterns, err := compileGlobs(config.SensitivePathPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sensitive path pattern: %w", err)
+	}
+
+	hotfixBranchPatterns, err := compileGlobs(config.HotfixBranchPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hotfix branch pattern: %w", err)
+	}
+
+	excludeFilePatterns, err := compileGlobs(config.ExcludeFileGlobs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude file glob: %w", err)
+	}
+
+	// Create a GitHub client with OAuth2 for each token
 	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: config.GitHubToken},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	clients := make([]*github.Client, len(tokens))
+	for i, token := range tokens {
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+		tc := oauth2.NewClient(ctx, ts)
+		tc.Transport = newSSOTransport(newRetryAfterTransport(newDiagnosticsTransport(tc.Transport)))
+		clients[i] = github.NewClient(tc)
+	}
 
 	return &Analyzer{
-		client: client,
+		client:                clients[0],
+		clients:               clients,
+		config:                config,
+		location:              location,
+		autoGeneratedPatterns: autoGeneratedPatterns,
+		sensitivePathPatterns: sensitivePathPatterns,
+		hotfixBranchPatterns:  hotfixBranchPatterns,
+		excludeFilePatterns:   excludeFilePatterns,
 	}, nil
 }
 
+// githubTokens returns Config.GitHubToken followed by Config.GitHubTokens, leaving out
+// empty and repeated tokens
+func githubTokens(config Config) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, token := range append([]string{config.GitHubToken}, config.GitHubTokens...) {
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// clock returns the current time in UTC for the analysis, from Analyzer.now when set
+func (a *Analyzer) clock() time.Time {
+	if a.now != nil {
+		return a.now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// apiClient returns the client to make the next API call with. With several tokens
+// configured, calls rotate through their clients in turn; the counter is atomic, so
+// concurrent analyses sharing the Analyzer still spread their calls evenly.
+func (a *Analyzer) apiClient() *github.Client {
+	if len(a.clients) < 2 {
+		return a.client
+	}
+	n := a.nextClient.Add(1) - 1
+	return a.clients[n%uint64(len(a.clients))]
+}
+
 // AnalyzePR analyzes a GitHub Pull Request and returns comprehensive details
 func (a *Analyzer) AnalyzePR(ctx context.Context, org, repo string, prNumber int) (*PRDetails, error) {
+	ctx = a.withDiagnostics(ctx)
+	pr, err := a.fetchPR(ctx, org, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.AnalyzeFromPR(ctx, org, repo, pr)
+}
+
+// AnalyzePRDelta re-analyzes a PR and returns only the fields that differ from baseline
+// (see DiffPRDetails), so a watcher loop can emit just what changed. A nil baseline
+// reports every field as new.
+func (a *Analyzer) AnalyzePRDelta(ctx context.Context, org, repo string, prNumber int, baseline *PRDetails) ([]FieldChange, error) {
+	current, err := a.AnalyzePR(ctx, org, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+	return DiffPRDetails(baseline, current), nil
+}
+
+// AnalyzePRWithReleases analyzes a PR like AnalyzePR, but matches it against the given
+// releases instead of fetching them, so a batch over one repository can list releases
+// once. The supplied releases are used even when Config.SkipReleaseLookup is set.
+func (a *Analyzer) AnalyzePRWithReleases(ctx context.Context, org, repo string, prNumber int, releases []*github.RepositoryRelease) (*PRDetails, error) {
+	ctx = a.withDiagnostics(ctx)
 	pr, err := a.fetchPR(ctx, org, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
 
-	reviews, err := a.fetchReviews(ctx, org, repo, prNumber)
+	supplied := func(context.Context, string, string) ([]*github.RepositoryRelease, error) {
+		return releases, nil
+	}
+	return a.analyzeFromPR(ctx, org, repo, pr, supplied)
+}
+
+// AnalyzeFromPR analyzes a Pull Request the caller has already fetched (for example
+// from a webhook payload). The PR itself is not re-fetched; reviews, comments,
+// timeline, files, commits and releases are still retrieved from the API.
+func (a *Analyzer) AnalyzeFromPR(ctx context.Context, org, repo string, pr *github.PullRequest) (*PRDetails, error) {
+	return a.analyzeFromPR(ctx, org, repo, pr, nil)
+}
+
+// releaseLister returns the releases of a repository
+type releaseLister func(ctx context.Context, org, repo string) ([]*github.RepositoryRelease, error)
+
+// prData holds everything fetched about a PR that the analysis is computed from
+type prData struct {
+	reviews         []*github.PullRequestReview
+	comments        []*github.IssueComment
+	reviewComments  []*github.PullRequestComment
+	timeline        []*github.Timeline
+	files           []*github.CommitFile
+	commits         []*github.RepositoryCommit
+	releases        []*github.RepositoryRelease
+	releaseSearched bool
+	// truncated names the data cut short by Config.MaxAnalysisDuration
+	truncated []string
+}
+
+// analyzeFromPR runs the analysis on data fetched from the API. Releases come from
+// listReleases when it is non-nil, otherwise they are fetched from the API unless
+// Config.SkipReleaseLookup is set.
+func (a *Analyzer) analyzeFromPR(ctx context.Context, org, repo string, pr *github.PullRequest, listReleases releaseLister) (*PRDetails, error) {
+	return a.analyze(ctx, org, repo, pr, func(ctx context.Context) (*prData, error) {
+		return a.fetchPRData(ctx, org, repo, pr, listReleases)
+	})
+}
+
+// fetchPRData fetches the reviews, comments, timeline, files, commits and releases of a PR
+func (a *Analyzer) fetchPRData(ctx context.Context, org, repo string, pr *github.PullRequest, listReleases releaseLister) (*prData, error) {
+	prNumber := pr.GetNumber()
+	var truncated []string
+
+	reviews, reviewsTruncated, err := a.fetchReviews(ctx, org, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
+	if reviewsTruncated {
+		truncated = append(truncated, "reviews")
+	}
 
-	comments, err := a.fetchComments(ctx, org, repo, prNumber)
+	comments, commentsTruncated, err := a.fetchComments(ctx, org, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
+	if commentsTruncated {
+		truncated = append(truncated, "comments")
+	}
 
-	reviewComments, err := a.fetchReviewComments(ctx, org, repo, prNumber)
+	reviewComments, reviewCommentsTruncated, err := a.fetchReviewComments(ctx, org, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
+	if reviewCommentsTruncated {
+		truncated = append(truncated, "review_comments")
+	}
 
-	timeline, err := a.fetchTimeline(ctx, org, repo, prNumber)
+	timeline, timelineTruncated, err := a.fetchTimeline(ctx, org, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
+	if timelineTruncated {
+		truncated = append(truncated, "timeline")
+	}
 
-	files, err := a.fetchPRFiles(ctx, org, repo, prNumber)
+	files, filesTruncated, err := a.fetchPRFiles(ctx, org, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
+	if filesTruncated {
+		truncated = append(truncated, "files")
+	}
 
-	commits, err := a.fetchPRCommits(ctx, org, repo, prNumber)
+	commits, commitsTruncated, err := a.fetchPRCommits(ctx, org, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
+	if commitsTruncated {
+		truncated = append(truncated, "commits")
+	}
+
+	if listReleases == nil && !a.config.SkipReleaseLookup {
+		listReleases = a.fetchReleases
+	}
 
 	var releases []*github.RepositoryRelease
-	if *pr.Merged {
-		releases, err = a.fetchReleases(ctx, org, repo)
+	releaseSearched := isMerged(pr) && listReleases != nil
+	if releaseSearched {
+		releases, err = listReleases(ctx, org, repo)
+		if err != nil {
+			if !analysisDeadlineExceeded(ctx) {
+				return nil, err
+			}
+			// A partial release list could match the wrong release, so none is reported
+			releases, releaseSearched = nil, false
+			truncated = append(truncated, "releases")
+		}
+	}
+
+	return &prData{
+		reviews:         reviews,
+		comments:        comments,
+		reviewComments:  reviewComments,
+		timeline:        timeline,
+		files:           files,
+		commits:         commits,
+		releases:        releases,
+		releaseSearched: releaseSearched,
+		truncated:       truncated,
+	}, nil
+}
+
+// errAnalysisDeadline is the cause of the context deadline set by Config.MaxAnalysisDuration
+var errAnalysisDeadline = errors.New("analysis deadline exceeded")
+
+// withAnalysisDeadline derives a context that expires after Config.MaxAnalysisDuration,
+// or returns ctx unchanged when no limit is set
+func (a *Analyzer) withAnalysisDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if a.config.MaxAnalysisDuration <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, a.config.MaxAnalysisDuration, errAnalysisDeadline)
+}
+
+// analysisDeadlineExceeded reports whether ctx expired because of Config.MaxAnalysisDuration,
+// as opposed to being cancelled by the caller. Fetch loops then stop paginating and return
+// what they have so far.
+func analysisDeadlineExceeded(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errAnalysisDeadline)
+}
+
+// analyze computes the PR details from the data returned by load, which is only called
+// once the checks that need nothing but the PR itself have passed. Optional lookups
+// enabled in the Config are made from here.
+func (a *Analyzer) analyze(ctx context.Context, org, repo string, pr *github.PullRequest, load func(ctx context.Context) (*prData, error)) (*PRDetails, error) {
+	if pr == nil {
+		return nil, fmt.Errorf("pull request is required")
+	}
+	prNumber := pr.GetNumber()
+	ctx = a.withDiagnostics(ctx)
+
+	if a.config.RequireClosed && pr.GetState() != "closed" {
+		return nil, fmt.Errorf("PR #%d: %w", prNumber, ErrPRStillOpen)
+	}
+
+	authorIsBot, err := a.isBotUser(ctx, pr.GetUser().GetLogin())
+	if err != nil {
+		return nil, err
+	}
+
+	// Checked before fetching anything else so policy failures are cheap
+	jiraUnknown := a.jiraUnknownValue()
+	jiraIssue := extractJiraIssue(pr, jiraUnknown, a.jiraBotValue())
+	if authorIsBot && jiraIssue == jiraUnknown {
+		jiraIssue = a.jiraBotValue()
+	}
+	jiraMissing, err := a.checkJiraPolicy(jiraIssue, authorIsBot)
+	if err != nil {
+		return nil, fmt.Errorf("PR #%d: %w", prNumber, err)
+	}
+
+	loadCtx, cancel := a.withAnalysisDeadline(ctx)
+	data, err := load(loadCtx)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	reviews, comments, reviewComments := data.reviews, data.comments, data.reviewComments
+	timeline, files, commits := data.timeline, data.files, data.commits
+	releases, releaseSearched := data.releases, data.releaseSearched
+	files, excludedFiles := excludeFiles(files, a.excludeFilePatterns)
+
+	if len(a.config.ReviewerFilter) > 0 {
+		reviews, comments, reviewComments = filterByReviewers(a.config.ReviewerFilter, reviews, comments, reviewComments)
+		pr, timeline = filterReviewRequests(a.config.ReviewerFilter, pr, timeline)
+	}
+
+	// Team expansion only feeds the requested reviewer counts
+	requestedPR, requestTimeline := pr, timeline
+	if a.config.ExpandTeamReviewers {
+		requestedPR, requestTimeline, err = a.expandTeamReviewers(ctx, org, pr, timeline)
+		if err != nil {
+			return nil, err
+		}
+		if len(a.config.ReviewerFilter) > 0 {
+			requestedPR, requestTimeline = filterReviewRequests(a.config.ReviewerFilter, requestedPR, requestTimeline)
+		}
+	}
+
+	var repository *github.Repository
+	if a.config.IncludeRepoMetadata {
+		repository, err = a.fetchRepository(ctx, org, repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Protection rules can't be read for a base branch that no longer exists
+	baseBranchMissing := isBaseBranchMissing(pr)
+	checkProtection := a.config.IncludeBranchProtection && !baseBranchMissing
+
+	var protection *github.Protection
+	if checkProtection {
+		protection, err = a.fetchBranchProtection(ctx, org, repo, pr.GetBase().GetRef())
 		if err != nil {
 			return nil, err
 		}
@@ -78,41 +369,114 @@ func (a *Analyzer) AnalyzePR(ctx context.Context, org, repo string, prNumber int
 
 	state := getPRState(pr)
 	approvers := getApprovers(reviews)
-	commenters := getCommenters(comments, reviewComments, *pr.User.Login)
+	if a.config.ExcludePostMergeApprovals {
+		approvers = getApprovers(reviewsBeforeMerge(pr, reviews))
+	}
+	authorUsername := userLogin(pr.GetUser())
+	commenters := getCommenters(comments, reviewComments, authorUsername)
 	commenterUsernames := getCommenterUsernames(commenters)
 	numComments := countTotalComments(comments, reviewComments)
-	numRequestedReviewers := countAllRequestedReviewers(pr, reviews)
+	numRequestedReviewers := countAllRequestedReviewers(requestedPR, reviews, requestTimeline)
 	timestamps := getTimestamps(pr, reviews, comments, reviewComments, timeline, commits)
+	timestamps.ReviewStart = a.reviewStart(pr, timeline, timestamps)
 	prSize := calculatePRSize(files)
-	releaseName, releaseCreatedAt := findReleaseForMergedPR(pr, releases)
+	sensitiveFiles := matchChangedFiles(files, a.sensitivePathPatterns)
+	releaseName, releaseCreatedAt, releaseIsPrerelease := findReleaseForMergedPR(pr, releases, a.config.IncludePreReleases)
 	commitsAfterFirstReview := countCommitsAfterFirstReview(commits, timeline)
+	numPushes := countPushes(commits, timeline)
+	approvalsBeforeLastCommit, approvalsAfterLastCommit := countApprovalsAroundLastCommit(reviews, commits)
 	changeRequestsCount := countChangeRequests(reviews)
-	jiraIssue := extractJiraIssue(pr)
-	metrics := calculatePRMetrics(pr, reviews, comments, timeline, timestamps)
+	distinctChangeRequesters := countDistinctChangeRequesters(reviews)
+	changeRequestResolutions := countChangeRequestResolutions(reviews)
+	commitTypes, primaryChangeType := classifyCommitTypes(commits)
+	now := a.clock()
+	metrics := calculatePRMetrics(pr, reviews, comments, timeline, timestamps, now)
+	metrics.TimeToFirstApprovalHours = calculateTimeToFirstApproval(timestamps, a.config.ApprovalTimeFromCreation)
+	metrics.ApprovalSpreadHours = calculateApprovalSpread(timestamps)
+	metrics.LastCommitToMergeHours = calculateLastCommitToMerge(pr, commits)
+	if a.config.ExcludeClosedIntervals {
+		metrics.ReviewCycleTimeHours = excludeClosedIntervals(metrics.ReviewCycleTimeHours, timestamps, timeline)
+	}
+	if a.config.DraftTimeIncludesReopens {
+		metrics.DraftTimeHours += calculateReopenedDraftHours(timeline, pr, timestamps.FirstReviewRequest, now)
+	}
+	metrics.AvgTimeToAddressCommentHours = calculateAvgTimeToAddressComment(reviewComments, commits, authorUsername)
+	metrics.ReviewCommentDensity = calculateReviewCommentDensity(reviewComments, prSize.LinesChanged)
+	metrics.ActiveReviewHours = calculateActiveReviewHours(reviews, comments, reviewComments, authorUsername)
+	metrics.MedianReviewerResponseHours = calculateMedianReviewerResponse(reviews, comments, reviewComments, timeline)
+	metrics.LongestIdleHours = calculateLongestIdle(pr, reviews, comments, reviewComments, commits, timeline, a.ignoredTimelineEvents(), now)
+	metrics.HoursSinceLastUpdate = calculateHoursSinceLastUpdate(pr, now)
+	metrics.TimeInWorkflowLabelHours = calculateTimeInLabel(pr, timeline, a.config.WorkflowLabel, now)
+	metrics.inapplicable = inapplicableMetrics(pr, timeline, timestamps, a.config)
 
 	result := &PRDetails{
-		OrganizationName:           org,
-		RepositoryName:             repo,
-		PRNumber:                   prNumber,
-		PRTitle:                    *pr.Title,
-		PRWebURL:                   *pr.HTMLURL,
-		PRNodeID:                   *pr.NodeID,
-		AuthorUsername:             *pr.User.Login,
-		ApproverUsernames:          approvers,
-		CommenterUsernames:         commenterUsernames,
-		State:                      state,
-		NumComments:                numComments,
-		NumCommenters:              len(commenters),
-		NumApprovers:               len(approvers),
-		NumRequestedReviewers:      numRequestedReviewers,
-		ChangeRequestsCount:        changeRequestsCount,
-		LinesChanged:               prSize.LinesChanged,
-		FilesChanged:               prSize.FilesChanged,
-		CommitsAfterFirstReview:    commitsAfterFirstReview,
-		JiraIssue:                  jiraIssue,
-		IsBot:                      isBot(*pr.User.Login),
-		Metrics:                    metrics,
-		GeneratedAt:                time.Now().UTC().Format(time.RFC3339),
+		OrganizationName:                  org,
+		RepositoryName:                    repo,
+		PRNumber:                          prNumber,
+		PRTitle:                           pr.GetTitle(),
+		PRWebURL:                          pr.GetHTMLURL(),
+		PRNodeID:                          pr.GetNodeID(),
+		BaseBranch:                        pr.GetBase().GetRef(),
+		HeadBranch:                        pr.GetHead().GetRef(),
+		CrossRepo:                         isCrossRepo(pr),
+		HeadRepoFullName:                  pr.GetHead().GetRepo().GetFullName(),
+		BaseBranchMissing:                 baseBranchMissing,
+		AuthorUsername:                    authorUsername,
+		ApproverUsernames:                 approvers,
+		ApproversWhoCommitted:             getApproversWhoCommitted(approvers, commits),
+		FirstApprover:                     timestamps.FirstApprover,
+		CommenterUsernames:                commenterUsernames,
+		Assignees:                         getAssignees(pr),
+		ReviewerStats:                     getReviewerStats(reviews, timeline, authorUsername),
+		ReviewCommentsByReviewer:          countReviewCommentsByReviewer(reviewComments, authorUsername),
+		State:                             state,
+		NumComments:                       numComments,
+		EditedComments:                    countEditedComments(comments, reviewComments, a.editedCommentThreshold()),
+		MaxThreadLength:                   maxThreadLength(reviewComments),
+		CommentsWithoutFollowupCommit:     countCommentsWithoutFollowupCommit(pr, reviewComments, commits, authorUsername),
+		NumCommenters:                     len(commenters),
+		NumApprovers:                      len(approvers),
+		ApprovalsBeforeLastCommit:         approvalsBeforeLastCommit,
+		ApprovalsAfterLastCommit:          approvalsAfterLastCommit,
+		PostMergeApprovals:                countPostMergeApprovals(pr, reviews),
+		NumRequestedReviewers:             numRequestedReviewers,
+		TotalReviewersEverRequested:       countReviewersEverRequested(requestTimeline),
+		UnfulfilledReviewRequests:         countUnfulfilledReviewRequests(requestedPR, reviews, requestTimeline),
+		NeverRequestedReview:              neverRequestedReview(pr, timeline),
+		DistinctReviewRequestRounds:       countReviewRequestRounds(timeline, reviews),
+		ChangeRequestsCount:               changeRequestsCount,
+		DistinctChangeRequesters:          distinctChangeRequesters,
+		ChangeRequestResolutions:          changeRequestResolutions,
+		LinesChanged:                      prSize.LinesChanged,
+		FilesChanged:                      prSize.FilesChanged,
+		IsEmpty:                           isEmptyPR(prSize, data.truncated),
+		ExcludedFilesCount:                excludedFiles,
+		SizeBucket:                        classifyPRSize(prSize.LinesChanged, a.config.SizeBuckets),
+		TouchesSensitivePaths:             len(sensitiveFiles) > 0,
+		SensitiveFilesTouched:             sensitiveFiles,
+		CommitsAfterFirstReview:           commitsAfterFirstReview,
+		NumPushes:                         numPushes,
+		ReopenCount:                       countReopens(timeline),
+		CommitTypes:                       commitTypes,
+		PrimaryChangeType:                 primaryChangeType,
+		JiraIssue:                         jiraIssue,
+		JiraMissing:                       jiraMissing,
+		IsBot:                             authorIsBot,
+		IsRevert:                          isRevert(pr),
+		IsAutoGenerated:                   isAutoGenerated(pr, a.autoGeneratedPatterns),
+		IsHotfix:                          isHotfix(pr, a.hotfixBranchPatterns, a.config.HotfixLabels),
+		OpenedOnWeekend:                   pr.CreatedAt != nil && isWeekend(pr.GetCreatedAt().Time, a.location),
+		MergedOnWeekend:                   pr.MergedAt != nil && isWeekend(pr.GetMergedAt().Time, a.location),
+		MergedOverUnresolvedChangeRequest: mergedOverUnresolvedChangeRequest(pr, reviews),
+		MergeCommitSHA:                    mergeCommitSHA(pr),
+		AutoMergeEnabled:                  pr.GetAutoMerge() != nil,
+		AutoMergeMethod:                   pr.GetAutoMerge().GetMergeMethod(),
+		Metrics:                           metrics,
+		ReleaseIsPrerelease:               releaseIsPrerelease,
+		ReleaseSearched:                   releaseSearched,
+		Truncated:                         len(data.truncated) > 0,
+		TruncatedData:                     data.truncated,
+		compact:                           a.config.CompactOutput,
 	}
 
 	// Add release name if it exists
@@ -137,113 +501,264 @@ func (a *Analyzer) AnalyzePR(ctx context.Context, org, repo string, prNumber int
 		prTimestamps.ReleaseCreatedAt = releaseCreatedAt
 	}
 
-	result.Timestamps = prTimestamps
+	result.Timestamps = a.localizeTimestamps(prTimestamps)
+
+	if a.config.ResolveUserProfiles {
+		profiles, err := a.resolveUserProfiles(ctx, approvers, commenterUsernames)
+		if err != nil {
+			return nil, err
+		}
+		result.UserProfiles = profiles
+	}
+
+	if a.config.ReviewSLAHours > 0 {
+		result.SLAMet, result.SLABreachHours = evaluateReviewSLA(pr, timestamps.FirstReviewRequest, reviews, authorUsername, a.config.ReviewSLAHours, now)
+	}
+
+	if !a.config.OmitGeneratedAt {
+		result.GeneratedAt = a.formatOutputTime(now.Format(time.RFC3339))
+	}
+
+	if repository != nil {
+		archived := repository.GetArchived()
+		result.RepoArchived = &archived
+	}
+
+	if a.config.IncludeDeployments && hasDeploymentEvents(timeline) {
+		deployments, err := a.fetchDeployments(ctx, org, repo, pr)
+		if err != nil {
+			return nil, err
+		}
+		result.Deployments = deployments
+	}
+
+	if a.config.IncludeLabelTimeline {
+		result.LabelEvents = a.labelEvents(timeline)
+	}
+
+	if a.config.IncludeCommits && len(commits) > 0 {
+		result.Commits, result.CommitsTruncated = commitEntries(commits, a.config.MaxCommits)
+	}
+
+	if a.config.DetectStackedPRs {
+		result.StackedOnPR, result.StackedPRs, err = a.findStackedPRs(ctx, org, repo, pr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.EngagementIndex = calculateEngagementIndex(result, a.config.EngagementWeights)
+
+	if result.LinesChanged < a.config.MinLinesForMetrics {
+		result.Metrics = nil
+		result.MetricsSuppressed = true
+	}
+
+	if a.config.ExpectedReviewers > 0 {
+		deviation := result.NumApprovers - a.config.ExpectedReviewers
+		result.ReviewerCountDeviation = &deviation
+	}
+
+	if a.config.IncludePatchStats {
+		patchBytes := calculatePatchBytes(files)
+		result.TotalPatchBytes = &patchBytes
+	}
+
+	if checkProtection {
+		applyBranchProtection(result, protection)
+	}
+
+	// Thread resolution is only available through GraphQL; omit the fields if it can't be fetched
+	if a.config.IncludeThreadResolution {
+		if threads, err := a.fetchReviewThreads(ctx, org, repo, prNumber); err == nil {
+			byAuthor, byReviewer := countThreadResolutions(threads, authorUsername)
+			result.ThreadsResolvedByAuthor = &byAuthor
+			result.ThreadsResolvedByReviewer = &byReviewer
+		}
+	}
+
+	// Projects (v2) are only available through GraphQL; omit the statuses if they can't be fetched
+	if a.config.IncludeProjectStatus {
+		if statuses, err := a.fetchProjectStatuses(ctx, org, repo, prNumber); err == nil {
+			result.ProjectStatuses = statuses
+		}
+	}
+
+	if recorder := diagnosticsFromContext(ctx); recorder != nil {
+		result.Diagnostics = recorder.snapshot()
+	}
 
 	return result, nil
 }
 
+// VerifyAccess checks that the configured token can read the given repository. It makes
+// a single cheap API call and is intended to be run once before analyzing a batch of PRs.
+// The returned error wraps ErrInvalidToken, ErrNoAccess or ErrRepoNotFound when GitHub
+// responds with 401, 403 or 404 respectively.
+func (a *Analyzer) VerifyAccess(ctx context.Context, org, repo string) error {
+	_, resp, err := a.apiClient().Repositories.Get(ctx, org, repo)
+	if err == nil {
+		return nil
+	}
+
+	if resp != nil {
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w %s/%s: %v", ErrNoAccess, org, repo, err)
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %s/%s (private repositories the token can't see also report not found)", ErrRepoNotFound, org, repo)
+		}
+	}
+	return fmt.Errorf("failed to verify repository access: %w", err)
+}
+
+// RateLimit reports the token's remaining API budget, including the core, search and
+// GraphQL limits, so callers can size a batch before starting it. Checking the rate
+// limit does not count against it.
+func (a *Analyzer) RateLimit(ctx context.Context) (*github.RateLimits, error) {
+	limits, _, err := a.apiClient().RateLimit.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rate limits: %w", err)
+	}
+	return limits, nil
+}
+
 func (a *Analyzer) fetchPR(ctx context.Context, org, repo string, prNumber int) (*github.PullRequest, error) {
-	pr, _, err := a.client.PullRequests.Get(ctx, org, repo, prNumber)
+	pr, _, err := a.apiClient().PullRequests.Get(ctx, org, repo, prNumber)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch PR: %w", err)
 	}
 	return pr, nil
 }
 
-func (a *Analyzer) fetchReviews(ctx context.Context, org, repo string, prNumber int) ([]*github.PullRequestReview, error) {
+func (a *Analyzer) fetchReviews(ctx context.Context, org, repo string, prNumber int) ([]*github.PullRequestReview, bool, error) {
 	var allReviews []*github.PullRequestReview
 	opts := &github.ListOptions{PerPage: 100}
 
 	for {
-		reviews, resp, err := a.client.PullRequests.ListReviews(ctx, org, repo, prNumber, opts)
+		reviews, resp, err := a.apiClient().PullRequests.ListReviews(ctx, org, repo, prNumber, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch reviews: %w", err)
+			if analysisDeadlineExceeded(ctx) {
+				return allReviews, true, nil
+			}
+			return nil, false, fmt.Errorf("failed to fetch reviews: %w", err)
 		}
 		allReviews = append(allReviews, reviews...)
 
 		if resp.NextPage == 0 {
 			break
 		}
+		if analysisDeadlineExceeded(ctx) {
+			return allReviews, true, nil
+		}
 		opts.Page = resp.NextPage
 	}
 
-	return allReviews, nil
+	return allReviews, false, nil
 }
 
-func (a *Analyzer) fetchComments(ctx context.Context, org, repo string, prNumber int) ([]*github.IssueComment, error) {
+func (a *Analyzer) fetchComments(ctx context.Context, org, repo string, prNumber int) ([]*github.IssueComment, bool, error) {
 	var allComments []*github.IssueComment
 	opts := &github.IssueListCommentsOptions{
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
 
 	for {
-		comments, resp, err := a.client.Issues.ListComments(ctx, org, repo, prNumber, opts)
+		comments, resp, err := a.apiClient().Issues.ListComments(ctx, org, repo, prNumber, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch comments: %w", err)
+			if analysisDeadlineExceeded(ctx) {
+				return allComments, true, nil
+			}
+			return nil, false, fmt.Errorf("failed to fetch comments: %w", err)
 		}
 		allComments = append(allComments, comments...)
 
 		if resp.NextPage == 0 {
 			break
 		}
+		if analysisDeadlineExceeded(ctx) {
+			return allComments, true, nil
+		}
 		opts.Page = resp.NextPage
 	}
 
-	return allComments, nil
+	return allComments, false, nil
 }
 
-func (a *Analyzer) fetchReviewComments(ctx context.Context, org, repo string, prNumber int) ([]*github.PullRequestComment, error) {
+func (a *Analyzer) fetchReviewComments(ctx context.Context, org, repo string, prNumber int) ([]*github.PullRequestComment, bool, error) {
 	var allReviewComments []*github.PullRequestComment
 	opts := &github.PullRequestListCommentsOptions{
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
 
 	for {
-		reviewComments, resp, err := a.client.PullRequests.ListComments(ctx, org, repo, prNumber, opts)
+		reviewComments, resp, err := a.apiClient().PullRequests.ListComments(ctx, org, repo, prNumber, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch review comments: %w", err)
+			if analysisDeadlineExceeded(ctx) {
+				return allReviewComments, true, nil
+			}
+			return nil, false, fmt.Errorf("failed to fetch review comments: %w", err)
 		}
 		allReviewComments = append(allReviewComments, reviewComments...)
 
 		if resp.NextPage == 0 {
 			break
 		}
+		if analysisDeadlineExceeded(ctx) {
+			return allReviewComments, true, nil
+		}
 		opts.Page = resp.NextPage
 	}
 
-	return allReviewComments, nil
+	return allReviewComments, false, nil
 }
 
-func (a *Analyzer) fetchTimeline(ctx context.Context, org, repo string, prNumber int) ([]*github.Timeline, error) {
+func (a *Analyzer) fetchTimeline(ctx context.Context, org, repo string, prNumber int) ([]*github.Timeline, bool, error) {
 	var allTimeline []*github.Timeline
 	opts := &github.ListOptions{PerPage: 100}
 
 	for {
-		timeline, resp, err := a.client.Issues.ListIssueTimeline(ctx, org, repo, prNumber, opts)
+		timeline, resp, err := a.apiClient().Issues.ListIssueTimeline(ctx, org, repo, prNumber, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch timeline: %w", err)
+			if analysisDeadlineExceeded(ctx) {
+				return allTimeline, true, nil
+			}
+			return nil, false, fmt.Errorf("failed to fetch timeline: %w", err)
 		}
 		allTimeline = append(allTimeline, timeline...)
 
 		if resp.NextPage == 0 {
 			break
 		}
+		if analysisDeadlineExceeded(ctx) {
+			return allTimeline, true, nil
+		}
 		opts.Page = resp.NextPage
 	}
 
-	return allTimeline, nil
+	return allTimeline, false, nil
 }
 
-func (a *Analyzer) fetchPRFiles(ctx context.Context, org, repo string, prNumber int) ([]*github.CommitFile, error) {
-	var allFiles []*github.CommitFile
-	opts := &github.ListOptions{PerPage: 100}
+// fetchDeployments lists the deployments of a PR's head branch, oldest first, each with
+// the state of its most recent status. A fork's branch name can match an unrelated branch
+// of the base repository, so deployments of cross-repo PRs are listed by head commit SHA.
+func (a *Analyzer) fetchDeployments(ctx context.Context, org, repo string, pr *github.PullRequest) ([]DeploymentInfo, error) {
+	var allDeployments []*github.Deployment
+	opts := &github.DeploymentsListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	if isCrossRepo(pr) {
+		opts.SHA = pr.GetHead().GetSHA()
+	} else {
+		opts.Ref = pr.GetHead().GetRef()
+	}
 
 	for {
-		files, resp, err := a.client.PullRequests.ListFiles(ctx, org, repo, prNumber, opts)
+		deployments, resp, err := a.apiClient().Repositories.ListDeployments(ctx, org, repo, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch PR files: %w", err)
+			return nil, fmt.Errorf("failed to fetch deployments: %w", err)
 		}
-		allFiles = append(allFiles, files...)
+		allDeployments = append(allDeployments, deployments...)
 
 		if resp.NextPage == 0 {
 			break
@@ -251,39 +766,80 @@ func (a *Analyzer) fetchPRFiles(ctx context.Context, org, repo string, prNumber
 		opts.Page = resp.NextPage
 	}
 
-	return allFiles, nil
+	infos := make([]DeploymentInfo, 0, len(allDeployments))
+	for _, deployment := range allDeployments {
+		// Statuses are returned newest first
+		statuses, _, err := a.apiClient().Repositories.ListDeploymentStatuses(ctx, org, repo, deployment.GetID(), &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch deployment statuses: %w", err)
+		}
+		info := DeploymentInfo{
+			Environment: deployment.GetEnvironment(),
+			CreatedAt:   deployment.GetCreatedAt().UTC().Format(time.RFC3339),
+		}
+		if len(statuses) > 0 {
+			info.State = statuses[0].GetState()
+		}
+		infos = append(infos, info)
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].CreatedAt < infos[j].CreatedAt
+	})
+	for i := range infos {
+		infos[i].CreatedAt = a.formatOutputTime(infos[i].CreatedAt)
+	}
+
+	return infos, nil
 }
 
-func (a *Analyzer) fetchReleases(ctx context.Context, org, repo string) ([]*github.RepositoryRelease, error) {
-	var allReleases []*github.RepositoryRelease
+// hasDeploymentEvents reports whether the timeline records a deployment of the PR
+func hasDeploymentEvents(timeline []*github.Timeline) bool {
+	for _, event := range timeline {
+		switch TimelineEvent(event.GetEvent()) {
+		case EventDeployed, EventDeploymentEnvChanged:
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Analyzer) fetchPRFiles(ctx context.Context, org, repo string, prNumber int) ([]*github.CommitFile, bool, error) {
+	var allFiles []*github.CommitFile
 	opts := &github.ListOptions{PerPage: 100}
 
 	for {
-		releases, resp, err := a.client.Repositories.ListReleases(ctx, org, repo, opts)
+		files, resp, err := a.apiClient().PullRequests.ListFiles(ctx, org, repo, prNumber, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch releases: %w", err)
+			if analysisDeadlineExceeded(ctx) {
+				return allFiles, true, nil
+			}
+			return nil, false, fmt.Errorf("failed to fetch PR files: %w", err)
 		}
-		allReleases = append(allReleases, releases...)
+		allFiles = append(allFiles, files...)
 
 		if resp.NextPage == 0 {
 			break
 		}
+		if analysisDeadlineExceeded(ctx) {
+			return allFiles, true, nil
+		}
 		opts.Page = resp.NextPage
 	}
 
-	return allReleases, nil
+	return allFiles, false, nil
 }
 
-func (a *Analyzer) fetchPRCommits(ctx context.Context, org, repo string, prNumber int) ([]*github.RepositoryCommit, error) {
-	var allCommits []*github.RepositoryCommit
+func (a *Analyzer) fetchReleases(ctx context.Context, org, repo string) ([]*github.RepositoryRelease, error) {
+	var allReleases []*github.RepositoryRelease
 	opts := &github.ListOptions{PerPage: 100}
 
 	for {
-		commits, resp, err := a.client.PullRequests.ListCommits(ctx, org, repo, prNumber, opts)
+		releases, resp, err := a.apiClient().Repositories.ListReleases(ctx, org, repo, opts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch PR commits: %w", err)
+			return nil, fmt.Errorf("failed to fetch releases: %w", err)
 		}
-		allCommits = append(allCommits, commits...)
+		allReleases = append(allReleases, releases...)
 
 		if resp.NextPage == 0 {
 			break
@@ -291,442 +847,2395 @@ func (a *Analyzer) fetchPRCommits(ctx context.Context, org, repo string, prNumbe
 		opts.Page = resp.NextPage
 	}
 
-	return allCommits, nil
+	return allReleases, nil
 }
 
-func getPRState(pr *github.PullRequest) string {
-	if pr.GetDraft() {
-		return "draft"
-	}
-	if pr.GetMerged() {
-		return "merged"
+func (a *Analyzer) fetchRepository(ctx context.Context, org, repo string) (*github.Repository, error) {
+	repository, _, err := a.apiClient().Repositories.Get(ctx, org, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repository: %w", err)
 	}
-	return pr.GetState()
+	return repository, nil
 }
 
-func getApprovers(reviews []*github.PullRequestReview) []string {
-	approvers := make(map[string]bool)
-	for _, review := range reviews {
-		if review.GetState() == "APPROVED" {
-			approvers[review.GetUser().GetLogin()] = true
-		}
-	}
-
-	result := make([]string, 0, len(approvers))
-	for username := range approvers {
-		result = append(result, username)
+// fetchUser returns a user's profile, served from the Analyzer's cache when the user
+// has already been fetched.
+func (a *Analyzer) fetchUser(ctx context.Context, login string) (*github.User, error) {
+	a.usersMu.Lock()
+	user, ok := a.users[login]
+	a.usersMu.Unlock()
+	if ok {
+		return user, nil
 	}
-	return result
-}
-
-func getCommenters(comments []*github.IssueComment, reviewComments []*github.PullRequestComment, authorUsername string) map[string]bool {
-	commenters := make(map[string]bool)
 
-	// Process regular comments
-	for _, comment := range comments {
-		if comment.GetUser().GetLogin() != authorUsername {
-			commenters[comment.GetUser().GetLogin()] = true
-		}
+	user, _, err := a.apiClient().Users.Get(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user %s: %w", login, err)
 	}
 
-	// Process review comments
-	for _, reviewComment := range reviewComments {
-		if reviewComment.GetUser().GetLogin() != authorUsername {
-			commenters[reviewComment.GetUser().GetLogin()] = true
-		}
+	a.usersMu.Lock()
+	if a.users == nil {
+		a.users = make(map[string]*github.User)
 	}
+	a.users[login] = user
+	a.usersMu.Unlock()
 
-	return commenters
-}
-
-func countTotalComments(comments []*github.IssueComment, reviewComments []*github.PullRequestComment) int {
-	return len(comments) + len(reviewComments)
+	return user, nil
 }
 
-func getCommenterUsernames(commenters map[string]bool) []string {
-	usernames := make([]string, 0, len(commenters))
-	for username := range commenters {
-		usernames = append(usernames, username)
+// fetchTeamMembers returns the logins of a team's members, served from the Analyzer's
+// cache when the team has already been fetched.
+func (a *Analyzer) fetchTeamMembers(ctx context.Context, org, slug string) ([]string, error) {
+	key := org + "/" + slug
+	a.teamsMu.Lock()
+	members, ok := a.teamMembers[key]
+	a.teamsMu.Unlock()
+	if ok {
+		return members, nil
 	}
-	sort.Strings(usernames) // Sort for consistent output
-	return usernames
-}
 
-func countAllRequestedReviewers(pr *github.PullRequest, reviews []*github.PullRequestReview) int {
-	// Count all reviewers who were requested to review (both those who reviewed and those who haven't)
-	requestedReviewers := make(map[string]bool)
+	opts := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		users, resp, err := a.apiClient().Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch members of team %s: %w", slug, err)
+		}
+		for _, user := range users {
+			members = append(members, user.GetLogin())
+		}
 
-	// Add users who have submitted reviews (they must have been requested to review)
-	for _, review := range reviews {
-		requestedReviewers[review.GetUser().GetLogin()] = true
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
 	}
 
-	// Add current requested reviewers (those who haven't reviewed yet)
-	for _, reviewer := range pr.RequestedReviewers {
-		requestedReviewers[reviewer.GetLogin()] = true
+	a.teamsMu.Lock()
+	if a.teamMembers == nil {
+		a.teamMembers = make(map[string][]string)
 	}
+	a.teamMembers[key] = members
+	a.teamsMu.Unlock()
 
-	return len(requestedReviewers)
+	return members, nil
 }
 
-func getTimestamps(pr *github.PullRequest, reviews []*github.PullRequestReview, comments []*github.IssueComment, reviewComments []*github.PullRequestComment, timeline []*github.Timeline, commits []*github.RepositoryCommit) *Timestamps {
-	timestamps := &Timestamps{}
-
-	// First commit timestamp (from commits)
-	if len(commits) > 0 {
-		// Sort commits by date to get the first one
-		sort.Slice(commits, func(i, j int) bool {
-			return commits[i].GetCommit().GetAuthor().GetDate().Before(commits[j].GetCommit().GetAuthor().GetDate().Time)
-		})
-		utcTime := formatToUTC(commits[0].GetCommit().GetAuthor().GetDate().Format(time.RFC3339))
-		timestamps.FirstCommit = &utcTime
+// expandTeamReviewers returns a copy of the PR whose requested reviewers include the
+// members of its requested teams, and a copy of the timeline in which each team review
+// request or removal is followed by the same event for every team member.
+func (a *Analyzer) expandTeamReviewers(ctx context.Context, org string, pr *github.PullRequest, timeline []*github.Timeline) (*github.PullRequest, []*github.Timeline, error) {
+	expandedPR := *pr
+	expandedPR.RequestedReviewers = append([]*github.User(nil), pr.RequestedReviewers...)
+	for _, team := range pr.RequestedTeams {
+		members, err := a.fetchTeamMembers(ctx, org, team.GetSlug())
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, member := range members {
+			expandedPR.RequestedReviewers = append(expandedPR.RequestedReviewers, &github.User{Login: github.String(member)})
+		}
 	}
 
-	// Created timestamp (from PR)
-	if !pr.GetCreatedAt().IsZero() {
-		utcTime := formatToUTC(pr.GetCreatedAt().Format(time.RFC3339))
-		timestamps.CreatedAt = &utcTime
+	expandedTimeline := make([]*github.Timeline, 0, len(timeline))
+	for _, event := range timeline {
+		expandedTimeline = append(expandedTimeline, event)
+
+		eventType := TimelineEvent(event.GetEvent())
+		slug := event.GetRequestedTeam().GetSlug()
+		if (eventType != EventReviewRequested && eventType != EventReviewRequestRemoved) || slug == "" || event.Reviewer != nil {
+			continue
+		}
+		members, err := a.fetchTeamMembers(ctx, org, slug)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, member := range members {
+			memberEvent := *event
+			memberEvent.Reviewer = &github.User{Login: github.String(member)}
+			memberEvent.RequestedTeam = nil
+			expandedTimeline = append(expandedTimeline, &memberEvent)
+		}
 	}
 
-	// Merged and closed timestamps (from PR)
-	if pr.MergedAt != nil && !pr.GetMergedAt().IsZero() {
-		utcTime := formatToUTC(pr.GetMergedAt().Format(time.RFC3339))
-		timestamps.MergedAt = &utcTime
+	return &expandedPR, expandedTimeline, nil
+}
+
+// resolveUserProfiles builds display profiles for every distinct user in the given lists
+func (a *Analyzer) resolveUserProfiles(ctx context.Context, usernameLists ...[]string) (map[string]UserProfile, error) {
+	profiles := make(map[string]UserProfile)
+	for _, usernames := range usernameLists {
+		for _, login := range usernames {
+			if _, done := profiles[login]; done || login == "" || login == DeletedUserLogin {
+				continue
+			}
+			user, err := a.fetchUser(ctx, login)
+			if err != nil {
+				return nil, err
+			}
+			profiles[login] = UserProfile{
+				Name:    user.GetName(),
+				Email:   user.GetEmail(),
+				Company: user.GetCompany(),
+			}
+		}
 	}
-	if pr.ClosedAt != nil && !pr.GetClosedAt().IsZero() {
-		utcTime := formatToUTC(pr.GetClosedAt().Format(time.RFC3339))
-		timestamps.ClosedAt = &utcTime
+	return profiles, nil
+}
+
+// findStackedPRs returns the open PR whose head branch is this PR's base branch, i.e. the
+// PR this one is stacked on, and the open PRs whose base branch is this PR's head branch,
+// which are stacked on it. Branches are matched within the repository, so a PR from a
+// fork has no PRs stacked on it. If several open PRs share the base branch as their head,
+// the lowest numbered one is reported.
+func (a *Analyzer) findStackedPRs(ctx context.Context, org, repo string, pr *github.PullRequest) (*int, []int, error) {
+	var stackedOn *int
+	if base := pr.GetBase().GetRef(); base != "" {
+		parents, err := a.fetchOpenPRs(ctx, org, repo, org+":"+base, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, parent := range parents {
+			if number := parent.GetNumber(); number != pr.GetNumber() && (stackedOn == nil || number < *stackedOn) {
+				stackedOn = &number
+			}
+		}
 	}
 
-	// First review request (from timeline)
-	for _, event := range timeline {
-		if event.GetEvent() == "review_requested" && timestamps.FirstReviewRequest == nil {
-			utcTime := formatToUTC(event.GetCreatedAt().Format(time.RFC3339))
-			timestamps.FirstReviewRequest = &utcTime
+	var stacked []int
+	if head := pr.GetHead().GetRef(); head != "" && !isCrossRepo(pr) {
+		children, err := a.fetchOpenPRs(ctx, org, repo, "", head)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, child := range children {
+			if child.GetNumber() != pr.GetNumber() {
+				stacked = append(stacked, child.GetNumber())
+			}
+		}
+		sort.Ints(stacked)
+	}
+
+	return stackedOn, stacked, nil
+}
+
+// fetchBranchProtection returns the protection rules for a branch, or nil if the
+// branch has no protection configured.
+func (a *Analyzer) fetchBranchProtection(ctx context.Context, org, repo, branch string) (*github.Protection, error) {
+	protection, _, err := a.apiClient().Repositories.GetBranchProtection(ctx, org, repo, branch)
+	if err != nil {
+		if errors.Is(err, github.ErrBranchNotProtected) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch branch protection: %w", err)
+	}
+	return protection, nil
+}
+
+func (a *Analyzer) fetchPRCommits(ctx context.Context, org, repo string, prNumber int) ([]*github.RepositoryCommit, bool, error) {
+	var allCommits []*github.RepositoryCommit
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		commits, resp, err := a.apiClient().PullRequests.ListCommits(ctx, org, repo, prNumber, opts)
+		if err != nil {
+			if analysisDeadlineExceeded(ctx) {
+				return allCommits, true, nil
+			}
+			return nil, false, fmt.Errorf("failed to fetch PR commits: %w", err)
+		}
+		allCommits = append(allCommits, commits...)
+
+		if resp.NextPage == 0 {
 			break
 		}
+		if analysisDeadlineExceeded(ctx) {
+			return allCommits, true, nil
+		}
+		opts.Page = resp.NextPage
 	}
 
-	// First comment (from both regular comments and review comments)
-	var allComments []time.Time
+	return allCommits, false, nil
+}
 
-	// Collect all comment timestamps
+// isMerged is the single determination of whether a PR was merged. GitHub occasionally
+// reports merged_at without merged (or the reverse) for edge states, so either is taken
+// as evidence of a merge.
+func isMerged(pr *github.PullRequest) bool {
+	return pr.GetMerged() || pr.MergedAt != nil
+}
+
+// mergeCommitSHA returns the SHA of the PR's merge commit, or "" when it isn't merged.
+// GitHub also reports a SHA for open PRs, but that is a test merge that never lands.
+func mergeCommitSHA(pr *github.PullRequest) string {
+	if !isMerged(pr) {
+		return ""
+	}
+	return pr.GetMergeCommitSHA()
+}
+
+func getPRState(pr *github.PullRequest) string {
+	if pr.GetDraft() {
+		return "draft"
+	}
+	if isMerged(pr) {
+		return "merged"
+	}
+	return pr.GetState()
+}
+
+// userLogin returns the user's login, or DeletedUserLogin for a missing user (GitHub
+// reports deleted accounts as a null user), so they never show up as an empty username
+func userLogin(user *github.User) string {
+	if login := user.GetLogin(); login != "" {
+		return login
+	}
+	return DeletedUserLogin
+}
+
+func getApprovers(reviews []*github.PullRequestReview) []string {
+	approvers := make(map[string]bool)
+	for _, review := range reviews {
+		if ReviewState(review.GetState()) == ReviewApproved {
+			approvers[userLogin(review.GetUser())] = true
+		}
+	}
+
+	result := make([]string, 0, len(approvers))
+	for username := range approvers {
+		result = append(result, username)
+	}
+	return result
+}
+
+// reviewsBeforeMerge returns the reviews submitted no later than the PR's merge. Reviews of
+// unmerged PRs are returned unchanged.
+func reviewsBeforeMerge(pr *github.PullRequest, reviews []*github.PullRequestReview) []*github.PullRequestReview {
+	if !isMerged(pr) || pr.MergedAt == nil {
+		return reviews
+	}
+
+	mergedAt := pr.GetMergedAt().Time
+	result := make([]*github.PullRequestReview, 0, len(reviews))
+	for _, review := range reviews {
+		if !review.GetSubmittedAt().After(mergedAt) {
+			result = append(result, review)
+		}
+	}
+	return result
+}
+
+// countPostMergeApprovals counts the approving reviews submitted after the PR was merged
+func countPostMergeApprovals(pr *github.PullRequest, reviews []*github.PullRequestReview) int {
+	if !isMerged(pr) || pr.MergedAt == nil {
+		return 0
+	}
+
+	mergedAt := pr.GetMergedAt().Time
+	count := 0
+	for _, review := range reviews {
+		if ReviewState(review.GetState()) == ReviewApproved && review.GetSubmittedAt().After(mergedAt) {
+			count++
+		}
+	}
+	return count
+}
+
+// firstReviewRequestTimes returns the time of the first review_requested event naming each
+// individual reviewer. Team requests are not included.
+func firstReviewRequestTimes(timeline []*github.Timeline) map[string]time.Time {
+	firstRequested := make(map[string]time.Time)
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) != EventReviewRequested || event.CreatedAt == nil {
+			continue
+		}
+		reviewer := event.GetReviewer().GetLogin()
+		if requestedAt, ok := firstRequested[reviewer]; reviewer != "" && (!ok || event.GetCreatedAt().Before(requestedAt)) {
+			firstRequested[reviewer] = event.GetCreatedAt().Time
+		}
+	}
+	return firstRequested
+}
+
+// calculateMedianReviewerResponse returns the median, across requested reviewers who
+// responded, of the hours from a reviewer's first review request to their first review or
+// comment after it. Nil when no requested reviewer responded.
+func calculateMedianReviewerResponse(reviews []*github.PullRequestReview, comments []*github.IssueComment, reviewComments []*github.PullRequestComment, timeline []*github.Timeline) *float64 {
+	firstRequested := firstReviewRequestTimes(timeline)
+	firstResponse := make(map[string]time.Time)
+	recordResponse := func(username string, at time.Time) {
+		requestedAt, ok := firstRequested[username]
+		if !ok || at.Before(requestedAt) {
+			return
+		}
+		if respondedAt, ok := firstResponse[username]; !ok || at.Before(respondedAt) {
+			firstResponse[username] = at
+		}
+	}
+
+	for _, review := range reviews {
+		if review.SubmittedAt != nil {
+			recordResponse(userLogin(review.GetUser()), review.GetSubmittedAt().Time)
+		}
+	}
 	for _, comment := range comments {
-		allComments = append(allComments, comment.GetCreatedAt().Time)
+		if comment.CreatedAt != nil {
+			recordResponse(userLogin(comment.GetUser()), comment.GetCreatedAt().Time)
+		}
 	}
-	for _, reviewComment := range reviewComments {
-		allComments = append(allComments, reviewComment.GetCreatedAt().Time)
+	for _, comment := range reviewComments {
+		if comment.CreatedAt != nil {
+			recordResponse(userLogin(comment.GetUser()), comment.GetCreatedAt().Time)
+		}
 	}
 
-	if len(allComments) > 0 {
-		// Sort all comment timestamps to get the first one
-		sort.Slice(allComments, func(i, j int) bool {
-			return allComments[i].Before(allComments[j])
-		})
-		utcTime := formatToUTC(allComments[0].Format(time.RFC3339))
-		timestamps.FirstComment = &utcTime
+	if len(firstResponse) == 0 {
+		return nil
 	}
+	latencies := make([]float64, 0, len(firstResponse))
+	for username, respondedAt := range firstResponse {
+		latencies = append(latencies, respondedAt.Sub(firstRequested[username]).Hours())
+	}
+	median := percentile(latencies, 50)
+	return &median
+}
 
-	// First and second approvals (from reviews)
-	var approvals []*github.PullRequestReview
+// getReviewerStats summarizes the reviews of each reviewer other than the author, sorted
+// by username. An approver's TimeToApprovalHours runs from the first review_requested
+// event naming them to their first approval after it.
+func getReviewerStats(reviews []*github.PullRequestReview, timeline []*github.Timeline, authorUsername string) []ReviewerStat {
+	firstRequested := firstReviewRequestTimes(timeline)
+
+	stats := make(map[string]*ReviewerStat)
+	approvedAt := make(map[string]time.Time)
 	for _, review := range reviews {
-		if review.GetState() == "APPROVED" {
-			approvals = append(approvals, review)
+		username := userLogin(review.GetUser())
+		if username == authorUsername {
+			continue
+		}
+		stat, ok := stats[username]
+		if !ok {
+			stat = &ReviewerStat{Username: username}
+			stats[username] = stat
+		}
+		stat.NumReviews++
+
+		requestedAt, requested := firstRequested[username]
+		if !requested || ReviewState(review.GetState()) != ReviewApproved || review.SubmittedAt == nil {
+			continue
+		}
+		submitted := review.GetSubmittedAt().Time
+		if submitted.Before(requestedAt) {
+			continue
+		}
+		if previous, ok := approvedAt[username]; !ok || submitted.Before(previous) {
+			approvedAt[username] = submitted
+			hours := submitted.Sub(requestedAt).Hours()
+			stat.TimeToApprovalHours = &hours
 		}
 	}
 
-	// Sort approvals by submission time
-	sort.Slice(approvals, func(i, j int) bool {
-		return approvals[i].GetSubmittedAt().Before(approvals[j].GetSubmittedAt().Time)
-	})
+	result := make([]ReviewerStat, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Username < result[j].Username })
+	return result
+}
 
-	if len(approvals) > 0 {
-		utcTime := formatToUTC(approvals[0].GetSubmittedAt().Format(time.RFC3339))
-		timestamps.FirstApproval = &utcTime
+// countReviewCommentsByReviewer returns the number of inline review comments each user
+// other than the author left. Nil when there are none, so the field is omitted; the JSON
+// encoder writes map keys in sorted order.
+func countReviewCommentsByReviewer(reviewComments []*github.PullRequestComment, authorUsername string) map[string]int {
+	var counts map[string]int
+	for _, comment := range reviewComments {
+		username := userLogin(comment.GetUser())
+		if username == authorUsername {
+			continue
+		}
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		counts[username]++
 	}
-	if len(approvals) > 1 {
-		utcTime := formatToUTC(approvals[1].GetSubmittedAt().Format(time.RFC3339))
-		timestamps.SecondApproval = &utcTime
+	return counts
+}
+
+// getApproversWhoCommitted returns the approvers who also authored commits in the PR,
+// i.e. reviewers who approved changes that include their own work. Sorted for consistent output.
+func getApproversWhoCommitted(approvers []string, commits []*github.RepositoryCommit) []string {
+	commitAuthors := make(map[string]bool)
+	for _, commit := range commits {
+		if login := commit.GetAuthor().GetLogin(); login != "" {
+			commitAuthors[login] = true
+		}
 	}
 
-	return timestamps
+	var result []string
+	for _, approver := range approvers {
+		if commitAuthors[approver] {
+			result = append(result, approver)
+		}
+	}
+	sort.Strings(result)
+	return result
 }
 
-func formatToUTC(timestamp string) string {
-	t, err := time.Parse(time.RFC3339, timestamp)
-	if err != nil {
-		return timestamp // Return original if parsing fails
+func getCommenters(comments []*github.IssueComment, reviewComments []*github.PullRequestComment, authorUsername string) map[string]bool {
+	commenters := make(map[string]bool)
+
+	// Process regular comments
+	for _, comment := range comments {
+		if userLogin(comment.GetUser()) != authorUsername {
+			commenters[userLogin(comment.GetUser())] = true
+		}
 	}
-	return t.UTC().Format(time.RFC3339)
+
+	// Process review comments
+	for _, reviewComment := range reviewComments {
+		if userLogin(reviewComment.GetUser()) != authorUsername {
+			commenters[userLogin(reviewComment.GetUser())] = true
+		}
+	}
+
+	return commenters
 }
 
-func calculatePRSize(files []*github.CommitFile) *PRSize {
-	size := &PRSize{
-		LinesChanged: 0,
-		FilesChanged: len(files),
+// maxThreadLength returns the number of comments in the longest review comment thread.
+// Replies are grouped with their thread's root by following the in_reply_to_id chain;
+// a reply whose parent isn't in the list starts its own thread.
+func maxThreadLength(reviewComments []*github.PullRequestComment) int {
+	parents := make(map[int64]int64, len(reviewComments))
+	for _, comment := range reviewComments {
+		if comment.InReplyTo != nil {
+			parents[comment.GetID()] = comment.GetInReplyTo()
+		}
+	}
+	known := make(map[int64]bool, len(reviewComments))
+	for _, comment := range reviewComments {
+		known[comment.GetID()] = true
 	}
 
-	for _, file := range files {
-		// Count total lines changed (additions + deletions)
-		size.LinesChanged += file.GetAdditions() + file.GetDeletions()
+	threadSizes := make(map[int64]int)
+	longest := 0
+	for _, comment := range reviewComments {
+		root := comment.GetID()
+		// Bounded walk in case of a malformed reply cycle
+		for steps := 0; steps < len(reviewComments); steps++ {
+			parent, ok := parents[root]
+			if !ok || !known[parent] {
+				break
+			}
+			root = parent
+		}
+		threadSizes[root]++
+		if threadSizes[root] > longest {
+			longest = threadSizes[root]
+		}
 	}
+	return longest
+}
 
-	return size
+func countTotalComments(comments []*github.IssueComment, reviewComments []*github.PullRequestComment) int {
+	return len(comments) + len(reviewComments)
+}
+
+// countEditedComments counts the conversation and review comments last updated more than
+// threshold after they were created
+func countEditedComments(comments []*github.IssueComment, reviewComments []*github.PullRequestComment, threshold time.Duration) int {
+	edited := func(createdAt, updatedAt *github.Timestamp) bool {
+		return createdAt != nil && updatedAt != nil && updatedAt.Sub(createdAt.Time) > threshold
+	}
+
+	count := 0
+	for _, comment := range comments {
+		if edited(comment.CreatedAt, comment.UpdatedAt) {
+			count++
+		}
+	}
+	for _, comment := range reviewComments {
+		if edited(comment.CreatedAt, comment.UpdatedAt) {
+			count++
+		}
+	}
+	return count
+}
+
+// editedCommentThreshold returns Config.EditedCommentThreshold, or
+// DefaultEditedCommentThreshold when unset
+func (a *Analyzer) editedCommentThreshold() time.Duration {
+	if a.config.EditedCommentThreshold > 0 {
+		return a.config.EditedCommentThreshold
+	}
+	return DefaultEditedCommentThreshold
+}
+
+func getCommenterUsernames(commenters map[string]bool) []string {
+	usernames := make([]string, 0, len(commenters))
+	for username := range commenters {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames) // Sort for consistent output
+	return usernames
+}
+
+// isCrossRepo reports whether the PR's head branch lives in a different repository than
+// its base, i.e. the PR was opened from a fork
+func isCrossRepo(pr *github.PullRequest) bool {
+	return pr.GetHead().GetRepo().GetFullName() != pr.GetBase().GetRepo().GetFullName()
+}
+
+// isBaseBranchMissing reports whether the PR's base branch data is absent, which happens
+// for old PRs whose base branch has since been deleted
+func isBaseBranchMissing(pr *github.PullRequest) bool {
+	return pr.GetBase().GetRef() == "" || pr.GetBase().GetSHA() == ""
+}
+
+// getAssignees returns the logins of the PR's assignees, sorted alphabetically.
+func getAssignees(pr *github.PullRequest) []string {
+	assignees := make([]string, 0, len(pr.Assignees))
+	for _, assignee := range pr.Assignees {
+		if login := assignee.GetLogin(); login != "" {
+			assignees = append(assignees, login)
+		}
+	}
+	sort.Strings(assignees)
+	return assignees
+}
+
+// filterByReviewers keeps only the reviews and comments left by the given users
+func filterByReviewers(users []string, reviews []*github.PullRequestReview, comments []*github.IssueComment, reviewComments []*github.PullRequestComment) ([]*github.PullRequestReview, []*github.IssueComment, []*github.PullRequestComment) {
+	allowed := allowedUsers(users)
+
+	var filteredReviews []*github.PullRequestReview
+	for _, review := range reviews {
+		if allowed[userLogin(review.GetUser())] {
+			filteredReviews = append(filteredReviews, review)
+		}
+	}
+
+	var filteredComments []*github.IssueComment
+	for _, comment := range comments {
+		if allowed[userLogin(comment.GetUser())] {
+			filteredComments = append(filteredComments, comment)
+		}
+	}
+
+	var filteredReviewComments []*github.PullRequestComment
+	for _, comment := range reviewComments {
+		if allowed[userLogin(comment.GetUser())] {
+			filteredReviewComments = append(filteredReviewComments, comment)
+		}
+	}
+
+	return filteredReviews, filteredComments, filteredReviewComments
+}
+
+// filterReviewRequests returns a copy of the PR whose requested reviewers are limited to
+// the given users, and a copy of the timeline without the review_requested and
+// review_request_removed events naming anyone else. Team requests are kept; with
+// ExpandTeamReviewers the member events they expand to are filtered in turn.
+func filterReviewRequests(users []string, pr *github.PullRequest, timeline []*github.Timeline) (*github.PullRequest, []*github.Timeline) {
+	allowed := allowedUsers(users)
+
+	filteredPR := *pr
+	filteredPR.RequestedReviewers = nil
+	for _, reviewer := range pr.RequestedReviewers {
+		if allowed[userLogin(reviewer)] {
+			filteredPR.RequestedReviewers = append(filteredPR.RequestedReviewers, reviewer)
+		}
+	}
+
+	filteredTimeline := make([]*github.Timeline, 0, len(timeline))
+	for _, event := range timeline {
+		eventType := TimelineEvent(event.GetEvent())
+		isRequest := eventType == EventReviewRequested || eventType == EventReviewRequestRemoved
+		if isRequest && event.Reviewer != nil && !allowed[userLogin(event.Reviewer)] {
+			continue
+		}
+		filteredTimeline = append(filteredTimeline, event)
+	}
+
+	return &filteredPR, filteredTimeline
+}
+
+// allowedUsers returns the set of the given logins
+func allowedUsers(users []string) map[string]bool {
+	allowed := make(map[string]bool, len(users))
+	for _, user := range users {
+		allowed[user] = true
+	}
+	return allowed
+}
+
+func countAllRequestedReviewers(pr *github.PullRequest, reviews []*github.PullRequestReview, timeline []*github.Timeline) int {
+	// Count all reviewers who were validly requested to review (both those who reviewed and those who haven't)
+	return len(validRequestedReviewers(pr, reviews, timeline))
+}
+
+// countReviewersEverRequested counts the distinct users named in review_requested events,
+// including requests that were later removed, to show review-request churn. Team requests
+// only count once expanded to their members.
+func countReviewersEverRequested(timeline []*github.Timeline) int {
+	requested := make(map[string]bool)
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) != EventReviewRequested {
+			continue
+		}
+		if reviewer := event.GetReviewer().GetLogin(); reviewer != "" {
+			requested[reviewer] = true
+		}
+	}
+	return len(requested)
+}
+
+// neverRequestedReview reports whether a review was never requested from anyone, neither
+// through a review_requested event nor as a currently requested reviewer or team. Metrics
+// measured from the first review request are nil for such PRs, as for drafts closed
+// without being marked ready.
+func neverRequestedReview(pr *github.PullRequest, timeline []*github.Timeline) bool {
+	if len(pr.RequestedReviewers) > 0 || len(pr.RequestedTeams) > 0 {
+		return false
+	}
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) == EventReviewRequested {
+			return false
+		}
+	}
+	return true
+}
+
+// validRequestedReviewers returns the reviewers whose review was validly requested, mapped
+// to whether they submitted a review. Anyone who reviewed counts as requested. A request
+// withdrawn by a review_request_removed event before the reviewer responded doesn't count,
+// unless the reviewer was requested again; current requested reviewers always count.
+func validRequestedReviewers(pr *github.PullRequest, reviews []*github.PullRequestReview, timeline []*github.Timeline) map[string]bool {
+	requested := make(map[string]bool)
+	reviewTimes := make(map[string][]time.Time)
+
+	// Add users who have submitted reviews (they must have been requested to review)
+	for _, review := range reviews {
+		login := userLogin(review.GetUser())
+		requested[login] = true
+		if review.SubmittedAt != nil {
+			reviewTimes[login] = append(reviewTimes[login], review.GetSubmittedAt().Time)
+		}
+	}
+
+	pending := make(map[string]bool)
+	for _, event := range timeline {
+		reviewer := event.GetReviewer().GetLogin()
+		if reviewer == "" {
+			continue
+		}
+		switch TimelineEvent(event.GetEvent()) {
+		case EventReviewRequested:
+			pending[reviewer] = true
+		case EventReviewRequestRemoved:
+			if !reviewedBetween(reviewTimes[reviewer], time.Time{}, event.GetCreatedAt().Time) {
+				delete(pending, reviewer)
+			}
+		}
+	}
+
+	// Add current requested reviewers (those who haven't reviewed yet)
+	for _, reviewer := range pr.RequestedReviewers {
+		pending[reviewer.GetLogin()] = true
+	}
+
+	for reviewer := range pending {
+		if !requested[reviewer] {
+			requested[reviewer] = false
+		}
+	}
+	return requested
+}
+
+// countUnfulfilledReviewRequests counts validly requested reviewers who never submitted a
+// review. Withdrawn requests are not unfulfilled.
+func countUnfulfilledReviewRequests(pr *github.PullRequest, reviews []*github.PullRequestReview, timeline []*github.Timeline) int {
+	unfulfilled := 0
+	for _, reviewed := range validRequestedReviewers(pr, reviews, timeline) {
+		if !reviewed {
+			unfulfilled++
+		}
+	}
+	return unfulfilled
+}
+
+func getTimestamps(pr *github.PullRequest, reviews []*github.PullRequestReview, comments []*github.IssueComment, reviewComments []*github.PullRequestComment, timeline []*github.Timeline, commits []*github.RepositoryCommit) *Timestamps {
+	timestamps := &Timestamps{}
+
+	// First commit timestamp (from commits)
+	if len(commits) > 0 {
+		// Sort commits by date to get the first one
+		sort.Slice(commits, func(i, j int) bool {
+			return commits[i].GetCommit().GetAuthor().GetDate().Before(commits[j].GetCommit().GetAuthor().GetDate().Time)
+		})
+		utcTime := formatToUTC(commits[0].GetCommit().GetAuthor().GetDate().Format(time.RFC3339))
+		timestamps.FirstCommit = &utcTime
+	}
+
+	// Created timestamp (from PR)
+	if !pr.GetCreatedAt().IsZero() {
+		utcTime := formatToUTC(pr.GetCreatedAt().Format(time.RFC3339))
+		timestamps.CreatedAt = &utcTime
+	}
+
+	// Merged and closed timestamps (from PR)
+	if pr.MergedAt != nil && !pr.GetMergedAt().IsZero() {
+		utcTime := formatToUTC(pr.GetMergedAt().Format(time.RFC3339))
+		timestamps.MergedAt = &utcTime
+	}
+	if pr.ClosedAt != nil && !pr.GetClosedAt().IsZero() {
+		utcTime := formatToUTC(pr.GetClosedAt().Format(time.RFC3339))
+		timestamps.ClosedAt = &utcTime
+	}
+
+	// First review request (from timeline)
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) == EventReviewRequested && timestamps.FirstReviewRequest == nil {
+			utcTime := formatToUTC(event.GetCreatedAt().Format(time.RFC3339))
+			timestamps.FirstReviewRequest = &utcTime
+			break
+		}
+	}
+
+	// First comment (from both regular comments and review comments)
+	var allComments []time.Time
+
+	// Collect all comment timestamps
+	for _, comment := range comments {
+		allComments = append(allComments, comment.GetCreatedAt().Time)
+	}
+	for _, reviewComment := range reviewComments {
+		allComments = append(allComments, reviewComment.GetCreatedAt().Time)
+	}
+
+	if len(allComments) > 0 {
+		// Sort all comment timestamps to get the first one
+		sort.Slice(allComments, func(i, j int) bool {
+			return allComments[i].Before(allComments[j])
+		})
+		utcTime := formatToUTC(allComments[0].Format(time.RFC3339))
+		timestamps.FirstComment = &utcTime
+	}
+
+	// First and second approvals (from reviews)
+	var approvals []*github.PullRequestReview
+	for _, review := range reviews {
+		if ReviewState(review.GetState()) == ReviewApproved {
+			approvals = append(approvals, review)
+		}
+	}
+
+	// Sort approvals by submission time, keeping the input order for simultaneous approvals
+	sort.SliceStable(approvals, func(i, j int) bool {
+		return approvals[i].GetSubmittedAt().Before(approvals[j].GetSubmittedAt().Time)
+	})
+
+	if len(approvals) > 0 {
+		utcTime := formatToUTC(approvals[0].GetSubmittedAt().Format(time.RFC3339))
+		timestamps.FirstApproval = &utcTime
+		firstApprover := userLogin(approvals[0].GetUser())
+		timestamps.FirstApprover = &firstApprover
+	}
+	if len(approvals) > 1 {
+		utcTime := formatToUTC(approvals[1].GetSubmittedAt().Format(time.RFC3339))
+		timestamps.SecondApproval = &utcTime
+		lastTime := formatToUTC(approvals[len(approvals)-1].GetSubmittedAt().Format(time.RFC3339))
+		timestamps.LastApproval = &lastTime
+	}
+
+	return timestamps
+}
+
+func formatToUTC(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp // Return original if parsing fails
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatOutputTime converts an RFC3339 timestamp to the configured output timezone.
+// All internal calculations stay in UTC; only the rendered value changes.
+func (a *Analyzer) formatOutputTime(timestamp string) string {
+	return formatInLocation(timestamp, a.location)
+}
+
+// labelEvents returns the labeled and unlabeled timeline events in chronological order.
+// Events without a timestamp are skipped.
+func (a *Analyzer) labelEvents(timeline []*github.Timeline) []LabelEvent {
+	var events []*github.Timeline
+	for _, event := range timeline {
+		switch TimelineEvent(event.GetEvent()) {
+		case EventLabeled, EventUnlabeled:
+			if event.CreatedAt != nil {
+				events = append(events, event)
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].GetCreatedAt().Before(events[j].GetCreatedAt().Time) })
+
+	var labelEvents []LabelEvent
+	for _, event := range events {
+		action := LabelAdded
+		if TimelineEvent(event.GetEvent()) == EventUnlabeled {
+			action = LabelRemoved
+		}
+		labelEvents = append(labelEvents, LabelEvent{
+			Label:     event.GetLabel().GetName(),
+			Action:    action,
+			CreatedAt: a.formatOutputTime(event.GetCreatedAt().UTC().Format(time.RFC3339)),
+		})
+	}
+	return labelEvents
+}
+
+// commitEntries lists the PR's commits in the order GitHub returns them, oldest first,
+// keeping the first maxCommits when it is positive. Author dates are always UTC, whatever
+// Config.OutputTimezone says. The second result reports whether any commits were left out.
+func commitEntries(commits []*github.RepositoryCommit, maxCommits int) ([]CommitEntry, bool) {
+	truncated := false
+	if maxCommits > 0 && len(commits) > maxCommits {
+		commits = commits[:maxCommits]
+		truncated = true
+	}
+
+	entries := make([]CommitEntry, 0, len(commits))
+	for _, commit := range commits {
+		entry := CommitEntry{
+			SHA:         commit.GetSHA(),
+			AuthorLogin: commit.GetAuthor().GetLogin(),
+			Subject:     strings.SplitN(commit.GetCommit().GetMessage(), "\n", 2)[0],
+		}
+		if date := commit.GetCommit().GetAuthor().GetDate(); !date.IsZero() {
+			entry.AuthoredAt = date.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, truncated
+}
+
+func (a *Analyzer) localizeTimestamps(timestamps *PRTimestamps) *PRTimestamps {
+	if a.location == nil || a.location == time.UTC {
+		return timestamps
+	}
+
+	for _, field := range []**string{
+		&timestamps.FirstCommit,
+		&timestamps.CreatedAt,
+		&timestamps.FirstReviewRequest,
+		&timestamps.FirstComment,
+		&timestamps.FirstApproval,
+		&timestamps.SecondApproval,
+		&timestamps.MergedAt,
+		&timestamps.ClosedAt,
+		&timestamps.ReleaseCreatedAt,
+	} {
+		if *field != nil {
+			local := formatInLocation(**field, a.location)
+			*field = &local
+		}
+	}
+
+	return timestamps
+}
+
+// isWeekend reports whether t falls on a Saturday or Sunday in loc (UTC when nil)
+func isWeekend(t time.Time, loc *time.Location) bool {
+	if loc == nil {
+		loc = time.UTC
+	}
+	day := t.In(loc).Weekday()
+	return day == time.Saturday || day == time.Sunday
+}
+
+func formatInLocation(timestamp string, loc *time.Location) string {
+	if loc == nil {
+		return formatToUTC(timestamp)
+	}
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp // Return original if parsing fails
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+func calculatePRSize(files []*github.CommitFile) *PRSize {
+	size := &PRSize{
+		LinesChanged: 0,
+		FilesChanged: len(files),
+	}
+
+	for _, file := range files {
+		// Count total lines changed (additions + deletions)
+		size.LinesChanged += file.GetAdditions() + file.GetDeletions()
+	}
+
+	return size
+}
+
+// isEmptyPR reports whether the PR changes no files. The file list is the PR's diff against
+// its merge base, so a PR whose only commits are merges of changes already on the base is
+// empty, while one whose merge commits bring in other changes is not. A file list cut
+// short by Config.MaxAnalysisDuration says nothing either way, so it never counts as empty.
+func isEmptyPR(size *PRSize, truncated []string) bool {
+	return size.FilesChanged == 0 && !slices.Contains(truncated, "files")
+}
+
+// calculatePatchBytes sums the length of each file's diff patch. Binary files and
+// diffs too large for the API have no patch and contribute nothing.
+func calculatePatchBytes(files []*github.CommitFile) int {
+	total := 0
+	for _, file := range files {
+		if file.Patch == nil {
+			continue
+		}
+		total += len(file.GetPatch())
+	}
+	return total
+}
+
+// calculateEngagementIndex combines the PR's comment, change request, approver and
+// commenter counts into one number using weights, or DefaultEngagementWeights when weights
+// is the zero value
+func calculateEngagementIndex(details *PRDetails, weights EngagementWeights) float64 {
+	if weights == (EngagementWeights{}) {
+		weights = DefaultEngagementWeights
+	}
+	return weights.Comments*float64(details.NumComments) +
+		weights.ChangeRequests*float64(details.ChangeRequestsCount) +
+		weights.Approvers*float64(details.NumApprovers) +
+		weights.Commenters*float64(details.NumCommenters)
+}
+
+// classifyPRSize maps the number of changed lines to a size bucket (XS, S, M, L, XL)
+func classifyPRSize(linesChanged int, buckets SizeBuckets) string {
+	if buckets == (SizeBuckets{}) {
+		buckets = DefaultSizeBuckets
+	}
+
+	switch {
+	case linesChanged < buckets.XS:
+		return "XS"
+	case linesChanged < buckets.S:
+		return "S"
+	case linesChanged < buckets.M:
+		return "M"
+	case linesChanged < buckets.L:
+		return "L"
+	default:
+		return "XL"
+	}
+}
+
+func findReleaseForMergedPR(pr *github.PullRequest, releases []*github.RepositoryRelease, includePreReleases bool) (*string, *string, bool) {
+	releaseInfo := findReleaseInfoForMergedPR(pr, releases, includePreReleases)
+	if releaseInfo == nil {
+		return nil, nil, false
+	}
+	return &releaseInfo.Name, &releaseInfo.CreatedAt, releaseInfo.Prerelease
+}
+
+// findReleaseInfoForMergedPR returns the first release published after the PR was merged.
+// Pre-releases and drafts are skipped unless includePreReleases is set.
+func findReleaseInfoForMergedPR(pr *github.PullRequest, releases []*github.RepositoryRelease, includePreReleases bool) *ReleaseInfo {
+	// Only check for releases if the PR was merged and the repository has any
+	// A merge without merged_at has no time to compare releases against
+	if !isMerged(pr) || pr.MergedAt == nil || len(releases) == 0 {
+		return nil
+	}
+
+	mergedTime := pr.GetMergedAt().Time
+
+	// Find releases published after the PR was merged
+	var validReleases []*github.RepositoryRelease
+	for _, release := range releases {
+		if release.PublishedAt == nil || release.GetPublishedAt().IsZero() {
+			continue
+		}
+		if !includePreReleases && (release.GetPrerelease() || release.GetDraft()) {
+			continue
+		}
+
+		publishedTime := release.GetPublishedAt().Time
+
+		// If the release was published after the PR was merged,
+		// this PR is likely included in this release
+		if publishedTime.After(mergedTime) {
+			validReleases = append(validReleases, release)
+		}
+	}
+
+	if len(validReleases) == 0 {
+		return nil
+	}
+
+	// Sort valid releases by published date (oldest first) to get the first release after merge
+	sort.Slice(validReleases, func(i, j int) bool {
+		return validReleases[i].GetPublishedAt().Before(validReleases[j].GetPublishedAt().Time)
+	})
+
+	// Return the first (earliest) release after merge
+	release := validReleases[0]
+	releaseName := release.GetName()
+	if releaseName == "" {
+		releaseName = release.GetTagName()
+	}
+
+	var releaseCreatedAt string
+	if release.CreatedAt != nil && !release.GetCreatedAt().IsZero() {
+		releaseCreatedAt = formatToUTC(release.GetCreatedAt().Format(time.RFC3339))
+	}
+
+	return &ReleaseInfo{
+		Name:       releaseName,
+		CreatedAt:  releaseCreatedAt,
+		Prerelease: release.GetPrerelease() || release.GetDraft(),
+	}
+}
+
+// countReviewRequestRounds counts review request cycles per reviewer. A reviewer's first
+// request starts a round; a later request only starts a new one if that reviewer has
+// submitted a review since their previous round began, so repeated requests for a
+// reviewer who hasn't responded yet are counted once. Team requests are keyed by team.
+func countReviewRequestRounds(timeline []*github.Timeline, reviews []*github.PullRequestReview) int {
+	reviewTimes := make(map[string][]time.Time)
+	for _, review := range reviews {
+		if review.SubmittedAt != nil {
+			login := userLogin(review.GetUser())
+			reviewTimes[login] = append(reviewTimes[login], review.GetSubmittedAt().Time)
+		}
+	}
+
+	roundStarts := make(map[string]time.Time)
+	rounds := 0
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) != EventReviewRequested {
+			continue
+		}
+		reviewer := event.GetReviewer().GetLogin()
+		if reviewer == "" {
+			if slug := event.GetRequestedTeam().GetSlug(); slug != "" {
+				reviewer = "team:" + slug
+			}
+		}
+		if reviewer == "" {
+			continue
+		}
+
+		requestedAt := event.GetCreatedAt().Time
+		previous, requested := roundStarts[reviewer]
+		if requested && !reviewedBetween(reviewTimes[reviewer], previous, requestedAt) {
+			continue
+		}
+		roundStarts[reviewer] = requestedAt
+		rounds++
+	}
+	return rounds
+}
+
+// reviewedBetween reports whether any of the review times falls after start and no later than end
+func reviewedBetween(reviewTimes []time.Time, start, end time.Time) bool {
+	for _, reviewed := range reviewTimes {
+		if reviewed.After(start) && !reviewed.After(end) {
+			return true
+		}
+	}
+	return false
+}
+
+func countCommitsAfterFirstReview(commits []*github.RepositoryCommit, timeline []*github.Timeline) int {
+	// Find the first review request timestamp
+	var firstReviewRequestTime *time.Time
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) == EventReviewRequested {
+			t := event.GetCreatedAt().Time
+			firstReviewRequestTime = &t
+			break
+		}
+	}
+
+	// If no review request was made, return 0
+	if firstReviewRequestTime == nil {
+		return 0
+	}
+
+	// Count commits made after the first review request
+	count := 0
+	for _, commit := range commits {
+		commitTime := commit.GetCommit().GetAuthor().GetDate().Time
+		if commitTime.After(*firstReviewRequestTime) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// pushClusterGap is the largest gap between the committer dates of consecutive commits by
+// the same committer that are still counted as one push
+const pushClusterGap = 5 * time.Minute
+
+// countPushes estimates how many batches the PR's commits arrived in. GitHub doesn't
+// report pushes for a PR, so commits are clustered instead: sorted by committer date, a
+// commit starts a new push when its committer differs from the previous commit's or its
+// committer date is more than pushClusterGap later. Commits created together, such as by a
+// rebase, share a committer date and count once. Each head_ref_force_pushed timeline event
+// adds one push for the history it replaced, whose commits are no longer listed.
+func countPushes(commits []*github.RepositoryCommit, timeline []*github.Timeline) int {
+	type commitInfo struct {
+		committer string
+		date      time.Time
+	}
+	var sorted []commitInfo
+	for _, commit := range commits {
+		date := commit.GetCommit().GetCommitter().GetDate()
+		if date.IsZero() {
+			date = commit.GetCommit().GetAuthor().GetDate()
+		}
+		committer := commit.GetCommitter().GetLogin()
+		if committer == "" {
+			committer = commit.GetCommit().GetCommitter().GetEmail()
+		}
+		sorted = append(sorted, commitInfo{committer: committer, date: date.Time})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].date.Before(sorted[j].date) })
+
+	pushes := 0
+	for i, commit := range sorted {
+		if i == 0 || commit.committer != sorted[i-1].committer || commit.date.Sub(sorted[i-1].date) > pushClusterGap {
+			pushes++
+		}
+	}
+
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) == EventHeadRefForcePushed {
+			pushes++
+		}
+	}
+	return pushes
+}
+
+// countApprovalsAroundLastCommit splits approvals by whether they were submitted before or
+// after the author date of the PR's last commit. Approvals before it were given on code that
+// has since changed. Without commits every approval counts as after.
+func countApprovalsAroundLastCommit(reviews []*github.PullRequestReview, commits []*github.RepositoryCommit) (before, after int) {
+	var lastCommit time.Time
+	for _, commit := range commits {
+		if commitTime := commit.GetCommit().GetAuthor().GetDate().Time; commitTime.After(lastCommit) {
+			lastCommit = commitTime
+		}
+	}
+
+	for _, review := range reviews {
+		if ReviewState(review.GetState()) != ReviewApproved || review.SubmittedAt == nil {
+			continue
+		}
+		if review.GetSubmittedAt().Before(lastCommit) {
+			before++
+		} else {
+			after++
+		}
+	}
+	return before, after
+}
+
+func countChangeRequests(reviews []*github.PullRequestReview) int {
+	count := 0
+	for _, review := range reviews {
+		if ReviewState(review.GetState()) == ReviewChangesRequested {
+			count++
+		}
+	}
+	return count
+}
+
+func countDistinctChangeRequesters(reviews []*github.PullRequestReview) int {
+	requesters := make(map[string]bool)
+	for _, review := range reviews {
+		if ReviewState(review.GetState()) == ReviewChangesRequested {
+			requesters[userLogin(review.GetUser())] = true
+		}
+	}
+	return len(requesters)
+}
+
+// countChangeRequestResolutions counts, per reviewer, transitions from a CHANGES_REQUESTED
+// review to a later APPROVED review by the same reviewer. Several change requests followed
+// by a single approval count as one resolution.
+func countChangeRequestResolutions(reviews []*github.PullRequestReview) int {
+	sorted := make([]*github.PullRequestReview, len(reviews))
+	copy(sorted, reviews)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetSubmittedAt().Before(sorted[j].GetSubmittedAt().Time)
+	})
+
+	pending := make(map[string]bool)
+	resolutions := 0
+	for _, review := range sorted {
+		reviewer := userLogin(review.GetUser())
+		switch ReviewState(review.GetState()) {
+		case ReviewChangesRequested:
+			pending[reviewer] = true
+		case ReviewApproved:
+			if pending[reviewer] {
+				resolutions++
+				pending[reviewer] = false
+			}
+		}
+	}
+	return resolutions
+}
+
+// mergedOverUnresolvedChangeRequest reports whether a merged PR still had a change request
+// outstanding at merge time: a reviewer whose latest approving or change-requesting review
+// before the merge requested changes. Dismissed change requests no longer count, since
+// GitHub changes their state to DISMISSED.
+func mergedOverUnresolvedChangeRequest(pr *github.PullRequest, reviews []*github.PullRequestReview) bool {
+	if !isMerged(pr) {
+		return false
+	}
+
+	sorted := make([]*github.PullRequestReview, len(reviews))
+	copy(sorted, reviews)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetSubmittedAt().Before(sorted[j].GetSubmittedAt().Time)
+	})
+
+	pending := make(map[string]bool)
+	for _, review := range sorted {
+		if pr.MergedAt != nil && review.GetSubmittedAt().After(pr.GetMergedAt().Time) {
+			break
+		}
+		reviewer := userLogin(review.GetUser())
+		switch ReviewState(review.GetState()) {
+		case ReviewChangesRequested:
+			pending[reviewer] = true
+		case ReviewApproved:
+			pending[reviewer] = false
+		}
+	}
+
+	for _, unresolved := range pending {
+		if unresolved {
+			return true
+		}
+	}
+	return false
+}
+
+// countThreadResolutions attributes each resolved review thread to either the PR author
+// or a reviewer (anyone else). Threads resolved by an unknown user are not counted.
+func countThreadResolutions(threads []reviewThread, authorUsername string) (int, int) {
+	byAuthor := 0
+	byReviewer := 0
+	for _, thread := range threads {
+		if !thread.IsResolved || thread.ResolvedBy == "" {
+			continue
+		}
+		if thread.ResolvedBy == authorUsername {
+			byAuthor++
+		} else {
+			byReviewer++
+		}
+	}
+	return byAuthor, byReviewer
+}
+
+// applyBranchProtection populates the branch protection fields from the base branch rules.
+// An unprotected branch (nil protection) has no requirements and is always met.
+// Only the required approval count is evaluated for MetBranchProtection; code owner
+// and linear history requirements are reported but can't be verified from PR data.
+func applyBranchProtection(details *PRDetails, protection *github.Protection) {
+	requiredReviews := 0
+	requiresCodeowner := false
+	requiresLinearHistory := false
+
+	if protection != nil {
+		if reviews := protection.GetRequiredPullRequestReviews(); reviews != nil {
+			requiredReviews = reviews.RequiredApprovingReviewCount
+			requiresCodeowner = reviews.RequireCodeOwnerReviews
+		}
+		if linearHistory := protection.GetRequireLinearHistory(); linearHistory != nil {
+			requiresLinearHistory = linearHistory.Enabled
+		}
+	}
+
+	met := details.NumApprovers >= requiredReviews
+	details.RequiredReviewsFromConfig = &requiredReviews
+	details.RequiresCodeownerReview = &requiresCodeowner
+	details.RequiresLinearHistory = &requiresLinearHistory
+	details.MetBranchProtection = &met
+}
+
+// conventionalCommitTypes lists the recognized conventional-commit types. The order is
+// used to break ties when choosing the primary change type.
+var conventionalCommitTypes = []string{"feat", "fix", "perf", "refactor", "revert", "docs", "test", "build", "ci", "style", "chore"}
+
+// conventionalCommitPattern matches "type: ", "type(scope): " and "type!: " prefixes
+var conventionalCommitPattern = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?!?:\s`)
+
+// classifyCommitTypes counts conventional-commit types across the first line of each
+// commit message and returns the counts along with the most frequent type.
+// Non-conventional messages are ignored; the primary type is empty when none match.
+func classifyCommitTypes(commits []*github.RepositoryCommit) (map[string]int, string) {
+	known := make(map[string]bool, len(conventionalCommitTypes))
+	for _, commitType := range conventionalCommitTypes {
+		known[commitType] = true
+	}
+
+	counts := make(map[string]int)
+	for _, commit := range commits {
+		subject := strings.SplitN(commit.GetCommit().GetMessage(), "\n", 2)[0]
+		match := conventionalCommitPattern.FindStringSubmatch(strings.TrimSpace(subject))
+		if match == nil {
+			continue
+		}
+		commitType := strings.ToLower(match[1])
+		if known[commitType] {
+			counts[commitType]++
+		}
+	}
+
+	primary := ""
+	for _, commitType := range conventionalCommitTypes {
+		if counts[commitType] > counts[primary] {
+			primary = commitType
+		}
+	}
+
+	return counts, primary
+}
+
+func isBot(username string) bool {
+	return strings.Contains(username, "[bot]")
+}
+
+// isBotUser combines the "[bot]" username heuristic with the account type reported by
+// the API when Config.ResolveBotViaAPI is set
+func (a *Analyzer) isBotUser(ctx context.Context, login string) (bool, error) {
+	if isBot(login) {
+		return true, nil
+	}
+	if !a.config.ResolveBotViaAPI || login == "" {
+		return false, nil
+	}
+	user, err := a.fetchUser(ctx, login)
+	if err != nil {
+		return false, err
+	}
+	return user.GetType() == "Bot", nil
+}
+
+var revertTitlePattern = regexp.MustCompile(`^Revert "`)
+
+var defaultAutoGeneratedPatterns = regexp.MustCompile(strings.Join(DefaultAutoGeneratedPatterns, "|"))
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// compileGlobs converts glob patterns to anchored regular expressions. "*" and "?" match
+// within a path segment, "**" matches across segments, and a pattern without a "/" matches
+// the last segment of any path.
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		var expr strings.Builder
+		if !strings.Contains(pattern, "/") {
+			expr.WriteString("(.*/)?")
+		}
+		for i := 0; i < len(pattern); i++ {
+			switch c := pattern[i]; {
+			case strings.HasPrefix(pattern[i:], "**/"):
+				expr.WriteString("(.*/)?")
+				i += 2
+			case strings.HasPrefix(pattern[i:], "**"):
+				expr.WriteString(".*")
+				i++
+			case c == '*':
+				expr.WriteString("[^/]*")
+			case c == '?':
+				expr.WriteString("[^/]")
+			default:
+				expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		}
+		re, err := regexp.Compile("^" + expr.String() + "$")
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// matchChangedFiles returns the changed files matching any of patterns, sorted. A renamed
+// file matches on either its new or previous name.
+func matchChangedFiles(files []*github.CommitFile, patterns []*regexp.Regexp) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	var matched []string
+	for _, file := range files {
+		for _, re := range patterns {
+			if re.MatchString(file.GetFilename()) || (file.GetPreviousFilename() != "" && re.MatchString(file.GetPreviousFilename())) {
+				matched = append(matched, file.GetFilename())
+				break
+			}
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// excludeFiles splits the changed files into those kept and the number whose name matches
+// any of patterns, which the analysis then treats as not part of the PR
+func excludeFiles(files []*github.CommitFile, patterns []*regexp.Regexp) ([]*github.CommitFile, int) {
+	if len(patterns) == 0 {
+		return files, 0
+	}
+
+	kept := make([]*github.CommitFile, 0, len(files))
+	for _, file := range files {
+		excluded := false
+		for _, re := range patterns {
+			if re.MatchString(file.GetFilename()) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, file)
+		}
+	}
+	return kept, len(files) - len(kept)
+}
+
+// isRevert reports whether the PR reverts another one, based on GitHub's revert title format
+func isRevert(pr *github.PullRequest) bool {
+	return revertTitlePattern.MatchString(pr.GetTitle())
+}
+
+// isAutoGenerated reports whether the PR was opened by a bot or its title matches one of
+// the auto-generated patterns. The default patterns are used when none are configured.
+func isAutoGenerated(pr *github.PullRequest, patterns []*regexp.Regexp) bool {
+	if isBot(pr.GetUser().GetLogin()) {
+		return true
+	}
+	if len(patterns) == 0 {
+		return defaultAutoGeneratedPatterns.MatchString(pr.GetTitle())
+	}
+	for _, pattern := range patterns {
+		if pattern.MatchString(pr.GetTitle()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHotfix reports whether the PR's base branch matches one of branchPatterns or the PR
+// carries one of hotfixLabels
+func isHotfix(pr *github.PullRequest, branchPatterns []*regexp.Regexp, hotfixLabels []string) bool {
+	base := pr.GetBase().GetRef()
+	for _, pattern := range branchPatterns {
+		if base != "" && pattern.MatchString(base) {
+			return true
+		}
+	}
+	for _, label := range pr.Labels {
+		for _, hotfixLabel := range hotfixLabels {
+			if strings.EqualFold(label.GetName(), hotfixLabel) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func findValidJiraIssue(pattern *regexp.Regexp, text string) string {
+	// Find all matches in the text
+	matches := pattern.FindAllString(text, -1)
+	for _, match := range matches {
+		upperMatch := strings.ToUpper(match)
+		// Exclude CVE identifiers (security vulnerability IDs)
+		if !strings.HasPrefix(upperMatch, "CVE-") {
+			return upperMatch
+		}
+	}
+	return ""
+}
+
+// extractJiraIssue returns the first Jira issue referenced by the PR's title, body or branch
+// name, or botValue for bot authors and unknownValue for everyone else when there is none.
+func extractJiraIssue(pr *github.PullRequest, unknownValue, botValue string) string {
+	// Jira issue pattern: PROJECT-123, ABC-1234, etc.
+	// Matches project key (2+ uppercase letters or alphanumeric) followed by hyphen and number
+	// Excludes CVE- identifiers which are security vulnerability IDs, not Jira issues
+	jiraPattern := regexp.MustCompile(`\b[A-Z][A-Z0-9]+-\d+\b`)
+
+	// Search in PR title first
+	if issue := findValidJiraIssue(jiraPattern, pr.GetTitle()); issue != "" {
+		return issue
+	}
+
+	// Search in PR body if available
+	if pr.Body != nil && pr.GetBody() != "" {
+		if issue := findValidJiraIssue(jiraPattern, pr.GetBody()); issue != "" {
+			return issue
+		}
+	}
+
+	// Search in branch name (head ref)
+	if issue := findValidJiraIssue(jiraPattern, strings.ToUpper(pr.GetHead().GetRef())); issue != "" {
+		return issue
+	}
+
+	// If not found, check if the user is a bot
+	if isBot(pr.GetUser().GetLogin()) {
+		return botValue
+	}
+
+	// If not a bot and no Jira issue found, return the unknown value
+	return unknownValue
+}
+
+// jiraUnknownValue returns Config.JiraUnknownValue, or DefaultJiraUnknownValue when unset
+func (a *Analyzer) jiraUnknownValue() string {
+	if a.config.JiraUnknownValue != nil {
+		return *a.config.JiraUnknownValue
+	}
+	return DefaultJiraUnknownValue
+}
+
+// jiraBotValue returns Config.JiraBotValue, or DefaultJiraBotValue when unset
+func (a *Analyzer) jiraBotValue() string {
+	if a.config.JiraBotValue != nil {
+		return *a.config.JiraBotValue
+	}
+	return DefaultJiraBotValue
+}
+
+// checkJiraPolicy applies Config.RequireJiraIssue to an extracted Jira issue. It returns
+// ErrJiraIssueMissing, or true when JiraMissingAsFlag is set, for PRs by non-bot authors
+// with no Jira issue. Bot PRs are exempt, even when the bot and unknown values are the same.
+func (a *Analyzer) checkJiraPolicy(jiraIssue string, authorIsBot bool) (bool, error) {
+	if !a.config.RequireJiraIssue || authorIsBot || jiraIssue != a.jiraUnknownValue() {
+		return false, nil
+	}
+	if a.config.JiraMissingAsFlag {
+		return true, nil
+	}
+	return false, ErrJiraIssueMissing
+}
+
+func calculatePRMetrics(pr *github.PullRequest, reviews []*github.PullRequestReview, comments []*github.IssueComment, timeline []*github.Timeline, timestamps *Timestamps, now time.Time) *PRMetrics {
+	metrics := &PRMetrics{}
+
+	// Draft Time: time from PR creation to first review request, minimum 0
+	draftHours := 0.0
+	if timestamps.CreatedAt != nil && timestamps.FirstReviewRequest != nil {
+		if createdTime, err := time.Parse(time.RFC3339, *timestamps.CreatedAt); err == nil {
+			if firstReviewRequestTime, err := time.Parse(time.RFC3339, *timestamps.FirstReviewRequest); err == nil {
+				if firstReviewRequestTime.After(createdTime) {
+					draftHours = firstReviewRequestTime.Sub(createdTime).Hours()
+				}
+			}
+		}
+	}
+	metrics.DraftTimeHours = draftHours
+	metrics.ActualDraftTimeHours = computeDraftIntervals(timeline, pr, now)
+	metrics.PickupTimeHours = calculatePickupTime(pr, reviews, timeline)
+
+	// Time to First Review Request: time from PR creation to first review request
+	if timestamps.CreatedAt != nil && timestamps.FirstReviewRequest != nil {
+		if createdTime, err := time.Parse(time.RFC3339, *timestamps.CreatedAt); err == nil {
+			if firstReviewRequestTime, err := time.Parse(time.RFC3339, *timestamps.FirstReviewRequest); err == nil {
+				if firstReviewRequestTime.After(createdTime) {
+					hours := firstReviewRequestTime.Sub(createdTime).Hours()
+					metrics.TimeToFirstReviewRequestHours = &hours
+				}
+			}
+		}
+	}
+
+	// Time to First Review: time from the review clock start (by default the first review
+	// request) to first comment or first approval
+	if reviewStart := timestamps.reviewStart(); reviewStart != nil {
+		if firstReviewRequestTime, err := time.Parse(time.RFC3339, *reviewStart); err == nil {
+			var firstReviewActivityTime *time.Time
+
+			// Find the earliest between first comment and first approval
+			if timestamps.FirstComment != nil {
+				if firstCommentTime, err := time.Parse(time.RFC3339, *timestamps.FirstComment); err == nil {
+					firstReviewActivityTime = &firstCommentTime
+				}
+			}
+
+			if timestamps.FirstApproval != nil {
+				if firstApprovalTime, err := time.Parse(time.RFC3339, *timestamps.FirstApproval); err == nil {
+					if firstReviewActivityTime == nil || firstApprovalTime.Before(*firstReviewActivityTime) {
+						firstReviewActivityTime = &firstApprovalTime
+					}
+				}
+			}
+
+			// Calculate time to first review activity if we have one and it's after the review request
+			if firstReviewActivityTime != nil && firstReviewActivityTime.After(firstReviewRequestTime) {
+				hours := firstReviewActivityTime.Sub(firstReviewRequestTime).Hours()
+				metrics.TimeToFirstReviewHours = &hours
+			}
+		}
+	}
+
+	// Review Cycle Time: time from the review clock start (by default the first review
+	// request) to PR resolution (merged or closed)
+	if reviewStart := timestamps.reviewStart(); reviewStart != nil {
+		if firstReviewTime, err := time.Parse(time.RFC3339, *reviewStart); err == nil {
+			var resolutionTime *time.Time
+
+			// Use merged time if available, otherwise closed time
+			if timestamps.MergedAt != nil {
+				if mergedTime, err := time.Parse(time.RFC3339, *timestamps.MergedAt); err == nil {
+					resolutionTime = &mergedTime
+				}
+			} else if timestamps.ClosedAt != nil {
+				if closedTime, err := time.Parse(time.RFC3339, *timestamps.ClosedAt); err == nil {
+					resolutionTime = &closedTime
+				}
+			}
+
+			if resolutionTime != nil && resolutionTime.After(firstReviewTime) {
+				hours := resolutionTime.Sub(firstReviewTime).Hours()
+				metrics.ReviewCycleTimeHours = &hours
+			}
+		}
+	}
+
+	// Blocking vs Non-Blocking comment ratio
+	blockingCount := 0
+	nonBlockingCount := 0
+
+	for _, review := range reviews {
+		if ReviewState(review.GetState()) == ReviewChangesRequested {
+			blockingCount++
+		} else if ReviewState(review.GetState()) == ReviewCommented || ReviewState(review.GetState()) == ReviewApproved {
+			nonBlockingCount++
+		}
+	}
+
+	if nonBlockingCount > 0 {
+		ratio := float64(blockingCount) / float64(nonBlockingCount)
+		metrics.BlockingNonBlockingRatio = &ratio
+	}
+
+	// Reviewer Participation Ratio: (actual reviewers) / (requested reviewers)
+	actualReviewers := make(map[string]bool)
+	for _, review := range reviews {
+		actualReviewers[userLogin(review.GetUser())] = true
+	}
+
+	requestedReviewers := countAllRequestedReviewers(pr, reviews, timeline)
+	if requestedReviewers > 0 {
+		ratio := float64(len(actualReviewers)) / float64(requestedReviewers)
+		metrics.ReviewerParticipationRatio = &ratio
+	}
+
+	return metrics
+}
+
+// computeDraftIntervals sums the hours the PR actually spent in draft state. See
+// draftIntervals for how intervals are derived. Nil when the PR was never a draft.
+func computeDraftIntervals(timeline []*github.Timeline, pr *github.PullRequest, now time.Time) *float64 {
+	intervals := draftIntervals(timeline, pr, now)
+	if len(intervals) == 0 {
+		return nil
+	}
+	hours := unionDuration(intervals).Hours()
+	return &hours
+}
+
+// draftIntervals returns the spans the PR spent in draft state, based on convert_to_draft
+// and ready_for_review timeline events. A PR whose first toggle is ready_for_review (or
+// that is still a draft with no toggles) was opened as a draft, so its first interval
+// starts at creation. An interval still open at the end runs until the PR was closed, or
+// until now for open PRs.
+func draftIntervals(timeline []*github.Timeline, pr *github.PullRequest, now time.Time) []timeInterval {
+	var intervals []timeInterval
+	var draftStart *time.Time
+	sawDraft := false
+
+	addInterval := func(end time.Time) {
+		if !end.Before(*draftStart) {
+			intervals = append(intervals, timeInterval{start: *draftStart, end: end})
+		}
+		draftStart = nil
+	}
+
+	for _, event := range timeline {
+		switch TimelineEvent(event.GetEvent()) {
+		case EventConvertToDraft:
+			if draftStart == nil {
+				start := event.GetCreatedAt().Time
+				draftStart = &start
+			}
+			sawDraft = true
+		case EventReadyForReview:
+			if !sawDraft {
+				// No earlier convert_to_draft: the PR was opened as a draft
+				start := pr.GetCreatedAt().Time
+				draftStart = &start
+				sawDraft = true
+			}
+			if draftStart != nil {
+				addInterval(event.GetCreatedAt().Time)
+			}
+		}
+	}
+
+	if !sawDraft && pr.GetDraft() {
+		start := pr.GetCreatedAt().Time
+		draftStart = &start
+	}
+
+	if draftStart != nil {
+		end := now
+		if pr.ClosedAt != nil {
+			end = pr.GetClosedAt().Time
+		}
+		addInterval(end)
+	}
+
+	return intervals
+}
+
+// calculateReopenedDraftHours sums the draft intervals that started after the first
+// review request, i.e. time spent back in draft after review had begun. Used to extend
+// DraftTimeHours when Config.DraftTimeIncludesReopens is set.
+func calculateReopenedDraftHours(timeline []*github.Timeline, pr *github.PullRequest, firstReviewRequest *string, now time.Time) float64 {
+	if firstReviewRequest == nil {
+		return 0
+	}
+	requestTime, err := time.Parse(time.RFC3339, *firstReviewRequest)
+	if err != nil {
+		return 0
+	}
+
+	var reopened []timeInterval
+	for _, interval := range draftIntervals(timeline, pr, now) {
+		if interval.start.After(requestTime) {
+			reopened = append(reopened, interval)
+		}
+	}
+	return unionDuration(reopened).Hours()
+}
+
+// countReopens counts the reopened timeline events, i.e. how often the PR was closed and
+// brought back.
+func countReopens(timeline []*github.Timeline) int {
+	count := 0
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) == EventReopened {
+			count++
+		}
+	}
+	return count
+}
+
+// closedIntervals returns the spans from each closed timeline event to the reopened event
+// that followed it. A final close without a reopen is the PR's resolution and isn't an
+// interval.
+func closedIntervals(timeline []*github.Timeline) []timeInterval {
+	var intervals []timeInterval
+	var closedAt *time.Time
+	for _, event := range timeline {
+		if event.CreatedAt == nil {
+			continue
+		}
+		switch TimelineEvent(event.GetEvent()) {
+		case EventClosed:
+			if closedAt == nil {
+				start := event.GetCreatedAt().Time
+				closedAt = &start
+			}
+		case EventReopened:
+			if closedAt != nil {
+				intervals = append(intervals, timeInterval{start: *closedAt, end: event.GetCreatedAt().Time})
+				closedAt = nil
+			}
+		}
+	}
+	return intervals
+}
+
+// excludeClosedIntervals subtracts from the review cycle time the time the PR spent
+// closed before being reopened, counting only what falls after the review clock start.
+// Used when Config.ExcludeClosedIntervals is set.
+func excludeClosedIntervals(cycleHours *float64, timestamps *Timestamps, timeline []*github.Timeline) *float64 {
+	reviewStart := timestamps.reviewStart()
+	if cycleHours == nil || reviewStart == nil {
+		return cycleHours
+	}
+	startTime, err := time.Parse(time.RFC3339, *reviewStart)
+	if err != nil {
+		return cycleHours
+	}
+
+	var closed []timeInterval
+	for _, interval := range closedIntervals(timeline) {
+		if interval.start.Before(startTime) {
+			interval.start = startTime
+		}
+		if interval.end.After(interval.start) {
+			closed = append(closed, interval)
+		}
+	}
+
+	hours := *cycleHours - unionDuration(closed).Hours()
+	if hours < 0 {
+		hours = 0
+	}
+	return &hours
+}
+
+// calculatePickupTime returns the hours from when the PR became ready for review to the
+// first submitted review by anyone. A PR opened as a draft becomes ready at its first
+// ready_for_review event; any other PR is ready from creation. Nil when no review was
+// submitted after that point or the PR never left draft.
+func calculatePickupTime(pr *github.PullRequest, reviews []*github.PullRequestReview, timeline []*github.Timeline) *float64 {
+	readyTime, ok := readyForReviewTime(pr, timeline)
+	if !ok {
+		return nil
+	}
+
+	var firstReview *time.Time
+	for _, review := range reviews {
+		if review.SubmittedAt == nil {
+			continue
+		}
+		submitted := review.GetSubmittedAt().Time
+		if submitted.After(readyTime) && (firstReview == nil || submitted.Before(*firstReview)) {
+			firstReview = &submitted
+		}
+	}
+	if firstReview == nil {
+		return nil
+	}
+
+	hours := firstReview.Sub(readyTime).Hours()
+	return &hours
+}
+
+// reviewStart returns ReviewStart, falling back to FirstReviewRequest when unset
+func (t *Timestamps) reviewStart() *string {
+	if t.ReviewStart != nil {
+		return t.ReviewStart
+	}
+	return t.FirstReviewRequest
+}
+
+// readyForReviewTime returns when the PR became ready for review: its first
+// ready_for_review event if it was opened as a draft, otherwise its creation. False for PRs
+// without a creation time and drafts that were never marked ready.
+func readyForReviewTime(pr *github.PullRequest, timeline []*github.Timeline) (time.Time, bool) {
+	if pr.CreatedAt == nil {
+		return time.Time{}, false
+	}
+	// The first draft toggle tells whether the PR was opened as a draft
+	for _, event := range timeline {
+		if TimelineEvent(event.GetEvent()) == EventReadyForReview {
+			return event.GetCreatedAt().Time, true
+		}
+		if TimelineEvent(event.GetEvent()) == EventConvertToDraft {
+			return pr.GetCreatedAt().Time, true
+		}
+	}
+	if pr.GetDraft() {
+		return time.Time{}, false
+	}
+	return pr.GetCreatedAt().Time, true
+}
+
+// reviewStart returns the start of the review clock for Config.ReviewStartAnchor, in the
+// same format as the other internal timestamps. Nil when the anchor event never happened.
+func (a *Analyzer) reviewStart(pr *github.PullRequest, timeline []*github.Timeline, timestamps *Timestamps) *string {
+	switch a.config.ReviewStartAnchor {
+	case ReviewStartCreation:
+		return timestamps.CreatedAt
+	case ReviewStartReadyForReview:
+		readyTime, ok := readyForReviewTime(pr, timeline)
+		if !ok {
+			return nil
+		}
+		utcTime := formatToUTC(readyTime.Format(time.RFC3339))
+		return &utcTime
+	default:
+		return timestamps.FirstReviewRequest
+	}
+}
+
+// calculateTimeToFirstApproval returns the hours from the review clock start (or PR
+// creation when fromCreation is set) to the first approval. Nil when never approved.
+func calculateTimeToFirstApproval(timestamps *Timestamps, fromCreation bool) *float64 {
+	start := timestamps.reviewStart()
+	if fromCreation {
+		start = timestamps.CreatedAt
+	}
+	return hoursBetween(start, timestamps.FirstApproval)
 }
 
-func findReleaseForMergedPR(pr *github.PullRequest, releases []*github.RepositoryRelease) (*string, *string) {
-	releaseInfo := findReleaseInfoForMergedPR(pr, releases)
-	if releaseInfo == nil {
-		return nil, nil
+// calculateApprovalSpread returns the hours between the first and last approval, showing
+// whether approvals clustered or trickled in. Nil with fewer than two approvals.
+func calculateApprovalSpread(timestamps *Timestamps) *float64 {
+	if timestamps.FirstApproval != nil && timestamps.LastApproval != nil && *timestamps.FirstApproval == *timestamps.LastApproval {
+		spread := 0.0
+		return &spread
 	}
-	return &releaseInfo.Name, &releaseInfo.CreatedAt
+	return hoursBetween(timestamps.FirstApproval, timestamps.LastApproval)
 }
 
-func findReleaseInfoForMergedPR(pr *github.PullRequest, releases []*github.RepositoryRelease) *ReleaseInfo {
-	// Only check for releases if the PR was merged
-	if !pr.GetMerged() || pr.MergedAt == nil {
+// calculateLastCommitToMerge returns the hours from the author date of the PR's latest
+// commit to the merge, i.e. how long the PR waited once the author stopped changing it.
+// Author dates can be later than the merge after a rebase or with a skewed clock, so the
+// result is clamped at 0. Nil when the PR isn't merged or has no dated commits.
+func calculateLastCommitToMerge(pr *github.PullRequest, commits []*github.RepositoryCommit) *float64 {
+	if pr.MergedAt == nil {
 		return nil
 	}
 
-	mergedTime := pr.GetMergedAt().Time
-
-	// Find releases published after the PR was merged
-	var validReleases []*github.RepositoryRelease
-	for _, release := range releases {
-		if release.PublishedAt == nil || release.GetPublishedAt().IsZero() {
-			continue
-		}
-
-		publishedTime := release.GetPublishedAt().Time
-
-		// If the release was published after the PR was merged,
-		// this PR is likely included in this release
-		if publishedTime.After(mergedTime) {
-			validReleases = append(validReleases, release)
+	var lastCommit time.Time
+	for _, commit := range commits {
+		if commitTime := commit.GetCommit().GetAuthor().GetDate().Time; commitTime.After(lastCommit) {
+			lastCommit = commitTime
 		}
 	}
-
-	if len(validReleases) == 0 {
+	if lastCommit.IsZero() {
 		return nil
 	}
 
-	// Sort valid releases by published date (oldest first) to get the first release after merge
-	sort.Slice(validReleases, func(i, j int) bool {
-		return validReleases[i].GetPublishedAt().Before(validReleases[j].GetPublishedAt().Time)
-	})
-
-	// Return the first (earliest) release after merge
-	release := validReleases[0]
-	releaseName := release.GetName()
-	if releaseName == "" {
-		releaseName = release.GetTagName()
+	hours := pr.GetMergedAt().Sub(lastCommit).Hours()
+	if hours < 0 {
+		hours = 0
 	}
+	return &hours
+}
 
-	var releaseCreatedAt string
-	if release.CreatedAt != nil && !release.GetCreatedAt().IsZero() {
-		releaseCreatedAt = formatToUTC(release.GetCreatedAt().Format(time.RFC3339))
+// calculateAvgTimeToAddressComment averages, over review comments left by others, the
+// hours until the author's next commit. Comments with no later commit by the author are
+// excluded. Nil when no comment was followed by a commit.
+func calculateAvgTimeToAddressComment(reviewComments []*github.PullRequestComment, commits []*github.RepositoryCommit, authorUsername string) *float64 {
+	var commitTimes []time.Time
+	for _, commit := range commits {
+		if userLogin(commit.GetAuthor()) != authorUsername {
+			continue
+		}
+		commitTimes = append(commitTimes, commit.GetCommit().GetAuthor().GetDate().Time)
 	}
+	sort.Slice(commitTimes, func(i, j int) bool { return commitTimes[i].Before(commitTimes[j]) })
 
-	return &ReleaseInfo{
-		Name:      releaseName,
-		CreatedAt: releaseCreatedAt,
+	total := 0.0
+	addressed := 0
+	for _, comment := range reviewComments {
+		if userLogin(comment.GetUser()) == authorUsername || comment.CreatedAt == nil {
+			continue
+		}
+		commentTime := comment.GetCreatedAt().Time
+		for _, commitTime := range commitTimes {
+			if commitTime.After(commentTime) {
+				total += commitTime.Sub(commentTime).Hours()
+				addressed++
+				break
+			}
+		}
 	}
-}
 
-func countCommitsAfterFirstReview(commits []*github.RepositoryCommit, timeline []*github.Timeline) int {
-	// Find the first review request timestamp
-	var firstReviewRequestTime *time.Time
-	for _, event := range timeline {
-		if event.GetEvent() == "review_requested" {
-			t := event.GetCreatedAt().Time
-			firstReviewRequestTime = &t
-			break
-		}
+	if addressed == 0 {
+		return nil
 	}
+	avg := total / float64(addressed)
+	return &avg
+}
 
-	// If no review request was made, return 0
-	if firstReviewRequestTime == nil {
-		return 0
+// countCommentsWithoutFollowupCommit counts review comments left by others that the author
+// made no commit after before the PR was merged or closed (or up to now for open PRs),
+// i.e. comments addressed by discussion rather than code. It complements
+// calculateAvgTimeToAddressComment, which covers the comments that were followed by one.
+func countCommentsWithoutFollowupCommit(pr *github.PullRequest, reviewComments []*github.PullRequestComment, commits []*github.RepositoryCommit, authorUsername string) int {
+	var end time.Time
+	if pr.MergedAt != nil {
+		end = pr.GetMergedAt().Time
+	} else if pr.ClosedAt != nil {
+		end = pr.GetClosedAt().Time
 	}
 
-	// Count commits made after the first review request
-	count := 0
+	var commitTimes []time.Time
 	for _, commit := range commits {
+		if userLogin(commit.GetAuthor()) != authorUsername {
+			continue
+		}
 		commitTime := commit.GetCommit().GetAuthor().GetDate().Time
-		if commitTime.After(*firstReviewRequestTime) {
-			count++
+		if !end.IsZero() && commitTime.After(end) {
+			continue
 		}
+		commitTimes = append(commitTimes, commitTime)
 	}
 
-	return count
-}
-
-func countChangeRequests(reviews []*github.PullRequestReview) int {
 	count := 0
-	for _, review := range reviews {
-		if review.GetState() == "CHANGES_REQUESTED" {
+	for _, comment := range reviewComments {
+		if userLogin(comment.GetUser()) == authorUsername || comment.CreatedAt == nil {
+			continue
+		}
+		commentTime := comment.GetCreatedAt().Time
+		followedUp := false
+		for _, commitTime := range commitTimes {
+			if commitTime.After(commentTime) {
+				followedUp = true
+				break
+			}
+		}
+		if !followedUp {
 			count++
 		}
 	}
 	return count
 }
 
-func isBot(username string) bool {
-	return strings.Contains(username, "[bot]")
+// calculateReviewCommentDensity returns review comments per changed line, so review
+// thoroughness can be compared across PR sizes. Nil when no lines changed.
+func calculateReviewCommentDensity(reviewComments []*github.PullRequestComment, linesChanged int) *float64 {
+	if linesChanged <= 0 {
+		return nil
+	}
+	density := float64(len(reviewComments)) / float64(linesChanged)
+	return &density
 }
 
-func findValidJiraIssue(pattern *regexp.Regexp, text string) string {
-	// Find all matches in the text
-	matches := pattern.FindAllString(text, -1)
-	for _, match := range matches {
-		upperMatch := strings.ToUpper(match)
-		// Exclude CVE identifiers (security vulnerability IDs)
-		if !strings.HasPrefix(upperMatch, "CVE-") {
-			return upperMatch
+// activeReviewGap is the longest pause between two reviewer activities that still counts
+// as continuous review attention. Longer gaps, where only the author (or nobody) was
+// active, are left out of ActiveReviewHours.
+const activeReviewGap = 24 * time.Hour
+
+// timeInterval is a closed span of time
+type timeInterval struct {
+	start time.Time
+	end   time.Time
+}
+
+// unionDuration returns the total time covered by the intervals, counting overlapping
+// spans once
+func unionDuration(intervals []timeInterval) time.Duration {
+	sorted := make([]timeInterval, len(intervals))
+	copy(sorted, intervals)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start.Before(sorted[j].start) })
+
+	var total time.Duration
+	var current *timeInterval
+	for i := range sorted {
+		interval := sorted[i]
+		if interval.end.Before(interval.start) {
+			continue
 		}
+		if current != nil && !interval.start.After(current.end) {
+			if interval.end.After(current.end) {
+				current.end = interval.end
+			}
+			continue
+		}
+		if current != nil {
+			total += current.end.Sub(current.start)
+		}
+		current = &interval
 	}
-	return ""
+	if current != nil {
+		total += current.end.Sub(current.start)
+	}
+	return total
 }
 
-func extractJiraIssue(pr *github.PullRequest) string {
-	// Jira issue pattern: PROJECT-123, ABC-1234, etc.
-	// Matches project key (2+ uppercase letters or alphanumeric) followed by hyphen and number
-	// Excludes CVE- identifiers which are security vulnerability IDs, not Jira issues
-	jiraPattern := regexp.MustCompile(`\b[A-Z][A-Z0-9]+-\d+\b`)
-
-	// Search in PR title first
-	if issue := findValidJiraIssue(jiraPattern, pr.GetTitle()); issue != "" {
-		return issue
+// calculateActiveReviewHours approximates how long reviewers were actively engaged: the
+// span from the first to the last review activity (reviews, comments and review comments
+// by anyone but the author), minus pauses longer than activeReviewGap. Nil when there was
+// no reviewer activity.
+func calculateActiveReviewHours(reviews []*github.PullRequestReview, comments []*github.IssueComment, reviewComments []*github.PullRequestComment, authorUsername string) *float64 {
+	var activity []time.Time
+	for _, review := range reviews {
+		if userLogin(review.GetUser()) != authorUsername && review.SubmittedAt != nil {
+			activity = append(activity, review.GetSubmittedAt().Time)
+		}
 	}
-
-	// Search in PR body if available
-	if pr.Body != nil && pr.GetBody() != "" {
-		if issue := findValidJiraIssue(jiraPattern, pr.GetBody()); issue != "" {
-			return issue
+	for _, comment := range comments {
+		if userLogin(comment.GetUser()) != authorUsername && comment.CreatedAt != nil {
+			activity = append(activity, comment.GetCreatedAt().Time)
 		}
 	}
-
-	// Search in branch name (head ref)
-	if issue := findValidJiraIssue(jiraPattern, strings.ToUpper(pr.GetHead().GetRef())); issue != "" {
-		return issue
+	for _, comment := range reviewComments {
+		if userLogin(comment.GetUser()) != authorUsername && comment.CreatedAt != nil {
+			activity = append(activity, comment.GetCreatedAt().Time)
+		}
+	}
+	if len(activity) == 0 {
+		return nil
 	}
+	sort.Slice(activity, func(i, j int) bool { return activity[i].Before(activity[j]) })
 
-	// If not found, check if the user is a bot
-	if isBot(pr.GetUser().GetLogin()) {
-		return "BOT"
+	var intervals []timeInterval
+	for i := 1; i < len(activity); i++ {
+		if activity[i].Sub(activity[i-1]) <= activeReviewGap {
+			intervals = append(intervals, timeInterval{start: activity[i-1], end: activity[i]})
+		}
 	}
 
-	// If not a bot and no Jira issue found, return UNKNOWN
-	return "UNKNOWN"
+	hours := unionDuration(intervals).Hours()
+	return &hours
 }
 
-func calculatePRMetrics(pr *github.PullRequest, reviews []*github.PullRequestReview, comments []*github.IssueComment, timeline []*github.Timeline, timestamps *Timestamps) *PRMetrics {
-	metrics := &PRMetrics{}
+// ignoredTimelineEvents returns the set of timeline event types excluded from the
+// activity-based metrics, falling back to DefaultIgnoredTimelineEvents.
+func (a *Analyzer) ignoredTimelineEvents() map[string]bool {
+	events := a.config.IgnoredTimelineEvents
+	if len(events) == 0 {
+		events = DefaultIgnoredTimelineEvents
+	}
+	ignored := make(map[string]bool, len(events))
+	for _, event := range events {
+		ignored[event] = true
+	}
+	return ignored
+}
 
-	// Draft Time: time from PR creation to first review request, minimum 0
-	draftHours := 0.0
-	if timestamps.CreatedAt != nil && timestamps.FirstReviewRequest != nil {
-		if createdTime, err := time.Parse(time.RFC3339, *timestamps.CreatedAt); err == nil {
-			if firstReviewRequestTime, err := time.Parse(time.RFC3339, *timestamps.FirstReviewRequest); err == nil {
-				if firstReviewRequestTime.After(createdTime) {
-					draftHours = firstReviewRequestTime.Sub(createdTime).Hours()
-				}
-			}
+// calculateLongestIdle returns the longest gap, in hours, between consecutive activities
+// on the PR: creation, commits, comments, review comments, reviews and timeline events
+// other than the ignored ones. The last gap runs
+// to the merge or close time, or to now for open PRs. Nil when the creation time is
+// unknown.
+func calculateLongestIdle(pr *github.PullRequest, reviews []*github.PullRequestReview, comments []*github.IssueComment, reviewComments []*github.PullRequestComment, commits []*github.RepositoryCommit, timeline []*github.Timeline, ignoredEvents map[string]bool, now time.Time) *float64 {
+	if pr.CreatedAt == nil {
+		return nil
+	}
+
+	activity := []time.Time{pr.GetCreatedAt().Time}
+	for _, commit := range commits {
+		if date := commit.GetCommit().GetAuthor().GetDate(); !date.IsZero() {
+			activity = append(activity, date.Time)
+		}
+	}
+	for _, comment := range comments {
+		if comment.CreatedAt != nil {
+			activity = append(activity, comment.GetCreatedAt().Time)
+		}
+	}
+	for _, comment := range reviewComments {
+		if comment.CreatedAt != nil {
+			activity = append(activity, comment.GetCreatedAt().Time)
+		}
+	}
+	for _, review := range reviews {
+		if review.SubmittedAt != nil {
+			activity = append(activity, review.GetSubmittedAt().Time)
+		}
+	}
+	for _, event := range timeline {
+		if event.CreatedAt != nil && !ignoredEvents[event.GetEvent()] {
+			activity = append(activity, event.GetCreatedAt().Time)
 		}
 	}
-	metrics.DraftTimeHours = draftHours
 
-	// Time to First Review Request: time from PR creation to first review request
-	if timestamps.CreatedAt != nil && timestamps.FirstReviewRequest != nil {
-		if createdTime, err := time.Parse(time.RFC3339, *timestamps.CreatedAt); err == nil {
-			if firstReviewRequestTime, err := time.Parse(time.RFC3339, *timestamps.FirstReviewRequest); err == nil {
-				if firstReviewRequestTime.After(createdTime) {
-					hours := firstReviewRequestTime.Sub(createdTime).Hours()
-					metrics.TimeToFirstReviewRequestHours = &hours
-				}
-			}
+	end := now
+	if pr.MergedAt != nil {
+		end = pr.GetMergedAt().Time
+	} else if pr.ClosedAt != nil {
+		end = pr.GetClosedAt().Time
+	}
+	activity = append(activity, end)
+	sort.Slice(activity, func(i, j int) bool { return activity[i].Before(activity[j]) })
+
+	longest := 0.0
+	for i := 1; i < len(activity); i++ {
+		if activity[i].After(end) {
+			break
+		}
+		if gap := activity[i].Sub(activity[i-1]).Hours(); gap > longest {
+			longest = gap
 		}
 	}
+	return &longest
+}
 
-	// Time to First Review: time from first review request to first comment or first approval
-	if timestamps.FirstReviewRequest != nil {
-		if firstReviewRequestTime, err := time.Parse(time.RFC3339, *timestamps.FirstReviewRequest); err == nil {
-			var firstReviewActivityTime *time.Time
+// calculateHoursSinceLastUpdate returns the hours from the PR's updated_at to now, a cheap
+// staleness signal for open PRs that doesn't need the PR's events. Clamped at 0 for an
+// updated_at ahead of the local clock. Nil for merged or closed PRs and when updated_at
+// is unknown.
+func calculateHoursSinceLastUpdate(pr *github.PullRequest, now time.Time) *float64 {
+	if pr.GetState() == "closed" || isMerged(pr) || pr.UpdatedAt == nil {
+		return nil
+	}
+	hours := now.Sub(pr.GetUpdatedAt().Time).Hours()
+	if hours < 0 {
+		hours = 0
+	}
+	return &hours
+}
 
-			// Find the earliest between first comment and first approval
-			if timestamps.FirstComment != nil {
-				if firstCommentTime, err := time.Parse(time.RFC3339, *timestamps.FirstComment); err == nil {
-					firstReviewActivityTime = &firstCommentTime
-				}
-			}
+// calculateTimeInLabel returns the total hours the PR carried label, summing the intervals
+// from each labeled event to the matching unlabeled event. A label still applied counts
+// until the PR was merged or closed, or until now for open PRs. Label names are compared
+// case-insensitively. Nil when label is empty or was never applied.
+func calculateTimeInLabel(pr *github.PullRequest, timeline []*github.Timeline, label string, now time.Time) *float64 {
+	if label == "" {
+		return nil
+	}
 
-			if timestamps.FirstApproval != nil {
-				if firstApprovalTime, err := time.Parse(time.RFC3339, *timestamps.FirstApproval); err == nil {
-					if firstReviewActivityTime == nil || firstApprovalTime.Before(*firstReviewActivityTime) {
-						firstReviewActivityTime = &firstApprovalTime
-					}
-				}
+	var events []*github.Timeline
+	for _, event := range timeline {
+		switch TimelineEvent(event.GetEvent()) {
+		case EventLabeled, EventUnlabeled:
+			if event.CreatedAt != nil && strings.EqualFold(event.GetLabel().GetName(), label) {
+				events = append(events, event)
 			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].GetCreatedAt().Before(events[j].GetCreatedAt().Time) })
 
-			// Calculate time to first review activity if we have one and it's after the review request
-			if firstReviewActivityTime != nil && firstReviewActivityTime.After(firstReviewRequestTime) {
-				hours := firstReviewActivityTime.Sub(firstReviewRequestTime).Hours()
-				metrics.TimeToFirstReviewHours = &hours
+	end := now
+	if pr.MergedAt != nil {
+		end = pr.GetMergedAt().Time
+	} else if pr.ClosedAt != nil {
+		end = pr.GetClosedAt().Time
+	}
+
+	var total time.Duration
+	var appliedAt *time.Time
+	applied := false
+	for _, event := range events {
+		at := event.GetCreatedAt().Time
+		if at.After(end) {
+			at = end
+		}
+		if TimelineEvent(event.GetEvent()) == EventLabeled {
+			applied = true
+			if appliedAt == nil {
+				appliedAt = &at
 			}
+			continue
+		}
+		if appliedAt != nil {
+			total += at.Sub(*appliedAt)
+			appliedAt = nil
 		}
 	}
+	if !applied {
+		return nil
+	}
+	if appliedAt != nil && end.After(*appliedAt) {
+		total += end.Sub(*appliedAt)
+	}
 
-	// Review Cycle Time: time from first review request to PR resolution (merged or closed)
-	if timestamps.FirstReviewRequest != nil {
-		if firstReviewTime, err := time.Parse(time.RFC3339, *timestamps.FirstReviewRequest); err == nil {
-			var resolutionTime *time.Time
+	hours := total.Hours()
+	return &hours
+}
 
-			// Use merged time if available, otherwise closed time
-			if timestamps.MergedAt != nil {
-				if mergedTime, err := time.Parse(time.RFC3339, *timestamps.MergedAt); err == nil {
-					resolutionTime = &mergedTime
-				}
-			} else if timestamps.ClosedAt != nil {
-				if closedTime, err := time.Parse(time.RFC3339, *timestamps.ClosedAt); err == nil {
-					resolutionTime = &closedTime
-				}
-			}
+// inapplicableMetrics returns the JSON names of the optional metrics that can't be
+// calculated for the PR given its state and config, so PRMetrics.MissingMetrics doesn't
+// count them as missing.
+func inapplicableMetrics(pr *github.PullRequest, timeline []*github.Timeline, timestamps *Timestamps, config Config) map[string]bool {
+	merged := isMerged(pr)
+	resolved := merged || pr.GetState() == "closed"
 
-			if resolutionTime != nil && resolutionTime.After(firstReviewTime) {
-				hours := resolutionTime.Sub(firstReviewTime).Hours()
-				metrics.ReviewCycleTimeHours = &hours
-			}
+	wasDraft := pr.GetDraft()
+	for _, event := range timeline {
+		switch TimelineEvent(event.GetEvent()) {
+		case EventConvertToDraft, EventReadyForReview:
+			wasDraft = true
 		}
 	}
 
-	// Blocking vs Non-Blocking comment ratio
-	blockingCount := 0
-	nonBlockingCount := 0
-
-	for _, review := range reviews {
-		if review.GetState() == "CHANGES_REQUESTED" {
-			blockingCount++
-		} else if review.GetState() == "COMMENTED" || review.GetState() == "APPROVED" {
-			nonBlockingCount++
+	inapplicable := map[string]bool{
+		"hours_since_last_update":      resolved,
+		"review_cycle_time_hours":      !resolved,
+		"last_commit_to_merge_hours":   !merged,
+		"approval_spread_hours":        timestamps.SecondApproval == nil,
+		"actual_draft_time_hours":      !wasDraft,
+		"time_in_workflow_label_hours": config.WorkflowLabel == "",
+	}
+	for name, skip := range inapplicable {
+		if !skip {
+			delete(inapplicable, name)
 		}
 	}
+	return inapplicable
+}
 
-	if nonBlockingCount > 0 {
-		ratio := float64(blockingCount) / float64(nonBlockingCount)
-		metrics.BlockingNonBlockingRatio = &ratio
+// hoursBetween returns the hours from start to end, or nil if either timestamp is
+// missing or unparsable, or end is not after start.
+func hoursBetween(start, end *string) *float64 {
+	if start == nil || end == nil {
+		return nil
+	}
+	startTime, err := time.Parse(time.RFC3339, *start)
+	if err != nil {
+		return nil
+	}
+	endTime, err := time.Parse(time.RFC3339, *end)
+	if err != nil {
+		return nil
+	}
+	if !endTime.After(startTime) {
+		return nil
 	}
+	hours := endTime.Sub(startTime).Hours()
+	return &hours
+}
 
-	// Reviewer Participation Ratio: (actual reviewers) / (requested reviewers)
-	actualReviewers := make(map[string]bool)
-	for _, review := range reviews {
-		actualReviewers[review.GetUser().GetLogin()] = true
+// evaluateReviewSLA checks whether the first human review (a review by anyone other than
+// the author or a bot) was submitted within slaHours of the first review request. If no
+// human review exists, the elapsed time up to when the PR was merged or closed (or up to
+// now for open PRs) is evaluated instead. Returns nil values when there was no review
+// request. The breach is only set when the SLA was missed.
+func evaluateReviewSLA(pr *github.PullRequest, firstReviewRequest *string, reviews []*github.PullRequestReview, authorUsername string, slaHours float64, now time.Time) (*bool, *float64) {
+	if firstReviewRequest == nil {
+		return nil, nil
+	}
+	requestTime, err := time.Parse(time.RFC3339, *firstReviewRequest)
+	if err != nil {
+		return nil, nil
 	}
 
-	requestedReviewers := countAllRequestedReviewers(pr, reviews)
-	if requestedReviewers > 0 {
-		ratio := float64(len(actualReviewers)) / float64(requestedReviewers)
-		metrics.ReviewerParticipationRatio = &ratio
+	responseTime := now
+	if pr.MergedAt != nil {
+		responseTime = pr.GetMergedAt().Time
+	} else if pr.ClosedAt != nil {
+		responseTime = pr.GetClosedAt().Time
+	}
+	for _, review := range reviews {
+		login := userLogin(review.GetUser())
+		if login == authorUsername || isBot(login) || review.SubmittedAt == nil {
+			continue
+		}
+		submitted := review.GetSubmittedAt().Time
+		if submitted.Before(requestTime) {
+			continue
+		}
+		if submitted.Before(responseTime) {
+			responseTime = submitted
+		}
 	}
 
-	return metrics
-}
\ No newline at end of file
+	elapsed := responseTime.Sub(requestTime).Hours()
+	met := elapsed <= slaHours
+	if met {
+		return &met, nil
+	}
+	breach := elapsed - slaHours
+	return &met, &breach
+}
@@ -1,6 +1,19 @@
 package pullmetrics
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -22,11 +35,55 @@ func boolPtr(b bool) *bool {
 	return &b
 }
 
+// Helper function to create a pointer to a float64
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+// Helper function to compare optional float64 results
+func assertFloat64Ptr(t *testing.T, name string, got, want *float64) {
+	t.Helper()
+	if want == nil {
+		if got != nil {
+			t.Errorf("%s = %v, want nil", name, *got)
+		}
+		return
+	}
+	if got == nil {
+		t.Errorf("%s = nil, want %v", name, *want)
+		return
+	}
+	if math.Abs(*got-*want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, *got, *want)
+	}
+}
+
 // Helper function to create a pointer to a time.Time
 func timePtr(t time.Time) *github.Timestamp {
 	return &github.Timestamp{Time: t}
 }
 
+// Helper function to create an Analyzer backed by a test HTTP server.
+// Any endpoint not registered on mux responds with an empty JSON list.
+func newTestAnalyzer(t *testing.T, mux *http.ServeMux) *Analyzer {
+	t.Helper()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "[]")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	client.BaseURL = baseURL
+
+	return &Analyzer{client: client}
+}
+
 func TestGetPRState(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -57,6 +114,25 @@ func TestGetPRState(t *testing.T) {
 			},
 			expected: "merged",
 		},
+		{
+			name: "merged_at without merged flag",
+			pr: &github.PullRequest{
+				State:    stringPtr("closed"),
+				Draft:    boolPtr(false),
+				Merged:   boolPtr(false),
+				MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+			},
+			expected: "merged",
+		},
+		{
+			name: "merged flag without merged_at",
+			pr: &github.PullRequest{
+				State:  stringPtr("closed"),
+				Draft:  boolPtr(false),
+				Merged: boolPtr(true),
+			},
+			expected: "merged",
+		},
 		{
 			name: "open PR",
 			pr: &github.PullRequest{
@@ -93,6 +169,67 @@ func TestGetPRState(t *testing.T) {
 	}
 }
 
+func TestMergeCommitSHA(t *testing.T) {
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		expected string
+	}{
+		{
+			name:     "merged PR",
+			pr:       &github.PullRequest{State: stringPtr("closed"), Merged: boolPtr(true), MergeCommitSHA: stringPtr("abc123")},
+			expected: "abc123",
+		},
+		{
+			name:     "merged PR from list endpoint without merged flag",
+			pr:       &github.PullRequest{State: stringPtr("closed"), MergedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)), MergeCommitSHA: stringPtr("abc123")},
+			expected: "abc123",
+		},
+		{
+			name:     "open PR with test merge commit",
+			pr:       &github.PullRequest{State: stringPtr("open"), MergeCommitSHA: stringPtr("def456")},
+			expected: "",
+		},
+		{
+			name:     "closed without merging",
+			pr:       &github.PullRequest{State: stringPtr("closed"), MergeCommitSHA: stringPtr("def456")},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := mergeCommitSHA(tt.pr); result != tt.expected {
+				t.Errorf("mergeCommitSHA() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsMerged(t *testing.T) {
+	mergedAt := timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		expected bool
+	}{
+		{name: "merged with merged_at", pr: &github.PullRequest{Merged: boolPtr(true), MergedAt: mergedAt}, expected: true},
+		{name: "merged_at without merged flag", pr: &github.PullRequest{Merged: boolPtr(false), MergedAt: mergedAt}, expected: true},
+		{name: "merged flag without merged_at", pr: &github.PullRequest{Merged: boolPtr(true)}, expected: true},
+		{name: "not merged", pr: &github.PullRequest{Merged: boolPtr(false)}, expected: false},
+		{name: "merge fields missing", pr: &github.PullRequest{}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMerged(tt.pr); got != tt.expected {
+				t.Errorf("isMerged() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetApprovers(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -171,6 +308,215 @@ func TestGetApprovers(t *testing.T) {
 	}
 }
 
+func TestGetTimestamps_FirstApprover(t *testing.T) {
+	review := func(login, state string, hour int) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			User:        &github.User{Login: stringPtr(login)},
+			State:       stringPtr(state),
+			SubmittedAt: timePtr(time.Date(2023, 1, 15, hour, 0, 0, 0, time.UTC)),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		reviews  []*github.PullRequestReview
+		expected *string
+	}{
+		{
+			name: "approvals in chronological order",
+			reviews: []*github.PullRequestReview{
+				review("user1", "APPROVED", 10),
+				review("user2", "APPROVED", 12),
+			},
+			expected: stringPtr("user1"),
+		},
+		{
+			name: "approvals out of order",
+			reviews: []*github.PullRequestReview{
+				review("user2", "APPROVED", 14),
+				review("user3", "APPROVED", 11),
+				review("user1", "CHANGES_REQUESTED", 9),
+			},
+			expected: stringPtr("user3"),
+		},
+		{
+			name: "earlier non-approval reviews are ignored",
+			reviews: []*github.PullRequestReview{
+				review("user1", "COMMENTED", 9),
+				review("user2", "APPROVED", 13),
+			},
+			expected: stringPtr("user2"),
+		},
+		{
+			name:     "no approvals",
+			reviews:  []*github.PullRequestReview{review("user1", "COMMENTED", 9)},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamps := getTimestamps(&github.PullRequest{}, tt.reviews, nil, nil, nil, nil)
+			switch {
+			case tt.expected == nil && timestamps.FirstApprover != nil:
+				t.Errorf("getTimestamps().FirstApprover = %v, want nil", *timestamps.FirstApprover)
+			case tt.expected != nil && (timestamps.FirstApprover == nil || *timestamps.FirstApprover != *tt.expected):
+				t.Errorf("getTimestamps().FirstApprover = %v, want %v", timestamps.FirstApprover, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestCalculateApprovalSpread(t *testing.T) {
+	approval := func(login string, at time.Time) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: stringPtr(login)}, State: stringPtr("APPROVED"), SubmittedAt: timePtr(at)}
+	}
+	base := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		reviews  []*github.PullRequestReview
+		expected *float64
+	}{
+		{
+			name:     "approvals close together",
+			reviews:  []*github.PullRequestReview{approval("user1", base), approval("user2", base.Add(15*time.Minute))},
+			expected: float64Ptr(0.25),
+		},
+		{
+			name:     "approvals far apart",
+			reviews:  []*github.PullRequestReview{approval("user2", base.Add(30*time.Hour)), approval("user1", base)},
+			expected: float64Ptr(30),
+		},
+		{
+			name: "spread runs to the last of several approvals",
+			reviews: []*github.PullRequestReview{
+				approval("user1", base),
+				approval("user2", base.Add(2*time.Hour)),
+				approval("user3", base.Add(5*time.Hour)),
+			},
+			expected: float64Ptr(5),
+		},
+		{
+			name:     "simultaneous approvals",
+			reviews:  []*github.PullRequestReview{approval("user1", base), approval("user2", base)},
+			expected: float64Ptr(0),
+		},
+		{
+			name:     "single approval",
+			reviews:  []*github.PullRequestReview{approval("user1", base)},
+			expected: nil,
+		},
+		{
+			name:     "no approvals",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamps := getTimestamps(&github.PullRequest{}, tt.reviews, nil, nil, nil, nil)
+			assertFloat64Ptr(t, "calculateApprovalSpread()", calculateApprovalSpread(timestamps), tt.expected)
+		})
+	}
+}
+
+func TestCalculateLastCommitToMerge(t *testing.T) {
+	created := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(hours float64) *github.Timestamp {
+		return timePtr(created.Add(time.Duration(hours * float64(time.Hour))))
+	}
+	commit := func(hours float64) *github.RepositoryCommit {
+		return &github.RepositoryCommit{Commit: &github.Commit{Author: &github.CommitAuthor{Date: at(hours)}}}
+	}
+
+	tests := []struct {
+		name     string
+		mergedAt *github.Timestamp
+		commits  []*github.RepositoryCommit
+		expected *float64
+	}{
+		{
+			name:     "latest commit before merge",
+			mergedAt: at(30),
+			commits:  []*github.RepositoryCommit{commit(0), commit(24), commit(6)},
+			expected: float64Ptr(6),
+		},
+		{
+			name:     "commit dated after merge is clamped",
+			mergedAt: at(30),
+			commits:  []*github.RepositoryCommit{commit(0), commit(32)},
+			expected: float64Ptr(0),
+		},
+		{
+			name:     "not merged",
+			mergedAt: nil,
+			commits:  []*github.RepositoryCommit{commit(0)},
+			expected: nil,
+		},
+		{
+			name:     "no dated commits",
+			mergedAt: at(30),
+			commits:  []*github.RepositoryCommit{{Commit: &github.Commit{}}},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{MergedAt: tt.mergedAt}
+			assertFloat64Ptr(t, "calculateLastCommitToMerge()", calculateLastCommitToMerge(pr, tt.commits), tt.expected)
+		})
+	}
+}
+
+func TestGetApproversWhoCommitted(t *testing.T) {
+	commitBy := func(login string) *github.RepositoryCommit {
+		return &github.RepositoryCommit{Author: &github.User{Login: stringPtr(login)}}
+	}
+
+	tests := []struct {
+		name      string
+		approvers []string
+		commits   []*github.RepositoryCommit
+		expected  []string
+	}{
+		{
+			name:      "approver also authored a commit",
+			approvers: []string{"user2", "user1"},
+			commits:   []*github.RepositoryCommit{commitBy("author"), commitBy("user2")},
+			expected:  []string{"user2"},
+		},
+		{
+			name:      "multiple approvers with commits are sorted",
+			approvers: []string{"user3", "user1", "user2"},
+			commits:   []*github.RepositoryCommit{commitBy("user3"), commitBy("user1"), commitBy("user1")},
+			expected:  []string{"user1", "user3"},
+		},
+		{
+			name:      "no approver committed",
+			approvers: []string{"user1"},
+			commits:   []*github.RepositoryCommit{commitBy("author"), {Author: nil}},
+			expected:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := getApproversWhoCommitted(tt.approvers, tt.commits)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("getApproversWhoCommitted() = %v, want %v", result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("getApproversWhoCommitted() = %v, want %v", result, tt.expected)
+					break
+				}
+			}
+		})
+	}
+}
+
 func TestGetCommenters(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -248,6 +594,75 @@ func TestGetCommenters(t *testing.T) {
 	}
 }
 
+func TestDeletedUsersAreLabelled(t *testing.T) {
+	reviews := []*github.PullRequestReview{
+		{User: nil, State: stringPtr("APPROVED")},
+		{User: &github.User{Login: stringPtr("user1")}, State: stringPtr("APPROVED")},
+		{User: nil, State: stringPtr("CHANGES_REQUESTED")},
+	}
+	comments := []*github.IssueComment{
+		{User: nil},
+		{User: &github.User{Login: stringPtr("author")}},
+	}
+	reviewComments := []*github.PullRequestComment{
+		{User: &github.User{}},
+	}
+
+	approvers := getApprovers(reviews)
+	commenters := getCommenterUsernames(getCommenters(comments, reviewComments, "author"))
+
+	for name, usernames := range map[string][]string{"getApprovers()": approvers, "getCommenters()": commenters} {
+		for _, username := range usernames {
+			if username == "" {
+				t.Errorf("%s returned an empty username: %v", name, usernames)
+			}
+		}
+	}
+
+	sort.Strings(approvers)
+	if len(approvers) != 2 || approvers[0] != DeletedUserLogin || approvers[1] != "user1" {
+		t.Errorf("getApprovers() = %v, want [%s user1]", approvers, DeletedUserLogin)
+	}
+	if len(commenters) != 1 || commenters[0] != DeletedUserLogin {
+		t.Errorf("getCommenters() = %v, want [%s]", commenters, DeletedUserLogin)
+	}
+	if count := countDistinctChangeRequesters(reviews); count != 1 {
+		t.Errorf("countDistinctChangeRequesters() = %d, want 1", count)
+	}
+	if count := countAllRequestedReviewers(&github.PullRequest{}, reviews, nil); count != 2 {
+		t.Errorf("countAllRequestedReviewers() = %d, want 2", count)
+	}
+}
+
+func TestDeletedAuthorIsRecognized(t *testing.T) {
+	start := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(hours int) *github.Timestamp { return timePtr(start.Add(time.Duration(hours) * time.Hour)) }
+
+	// The author's account was deleted, so their activity has a null user
+	reviews := []*github.PullRequestReview{{User: nil, State: stringPtr("COMMENTED"), SubmittedAt: at(1)}}
+	comments := []*github.IssueComment{{User: nil, CreatedAt: at(2)}}
+	reviewComments := []*github.PullRequestComment{
+		{User: nil, CreatedAt: at(2)},
+		{User: &github.User{Login: stringPtr("reviewer1")}, CreatedAt: at(3)},
+	}
+	commits := []*github.RepositoryCommit{
+		{Author: nil, Commit: &github.Commit{Author: &github.CommitAuthor{Date: at(5)}}},
+	}
+	pr := &github.PullRequest{MergedAt: at(10)}
+
+	if hours := calculateActiveReviewHours(reviews, comments, reviewComments[:1], DeletedUserLogin); hours != nil {
+		t.Errorf("calculateActiveReviewHours() = %v, want nil for activity by the deleted author only", *hours)
+	}
+	assertFloat64Ptr(t, "calculateAvgTimeToAddressComment()", calculateAvgTimeToAddressComment(reviewComments, commits, DeletedUserLogin), float64Ptr(2))
+	if count := countCommentsWithoutFollowupCommit(pr, reviewComments, commits, DeletedUserLogin); count != 0 {
+		t.Errorf("countCommentsWithoutFollowupCommit() = %d, want 0", count)
+	}
+	met, _ := evaluateReviewSLA(&github.PullRequest{}, stringPtr("2023-01-15T10:00:00Z"), reviews, DeletedUserLogin, 4, start.Add(8*time.Hour))
+	if met == nil || *met {
+		t.Errorf("evaluateReviewSLA() met = %v, want false since the deleted author's review doesn't count", met)
+	}
+}
+
 func TestCountTotalComments(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -303,6 +718,59 @@ func TestCountTotalComments(t *testing.T) {
 	}
 }
 
+func TestCountEditedComments(t *testing.T) {
+	created := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	issueComment := func(editedAfter time.Duration) *github.IssueComment {
+		return &github.IssueComment{CreatedAt: timePtr(created), UpdatedAt: timePtr(created.Add(editedAfter))}
+	}
+	reviewComment := func(editedAfter time.Duration) *github.PullRequestComment {
+		return &github.PullRequestComment{CreatedAt: timePtr(created), UpdatedAt: timePtr(created.Add(editedAfter))}
+	}
+
+	tests := []struct {
+		name           string
+		comments       []*github.IssueComment
+		reviewComments []*github.PullRequestComment
+		threshold      time.Duration
+		expected       int
+	}{
+		{
+			name:           "edited and unedited comments",
+			comments:       []*github.IssueComment{issueComment(0), issueComment(2 * time.Hour)},
+			reviewComments: []*github.PullRequestComment{reviewComment(0), reviewComment(30 * time.Minute)},
+			threshold:      DefaultEditedCommentThreshold,
+			expected:       2,
+		},
+		{
+			name:      "quick typo fix is ignored",
+			comments:  []*github.IssueComment{issueComment(time.Minute)},
+			threshold: DefaultEditedCommentThreshold,
+			expected:  0,
+		},
+		{
+			name:      "custom threshold",
+			comments:  []*github.IssueComment{issueComment(time.Minute), issueComment(2 * time.Hour)},
+			threshold: time.Hour,
+			expected:  1,
+		},
+		{
+			name:      "missing updated_at",
+			comments:  []*github.IssueComment{{CreatedAt: timePtr(created)}},
+			threshold: DefaultEditedCommentThreshold,
+			expected:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := countEditedComments(tt.comments, tt.reviewComments, tt.threshold)
+			if result != tt.expected {
+				t.Errorf("countEditedComments() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetCommenterUsernames(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -350,596 +818,5288 @@ func TestGetCommenterUsernames(t *testing.T) {
 	}
 }
 
-func TestCountAllRequestedReviewers(t *testing.T) {
+func TestMaxThreadLength(t *testing.T) {
+	comment := func(id int64, replyTo int64) *github.PullRequestComment {
+		c := &github.PullRequestComment{ID: github.Int64(id)}
+		if replyTo != 0 {
+			c.InReplyTo = github.Int64(replyTo)
+		}
+		return c
+	}
+
 	tests := []struct {
-		name     string
-		pr       *github.PullRequest
-		reviews  []*github.PullRequestReview
-		expected int
+		name           string
+		reviewComments []*github.PullRequestComment
+		expected       int
 	}{
 		{
-			name: "reviewers who have reviewed and pending reviewers",
-			pr: &github.PullRequest{
-				RequestedReviewers: []*github.User{
-					{Login: stringPtr("pending1")},
-					{Login: stringPtr("pending2")},
-				},
-			},
-			reviews: []*github.PullRequestReview{
-				{User: &github.User{Login: stringPtr("reviewed1")}},
-				{User: &github.User{Login: stringPtr("reviewed2")}},
-			},
-			expected: 4,
+			name:     "no comments",
+			expected: 0,
 		},
 		{
-			name: "overlap between reviewed and pending",
-			pr: &github.PullRequest{
-				RequestedReviewers: []*github.User{
-					{Login: stringPtr("user1")},
-					{Login: stringPtr("pending1")},
-				},
-			},
-			reviews: []*github.PullRequestReview{
-				{User: &github.User{Login: stringPtr("user1")}}, // Same user in both lists
-				{User: &github.User{Login: stringPtr("reviewed1")}},
-			},
-			expected: 3, // user1 counted once, pending1, reviewed1
+			name:           "independent comments",
+			reviewComments: []*github.PullRequestComment{comment(1, 0), comment(2, 0), comment(3, 0)},
+			expected:       1,
 		},
 		{
-			name: "only reviewed, no pending",
-			pr: &github.PullRequest{
-				RequestedReviewers: []*github.User{},
+			name: "branched reply thread",
+			reviewComments: []*github.PullRequestComment{
+				comment(1, 0),
+				comment(2, 1),
+				comment(3, 2), // reply to a reply
+				comment(4, 1), // second branch from the root
+				comment(5, 0),
+				comment(6, 5),
 			},
-			reviews: []*github.PullRequestReview{
-				{User: &github.User{Login: stringPtr("reviewed1")}},
-				{User: &github.User{Login: stringPtr("reviewed2")}},
-			},
-			expected: 2,
+			expected: 4,
 		},
 		{
-			name: "only pending, no reviewed",
+			name:           "reply to a comment outside the list",
+			reviewComments: []*github.PullRequestComment{comment(2, 99), comment(3, 2), comment(4, 0)},
+			expected:       2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := maxThreadLength(tt.reviewComments); result != tt.expected {
+				t.Errorf("maxThreadLength() = %d, want %d", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetAssignees(t *testing.T) {
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		expected []string
+	}{
+		{
+			name: "multiple assignees",
 			pr: &github.PullRequest{
-				RequestedReviewers: []*github.User{
-					{Login: stringPtr("pending1")},
-					{Login: stringPtr("pending2")},
+				Assignees: []*github.User{
+					{Login: stringPtr("user3")},
+					{Login: stringPtr("user1")},
+					{Login: stringPtr("user2")},
 				},
 			},
-			reviews:  []*github.PullRequestReview{},
-			expected: 2,
+			expected: []string{"user1", "user2", "user3"}, // Should be sorted
 		},
 		{
-			name: "no reviewers at all",
-			pr: &github.PullRequest{
-				RequestedReviewers: []*github.User{},
-			},
-			reviews:  []*github.PullRequestReview{},
-			expected: 0,
+			name:     "no assignees",
+			pr:       &github.PullRequest{},
+			expected: []string{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := countAllRequestedReviewers(tt.pr, tt.reviews)
-			if result != tt.expected {
-				t.Errorf("countAllRequestedReviewers() = %v, want %v", result, tt.expected)
+			result := getAssignees(tt.pr)
+
+			if len(result) != len(tt.expected) {
+				t.Errorf("getAssignees() returned %d assignees, want %d", len(result), len(tt.expected))
+				return
+			}
+
+			for i, assignee := range result {
+				if assignee != tt.expected[i] {
+					t.Errorf("getAssignees()[%d] = %v, want %v", i, assignee, tt.expected[i])
+				}
 			}
 		})
 	}
 }
 
-func TestCountChangeRequests(t *testing.T) {
+func TestAnalyzeFromPR_Branches(t *testing.T) {
 	tests := []struct {
-		name     string
-		reviews  []*github.PullRequestReview
-		expected int
+		name          string
+		headRepo      *github.Repository
+		expectedCross bool
 	}{
 		{
-			name: "multiple change requests",
-			reviews: []*github.PullRequestReview{
-				{State: stringPtr("CHANGES_REQUESTED")},
-				{State: stringPtr("APPROVED")},
-				{State: stringPtr("CHANGES_REQUESTED")},
-				{State: stringPtr("COMMENTED")},
-			},
-			expected: 2,
+			name:          "same repository",
+			headRepo:      &github.Repository{FullName: stringPtr("org/repo")},
+			expectedCross: false,
 		},
 		{
-			name: "no change requests",
-			reviews: []*github.PullRequestReview{
-				{State: stringPtr("APPROVED")},
-				{State: stringPtr("COMMENTED")},
-			},
-			expected: 0,
+			name:          "fork PR",
+			headRepo:      &github.Repository{FullName: stringPtr("contributor/repo")},
+			expectedCross: true,
 		},
 		{
-			name:     "no reviews",
-			reviews:  []*github.PullRequestReview{},
-			expected: 0,
+			name:          "deleted fork",
+			headRepo:      nil,
+			expectedCross: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := countChangeRequests(tt.reviews)
-			if result != tt.expected {
-				t.Errorf("countChangeRequests() = %v, want %v", result, tt.expected)
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/contributor/", func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("request to the fork %s, want all data fetched from the base repository", r.URL.Path)
+				fmt.Fprint(w, "[]")
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			pr := &github.PullRequest{
+				Number: intPtr(1),
+				User:   &github.User{Login: stringPtr("contributor")},
+				Base: &github.PullRequestBranch{
+					Ref:  stringPtr("main"),
+					Repo: &github.Repository{FullName: stringPtr("org/repo")},
+				},
+				Head: &github.PullRequestBranch{
+					Ref:  stringPtr("fix-typo"),
+					Repo: tt.headRepo,
+				},
+			}
+
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.BaseBranch != "main" {
+				t.Errorf("AnalyzeFromPR().BaseBranch = %q, want %q", details.BaseBranch, "main")
+			}
+			if details.HeadBranch != "fix-typo" {
+				t.Errorf("AnalyzeFromPR().HeadBranch = %q, want %q", details.HeadBranch, "fix-typo")
+			}
+			if details.CrossRepo != tt.expectedCross {
+				t.Errorf("AnalyzeFromPR().CrossRepo = %v, want %v", details.CrossRepo, tt.expectedCross)
+			}
+			if details.HeadRepoFullName != tt.headRepo.GetFullName() {
+				t.Errorf("AnalyzeFromPR().HeadRepoFullName = %q, want %q", details.HeadRepoFullName, tt.headRepo.GetFullName())
 			}
 		})
 	}
 }
 
-
-func TestIsBot(t *testing.T) {
+func TestAnalyzeFromPR_AutoMerge(t *testing.T) {
 	tests := []struct {
-		name     string
-		username string
-		expected bool
+		name            string
+		autoMerge       *github.PullRequestAutoMerge
+		expectedEnabled bool
+		expectedMethod  string
 	}{
 		{
-			name:     "dependabot user",
-			username: "dependabot[bot]",
-			expected: true,
+			name:            "auto-merge enabled",
+			autoMerge:       &github.PullRequestAutoMerge{MergeMethod: stringPtr("squash"), EnabledBy: &github.User{Login: stringPtr("author")}},
+			expectedEnabled: true,
+			expectedMethod:  "squash",
 		},
 		{
-			name:     "github actions bot",
-			username: "github-actions[bot]",
-			expected: true,
+			name:            "auto-merge not enabled",
+			autoMerge:       nil,
+			expectedEnabled: false,
+			expectedMethod:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := newTestAnalyzer(t, http.NewServeMux())
+			pr := &github.PullRequest{
+				Number:    intPtr(1),
+				User:      &github.User{Login: stringPtr("author")},
+				AutoMerge: tt.autoMerge,
+			}
+
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.AutoMergeEnabled != tt.expectedEnabled {
+				t.Errorf("AnalyzeFromPR().AutoMergeEnabled = %v, want %v", details.AutoMergeEnabled, tt.expectedEnabled)
+			}
+			if details.AutoMergeMethod != tt.expectedMethod {
+				t.Errorf("AnalyzeFromPR().AutoMergeMethod = %q, want %q", details.AutoMergeMethod, tt.expectedMethod)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_BaseBranchMissing(t *testing.T) {
+	tests := []struct {
+		name            string
+		base            *github.PullRequestBranch
+		expectedMissing bool
+	}{
+		{
+			name:            "base branch present",
+			base:            &github.PullRequestBranch{Ref: stringPtr("main"), SHA: stringPtr("abc123")},
+			expectedMissing: false,
 		},
 		{
-			name:     "regular user",
-			username: "john_doe",
-			expected: false,
+			name:            "base ref data absent",
+			base:            nil,
+			expectedMissing: true,
 		},
 		{
-			name:     "user with bot in name but not bracketed",
-			username: "robotuser",
-			expected: false,
+			name:            "base SHA absent",
+			base:            &github.PullRequestBranch{Ref: stringPtr("release-1.0")},
+			expectedMissing: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isBot(tt.username)
-			if result != tt.expected {
-				t.Errorf("isBot(%s) = %v, want %v", tt.username, result, tt.expected)
+			protectionCalls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/branches/", func(w http.ResponseWriter, r *http.Request) {
+				protectionCalls++
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message":"Branch not protected"}`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = Config{IncludeBranchProtection: true}
+
+			pr := &github.PullRequest{
+				Number: intPtr(1),
+				User:   &github.User{Login: stringPtr("user1")},
+				Base:   tt.base,
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.BaseBranchMissing != tt.expectedMissing {
+				t.Errorf("AnalyzeFromPR().BaseBranchMissing = %v, want %v", details.BaseBranchMissing, tt.expectedMissing)
+			}
+			if tt.expectedMissing {
+				if protectionCalls != 0 {
+					t.Errorf("branch protection fetched %d times for a missing base branch, want 0", protectionCalls)
+				}
+				if details.MetBranchProtection != nil {
+					t.Errorf("AnalyzeFromPR().MetBranchProtection = %v, want nil", *details.MetBranchProtection)
+				}
 			}
 		})
 	}
 }
 
-func TestExtractJiraIssue(t *testing.T) {
+func TestAnalyzeFromPR_StackedPRs(t *testing.T) {
+	// Open PRs in org/repo: #10 feature-a -> main, #11 feature-b -> feature-a,
+	// #12 feature-c -> feature-b, #13 feature-d -> feature-b
+	openPRs := []struct {
+		number     int
+		head, base string
+	}{
+		{10, "feature-a", "main"},
+		{11, "feature-b", "feature-a"},
+		{12, "feature-c", "feature-b"},
+		{13, "feature-d", "feature-b"},
+	}
+	repoJSON := `{"full_name":"org/repo"}`
+
+	tests := []struct {
+		name              string
+		number            int
+		head, base        string
+		headRepo          string
+		expectedStackedOn *int
+		expectedStacked   []int
+	}{
+		{name: "bottom of the stack", number: 10, head: "feature-a", base: "main", headRepo: "org/repo", expectedStackedOn: nil, expectedStacked: []int{11}},
+		{name: "middle of the stack", number: 11, head: "feature-b", base: "feature-a", headRepo: "org/repo", expectedStackedOn: intPtr(10), expectedStacked: []int{12, 13}},
+		{name: "top of the stack", number: 12, head: "feature-c", base: "feature-b", headRepo: "org/repo", expectedStackedOn: intPtr(11), expectedStacked: nil},
+		{name: "fork PR with a same-named head branch", number: 20, head: "feature-a", base: "main", headRepo: "contributor/repo", expectedStackedOn: nil, expectedStacked: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				if query.Get("state") != "open" {
+					t.Errorf("listed PRs with state %q, want open", query.Get("state"))
+				}
+				var matches []string
+				for _, open := range openPRs {
+					if head := query.Get("head"); head != "" && head != "org:"+open.head {
+						continue
+					}
+					if base := query.Get("base"); base != "" && base != open.base {
+						continue
+					}
+					matches = append(matches, fmt.Sprintf(`{"number":%d,"head":{"ref":%q,"repo":%s},"base":{"ref":%q,"repo":%s}}`, open.number, open.head, repoJSON, open.base, repoJSON))
+				}
+				fmt.Fprintf(w, "[%s]", strings.Join(matches, ","))
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = Config{DetectStackedPRs: true}
+			pr := &github.PullRequest{
+				Number: intPtr(tt.number),
+				User:   &github.User{Login: stringPtr("author")},
+				Head:   &github.PullRequestBranch{Ref: stringPtr(tt.head), Repo: &github.Repository{FullName: stringPtr(tt.headRepo)}},
+				Base:   &github.PullRequestBranch{Ref: stringPtr(tt.base), Repo: &github.Repository{FullName: stringPtr("org/repo")}},
+			}
+
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if !reflect.DeepEqual(details.StackedOnPR, tt.expectedStackedOn) {
+				t.Errorf("AnalyzeFromPR().StackedOnPR = %v, want %v", details.StackedOnPR, tt.expectedStackedOn)
+			}
+			if !reflect.DeepEqual(details.StackedPRs, tt.expectedStacked) {
+				t.Errorf("AnalyzeFromPR().StackedPRs = %v, want %v", details.StackedPRs, tt.expectedStacked)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_StackedPRsDisabled(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("open PRs listed without Config.DetectStackedPRs")
+		fmt.Fprint(w, "[]")
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	pr := &github.PullRequest{
+		Number: intPtr(11),
+		User:   &github.User{Login: stringPtr("author")},
+		Head:   &github.PullRequestBranch{Ref: stringPtr("feature-b")},
+		Base:   &github.PullRequestBranch{Ref: stringPtr("feature-a")},
+	}
+
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+	if details.StackedOnPR != nil || details.StackedPRs != nil {
+		t.Errorf("AnalyzeFromPR() stacked on %v with %v stacked, want neither", details.StackedOnPR, details.StackedPRs)
+	}
+}
+
+func TestCountAllRequestedReviewers(t *testing.T) {
 	tests := []struct {
 		name     string
 		pr       *github.PullRequest
-		expected string
+		reviews  []*github.PullRequestReview
+		timeline []*github.Timeline
+		expected int
 	}{
 		{
-			name: "Jira issue in title",
+			name: "reviewers who have reviewed and pending reviewers",
 			pr: &github.PullRequest{
-				Title: stringPtr("Fix bug in ABC-123 authentication"),
-				Body:  stringPtr("This fixes the auth issue"),
-				User:  &github.User{Login: stringPtr("developer")},
-				Head: &github.PullRequestBranch{
-					Ref: stringPtr("feature-branch"),
+				RequestedReviewers: []*github.User{
+					{Login: stringPtr("pending1")},
+					{Login: stringPtr("pending2")},
 				},
 			},
-			expected: "ABC-123",
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("reviewed1")}},
+				{User: &github.User{Login: stringPtr("reviewed2")}},
+			},
+			expected: 4,
 		},
 		{
-			name: "Jira issue in body when not in title",
+			name: "overlap between reviewed and pending",
 			pr: &github.PullRequest{
-				Title: stringPtr("Fix authentication bug"),
-				Body:  stringPtr("This addresses DEF-456 by updating the token validation"),
-				User:  &github.User{Login: stringPtr("developer")},
-				Head: &github.PullRequestBranch{
-					Ref: stringPtr("feature-branch"),
+				RequestedReviewers: []*github.User{
+					{Login: stringPtr("user1")},
+					{Login: stringPtr("pending1")},
 				},
 			},
-			expected: "DEF-456",
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("user1")}}, // Same user in both lists
+				{User: &github.User{Login: stringPtr("reviewed1")}},
+			},
+			expected: 3, // user1 counted once, pending1, reviewed1
 		},
 		{
-			name: "Jira issue in branch name when not in title or body",
+			name: "only reviewed, no pending",
 			pr: &github.PullRequest{
-				Title: stringPtr("Fix authentication bug"),
-				Body:  stringPtr("This fixes the auth issue"),
-				User:  &github.User{Login: stringPtr("developer")},
-				Head: &github.PullRequestBranch{
-					Ref: stringPtr("feature/GHI-789-fix-auth"),
-				},
+				RequestedReviewers: []*github.User{},
 			},
-			expected: "GHI-789",
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("reviewed1")}},
+				{User: &github.User{Login: stringPtr("reviewed2")}},
+			},
+			expected: 2,
 		},
 		{
-			name: "Bot user with no Jira issue",
+			name: "only pending, no reviewed",
 			pr: &github.PullRequest{
-				Title: stringPtr("Update dependencies"),
-				Body:  stringPtr("Automated dependency update"),
-				User:  &github.User{Login: stringPtr("dependabot[bot]")},
-				Head: &github.PullRequestBranch{
-					Ref: stringPtr("dependabot/npm_and_yarn/package-update"),
+				RequestedReviewers: []*github.User{
+					{Login: stringPtr("pending1")},
+					{Login: stringPtr("pending2")},
 				},
 			},
-			expected: "BOT",
+			reviews:  []*github.PullRequestReview{},
+			expected: 2,
 		},
 		{
-			name: "Regular user with no Jira issue",
+			name: "no reviewers at all",
 			pr: &github.PullRequest{
-				Title: stringPtr("Update documentation"),
-				Body:  stringPtr("Updated the README file"),
-				User:  &github.User{Login: stringPtr("developer")},
-				Head: &github.PullRequestBranch{
-					Ref: stringPtr("update-docs"),
-				},
+				RequestedReviewers: []*github.User{},
 			},
-			expected: "UNKNOWN",
+			reviews:  []*github.PullRequestReview{},
+			expected: 0,
 		},
 		{
-			name: "CVE identifier should be excluded",
-			pr: &github.PullRequest{
-				Title: stringPtr("Security fix for CVE-2023-1234"),
-				Body:  stringPtr("This addresses the security vulnerability"),
-				User:  &github.User{Login: stringPtr("developer")},
-				Head: &github.PullRequestBranch{
-					Ref: stringPtr("security-fix"),
-				},
+			name: "request removed before the reviewer responded",
+			pr:   &github.PullRequest{},
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("reviewed1")}, SubmittedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC))},
 			},
-			expected: "UNKNOWN", // CVE should be excluded
+			timeline: []*github.Timeline{
+				reviewRequestEvent(EventReviewRequested, "reviewed1", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+				reviewRequestEvent(EventReviewRequested, "withdrawn1", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+				reviewRequestEvent(EventReviewRequestRemoved, "withdrawn1", time.Date(2023, 1, 15, 11, 0, 0, 0, time.UTC)),
+			},
+			expected: 1,
 		},
 		{
-			name: "Jira issue with CVE present - Jira should win",
-			pr: &github.PullRequest{
-				Title: stringPtr("SECURITY-123: Fix CVE-2023-1234 vulnerability"),
-				Body:  stringPtr("This addresses the CVE-2023-1234 security issue"),
-				User:  &github.User{Login: stringPtr("developer")},
-				Head: &github.PullRequestBranch{
-					Ref: stringPtr("security-fix"),
-				},
+			name: "request removed after the reviewer responded",
+			pr:   &github.PullRequest{},
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("reviewer1")}, SubmittedAt: timePtr(time.Date(2023, 1, 15, 11, 0, 0, 0, time.UTC))},
 			},
-			expected: "SECURITY-123", // Valid Jira issue should be returned, CVE ignored
+			timeline: []*github.Timeline{
+				reviewRequestEvent(EventReviewRequested, "reviewer1", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+				reviewRequestEvent(EventReviewRequestRemoved, "reviewer1", time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+			},
+			expected: 1,
+		},
+		{
+			name: "requested, not yet reviewed",
+			pr:   &github.PullRequest{},
+			timeline: []*github.Timeline{
+				reviewRequestEvent(EventReviewRequested, "pending1", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+			},
+			expected: 1,
+		},
+		{
+			name: "removed then requested again",
+			pr:   &github.PullRequest{},
+			timeline: []*github.Timeline{
+				reviewRequestEvent(EventReviewRequested, "pending1", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+				reviewRequestEvent(EventReviewRequestRemoved, "pending1", time.Date(2023, 1, 15, 11, 0, 0, 0, time.UTC)),
+				reviewRequestEvent(EventReviewRequested, "pending1", time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+			},
+			expected: 1,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := extractJiraIssue(tt.pr)
+			result := countAllRequestedReviewers(tt.pr, tt.reviews, tt.timeline)
 			if result != tt.expected {
-				t.Errorf("extractJiraIssue() = %v, want %v", result, tt.expected)
+				t.Errorf("countAllRequestedReviewers() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestFormatToUTC(t *testing.T) {
-	tests := []struct {
-		name      string
-		timestamp string
-		expected  string
+func reviewRequestEvent(event TimelineEvent, reviewer string, at time.Time) *github.Timeline {
+	return &github.Timeline{
+		Event:     stringPtr(string(event)),
+		Reviewer:  &github.User{Login: stringPtr(reviewer)},
+		CreatedAt: timePtr(at),
+	}
+}
+
+func TestCountUnfulfilledReviewRequests(t *testing.T) {
+	requestedAt := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		reviews  []*github.PullRequestReview
+		timeline []*github.Timeline
+		expected int
 	}{
 		{
-			name:      "RFC3339 timestamp",
-			timestamp: "2023-01-15T10:30:45Z",
-			expected:  "2023-01-15T10:30:45Z",
+			name: "pending requests are unfulfilled",
+			pr: &github.PullRequest{
+				RequestedReviewers: []*github.User{{Login: stringPtr("pending1")}},
+			},
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("reviewer1")}, SubmittedAt: timePtr(requestedAt.Add(time.Hour))},
+			},
+			timeline: []*github.Timeline{
+				reviewRequestEvent(EventReviewRequested, "reviewer1", requestedAt),
+				reviewRequestEvent(EventReviewRequested, "pending1", requestedAt),
+			},
+			expected: 1,
 		},
 		{
-			name:      "timestamp with timezone",
-			timestamp: "2023-01-15T10:30:45-08:00",
-			expected:  "2023-01-15T18:30:45Z", // Converted to UTC
+			name: "request removed before the reviewer responded",
+			pr:   &github.PullRequest{},
+			timeline: []*github.Timeline{
+				reviewRequestEvent(EventReviewRequested, "withdrawn1", requestedAt),
+				reviewRequestEvent(EventReviewRequestRemoved, "withdrawn1", requestedAt.Add(time.Hour)),
+			},
+			expected: 0,
 		},
 		{
-			name:      "invalid timestamp",
-			timestamp: "invalid-timestamp",
-			expected:  "invalid-timestamp", // Should return original if parsing fails
+			name:     "no requests",
+			pr:       &github.PullRequest{},
+			expected: 0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := formatToUTC(tt.timestamp)
-			if result != tt.expected {
-				t.Errorf("formatToUTC(%s) = %v, want %v", tt.timestamp, result, tt.expected)
+			if got := countUnfulfilledReviewRequests(tt.pr, tt.reviews, tt.timeline); got != tt.expected {
+				t.Errorf("countUnfulfilledReviewRequests() = %v, want %v", got, tt.expected)
 			}
 		})
 	}
 }
 
-func TestCalculatePRSize(t *testing.T) {
+func TestCountReviewersEverRequested(t *testing.T) {
+	requestedAt := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return requestedAt.Add(time.Duration(hours) * time.Hour) }
+
 	tests := []struct {
 		name     string
-		files    []*github.CommitFile
-		expected *PRSize
+		timeline []*github.Timeline
+		expected int
 	}{
 		{
-			name: "multiple files with changes",
-			files: []*github.CommitFile{
-				{
-					Filename:  stringPtr("file1.go"),
-					Additions: intPtr(10),
-					Deletions: intPtr(5),
-				},
-				{
-					Filename:  stringPtr("file2.go"),
-					Additions: intPtr(20),
-					Deletions: intPtr(3),
-				},
-			},
-			expected: &PRSize{
-				LinesChanged: 38, // 10+5+20+3
-				FilesChanged: 2,
+			name: "reviewers added and removed",
+			timeline: []*github.Timeline{
+				reviewRequestEvent(EventReviewRequested, "reviewer1", at(0)),
+				reviewRequestEvent(EventReviewRequested, "reviewer2", at(0)),
+				reviewRequestEvent(EventReviewRequestRemoved, "reviewer2", at(1)),
+				reviewRequestEvent(EventReviewRequested, "reviewer3", at(1)),
+				reviewRequestEvent(EventReviewRequestRemoved, "reviewer3", at(2)),
 			},
+			expected: 3,
 		},
 		{
-			name: "single file",
-			files: []*github.CommitFile{
-				{
-					Filename:  stringPtr("file1.go"),
-					Additions: intPtr(15),
-					Deletions: intPtr(8),
-				},
-			},
-			expected: &PRSize{
-				LinesChanged: 23, // 15+8
-				FilesChanged: 1,
+			name: "reviewer re-requested counts once",
+			timeline: []*github.Timeline{
+				reviewRequestEvent(EventReviewRequested, "reviewer1", at(0)),
+				reviewRequestEvent(EventReviewRequestRemoved, "reviewer1", at(1)),
+				reviewRequestEvent(EventReviewRequested, "reviewer1", at(2)),
 			},
+			expected: 1,
 		},
 		{
-			name:  "no files",
-			files: []*github.CommitFile{},
-			expected: &PRSize{
-				LinesChanged: 0,
-				FilesChanged: 0,
+			name: "team request without a user",
+			timeline: []*github.Timeline{
+				{Event: stringPtr(string(EventReviewRequested)), RequestedTeam: &github.Team{Slug: stringPtr("backend")}},
 			},
+			expected: 0,
+		},
+		{
+			name:     "no requests",
+			expected: 0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := calculatePRSize(tt.files)
-			if result.LinesChanged != tt.expected.LinesChanged {
-				t.Errorf("calculatePRSize().LinesChanged = %v, want %v", result.LinesChanged, tt.expected.LinesChanged)
-			}
-			if result.FilesChanged != tt.expected.FilesChanged {
-				t.Errorf("calculatePRSize().FilesChanged = %v, want %v", result.FilesChanged, tt.expected.FilesChanged)
+			if got := countReviewersEverRequested(tt.timeline); got != tt.expected {
+				t.Errorf("countReviewersEverRequested() = %v, want %v", got, tt.expected)
 			}
 		})
 	}
 }
 
-func TestCalculatePRMetrics_DraftTime(t *testing.T) {
+func TestNeverRequestedReview(t *testing.T) {
+	requestedAt := time.Date(2023, 1, 15, 11, 0, 0, 0, time.UTC)
+
 	tests := []struct {
-		name        string
-		timestamps  *Timestamps
-		expectedHours float64
+		name     string
+		pr       *github.PullRequest
+		timeline []*github.Timeline
+		expected bool
 	}{
 		{
-			name: "draft time calculated when both timestamps exist",
-			timestamps: &Timestamps{
-				CreatedAt:          stringPtr("2023-01-15T10:00:00Z"),
-				FirstReviewRequest: stringPtr("2023-01-15T12:30:00Z"),
-			},
-			expectedHours: 2.5, // 2.5 hours
+			name:     "no requests",
+			pr:       &github.PullRequest{},
+			expected: true,
 		},
 		{
-			name: "zero draft time when created_at missing",
-			timestamps: &Timestamps{
-				FirstReviewRequest: stringPtr("2023-01-15T12:30:00Z"),
-			},
-			expectedHours: 0.0,
+			name:     "review requested event",
+			pr:       &github.PullRequest{},
+			timeline: []*github.Timeline{reviewRequestEvent(EventReviewRequested, "user1", requestedAt)},
+			expected: false,
 		},
 		{
-			name: "zero draft time when first_review_request missing",
-			timestamps: &Timestamps{
-				CreatedAt: stringPtr("2023-01-15T10:00:00Z"),
-			},
-			expectedHours: 0.0,
+			name:     "request later withdrawn",
+			pr:       &github.PullRequest{},
+			timeline: []*github.Timeline{reviewRequestEvent(EventReviewRequested, "user1", requestedAt), reviewRequestEvent(EventReviewRequestRemoved, "user1", requestedAt.Add(time.Hour))},
+			expected: false,
 		},
 		{
-			name: "zero draft time when review request is before creation",
-			timestamps: &Timestamps{
-				CreatedAt:          stringPtr("2023-01-15T12:00:00Z"),
-				FirstReviewRequest: stringPtr("2023-01-15T10:00:00Z"), // Before creation
-			},
-			expectedHours: 0.0,
+			name:     "currently requested reviewer",
+			pr:       &github.PullRequest{RequestedReviewers: []*github.User{{Login: stringPtr("user1")}}},
+			expected: false,
 		},
 		{
-			name: "zero draft time when review request is at same time as creation",
-			timestamps: &Timestamps{
-				CreatedAt:          stringPtr("2023-01-15T10:00:00Z"),
-				FirstReviewRequest: stringPtr("2023-01-15T10:00:00Z"), // Same time
-			},
-			expectedHours: 0.0, // Should be 0 since not after creation time
+			name:     "currently requested team",
+			pr:       &github.PullRequest{RequestedTeams: []*github.Team{{Slug: stringPtr("team1")}}},
+			expected: false,
+		},
+		{
+			name:     "unrelated events",
+			pr:       &github.PullRequest{},
+			timeline: []*github.Timeline{{Event: stringPtr("labeled"), CreatedAt: timePtr(requestedAt)}},
+			expected: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			metrics := calculatePRMetrics(
-				&github.PullRequest{},
-				[]*github.PullRequestReview{},
-				[]*github.IssueComment{},
-				[]*github.Timeline{},
-				tt.timestamps,
-			)
-
-			if metrics.DraftTimeHours != tt.expectedHours {
-				t.Errorf("calculatePRMetrics().DraftTimeHours = %v, want %v", metrics.DraftTimeHours, tt.expectedHours)
+			result := neverRequestedReview(tt.pr, tt.timeline)
+			if result != tt.expected {
+				t.Errorf("neverRequestedReview() = %v, want %v", result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestFindReleaseForMergedPR_WithCreatedAt(t *testing.T) {
+func TestAnalyzeFromPR_ClosedDraftNeverRequestedReview(t *testing.T) {
+	analyzer := newTestAnalyzer(t, http.NewServeMux())
+
+	pr := &github.PullRequest{
+		Number:    intPtr(1),
+		Title:     stringPtr("ABC-1 Experiment"),
+		State:     stringPtr("closed"),
+		Draft:     boolPtr(true),
+		User:      &github.User{Login: stringPtr("author")},
+		CreatedAt: timePtr(time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+		ClosedAt:  timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+	}
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+	if !details.NeverRequestedReview {
+		t.Error("AnalyzeFromPR().NeverRequestedReview = false, want true for a closed draft")
+	}
+	if details.Metrics != nil && (details.Metrics.TimeToFirstReviewHours != nil || details.Metrics.ReviewCycleTimeHours != nil) {
+		t.Errorf("AnalyzeFromPR() review metrics = %+v, want nil without a review request", details.Metrics)
+	}
+}
+
+func TestCalculatePRMetrics_ParticipationExcludesWithdrawnRequests(t *testing.T) {
+	requestedAt := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	pr := &github.PullRequest{CreatedAt: timePtr(requestedAt)}
+	reviews := []*github.PullRequestReview{
+		{User: &github.User{Login: stringPtr("reviewer1")}, State: stringPtr("APPROVED"), SubmittedAt: timePtr(requestedAt.Add(2 * time.Hour))},
+	}
+	timeline := []*github.Timeline{
+		reviewRequestEvent(EventReviewRequested, "reviewer1", requestedAt),
+		reviewRequestEvent(EventReviewRequested, "withdrawn1", requestedAt),
+		reviewRequestEvent(EventReviewRequestRemoved, "withdrawn1", requestedAt.Add(time.Hour)),
+	}
+
+	metrics := calculatePRMetrics(pr, reviews, nil, timeline, &Timestamps{}, time.Now())
+	assertFloat64Ptr(t, "ReviewerParticipationRatio", metrics.ReviewerParticipationRatio, float64Ptr(1))
+}
+
+func TestAnalyzeFromPR_ExpandTeamReviewers(t *testing.T) {
 	tests := []struct {
-		name                    string
-		pr                      *github.PullRequest
-		releases                []*github.RepositoryRelease
-		expectedReleaseName     *string
-		expectedReleaseCreatedAt *string
+		name                string
+		config              Config
+		expectedRequested   int
+		expectedUnfulfilled int
 	}{
 		{
-			name: "merged PR with release and created timestamp",
-			pr: &github.PullRequest{
-				Merged:   boolPtr(true),
-				MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
-			},
-			releases: []*github.RepositoryRelease{
-				{
-					Name:        stringPtr("v1.0.0"),
-					TagName:     stringPtr("v1.0.0"),
-					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
-					CreatedAt:   timePtr(time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC)),
-				},
-			},
-			expectedReleaseName:     stringPtr("v1.0.0"),
-			expectedReleaseCreatedAt: stringPtr("2023-01-16T09:00:00Z"),
-		},
-		{
-			name: "merged PR with release but no created timestamp",
-			pr: &github.PullRequest{
-				Merged:   boolPtr(true),
-				MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
-			},
-			releases: []*github.RepositoryRelease{
-				{
-					Name:        stringPtr("v1.0.0"),
-					TagName:     stringPtr("v1.0.0"),
-					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
-					CreatedAt:   nil, // No creation timestamp
-				},
-			},
-			expectedReleaseName:     stringPtr("v1.0.0"),
-			expectedReleaseCreatedAt: nil,
-		},
-		{
-			name: "unmerged PR",
-			pr: &github.PullRequest{
-				Merged:   boolPtr(false),
-				MergedAt: nil,
-			},
-			releases: []*github.RepositoryRelease{
-				{
-					Name:        stringPtr("v1.0.0"),
-					TagName:     stringPtr("v1.0.0"),
-					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
-					CreatedAt:   timePtr(time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC)),
-				},
-			},
-			expectedReleaseName:     nil,
-			expectedReleaseCreatedAt: nil,
+			name:                "team members counted",
+			config:              Config{ExpandTeamReviewers: true},
+			expectedRequested:   3,
+			expectedUnfulfilled: 2,
 		},
 		{
-			name: "merged PR with multiple releases, earliest selected",
-			pr: &github.PullRequest{
-				Merged:   boolPtr(true),
-				MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
-			},
-			releases: []*github.RepositoryRelease{
-				{
-					Name:        stringPtr("v1.1.0"),
-					TagName:     stringPtr("v1.1.0"),
-					PublishedAt: timePtr(time.Date(2023, 1, 20, 10, 0, 0, 0, time.UTC)),
-					CreatedAt:   timePtr(time.Date(2023, 1, 20, 9, 0, 0, 0, time.UTC)),
-				},
-				{
-					Name:        stringPtr("v1.0.0"),
-					TagName:     stringPtr("v1.0.0"),
-					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
-					CreatedAt:   timePtr(time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC)),
-				},
-			},
-			expectedReleaseName:     stringPtr("v1.0.0"), // Earliest release
-			expectedReleaseCreatedAt: stringPtr("2023-01-16T09:00:00Z"),
+			name:                "teams not expanded",
+			config:              Config{},
+			expectedRequested:   1,
+			expectedUnfulfilled: 0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			releaseName, releaseCreatedAt := findReleaseForMergedPR(tt.pr, tt.releases)
-			
-			if tt.expectedReleaseName == nil {
-				if releaseName != nil {
-					t.Errorf("findReleaseForMergedPR() releaseName = %v, want nil", *releaseName)
-				}
-			} else {
-				if releaseName == nil {
-					t.Errorf("findReleaseForMergedPR() releaseName = nil, want %v", *tt.expectedReleaseName)
-				} else if *releaseName != *tt.expectedReleaseName {
-					t.Errorf("findReleaseForMergedPR() releaseName = %v, want %v", *releaseName, *tt.expectedReleaseName)
-				}
+			var teamRequests int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/orgs/org/teams/backend/members", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&teamRequests, 1)
+				fmt.Fprint(w, `[{"login":"member1"},{"login":"member2"}]`)
+			})
+			mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"user":{"login":"reviewer1"},"state":"APPROVED","submitted_at":"2023-01-15T12:00:00Z"}]`)
+			})
+			mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"event":"review_requested","created_at":"2023-01-15T10:00:00Z","reviewer":{"login":"reviewer1"}},
+					{"event":"review_requested","created_at":"2023-01-15T10:00:00Z","requested_team":{"slug":"backend"}}
+				]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{
+				Number:         intPtr(1),
+				User:           &github.User{Login: stringPtr("author")},
+				RequestedTeams: []*github.Team{{Slug: stringPtr("backend")}},
 			}
-			
-			if tt.expectedReleaseCreatedAt == nil {
-				if releaseCreatedAt != nil && *releaseCreatedAt != "" {
-					t.Errorf("findReleaseForMergedPR() releaseCreatedAt = %v, want nil or empty", *releaseCreatedAt)
-				}
-			} else {
-				if releaseCreatedAt == nil {
-					t.Errorf("findReleaseForMergedPR() releaseCreatedAt = nil, want %v", *tt.expectedReleaseCreatedAt)
-				} else if *releaseCreatedAt != *tt.expectedReleaseCreatedAt {
-					t.Errorf("findReleaseForMergedPR() releaseCreatedAt = %v, want %v", *releaseCreatedAt, *tt.expectedReleaseCreatedAt)
-				}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+
+			if details.NumRequestedReviewers != tt.expectedRequested {
+				t.Errorf("AnalyzeFromPR().NumRequestedReviewers = %v, want %v", details.NumRequestedReviewers, tt.expectedRequested)
+			}
+			if details.UnfulfilledReviewRequests != tt.expectedUnfulfilled {
+				t.Errorf("AnalyzeFromPR().UnfulfilledReviewRequests = %v, want %v", details.UnfulfilledReviewRequests, tt.expectedUnfulfilled)
+			}
+			if len(pr.RequestedReviewers) != 0 {
+				t.Errorf("AnalyzeFromPR() modified the PR's requested reviewers: %v", pr.RequestedReviewers)
+			}
+
+			// Team membership is cached per Analyzer
+			if _, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr); err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if tt.config.ExpandTeamReviewers && atomic.LoadInt32(&teamRequests) != 1 {
+				t.Errorf("team member requests = %d, want 1", teamRequests)
 			}
 		})
 	}
 }
 
-func TestGetPRDetails_ReleaseCreatedAtInTimestamps(t *testing.T) {
-	// Test that release_created_at appears in timestamps object, not at top level
-	pr := &github.PullRequest{
-		Title:    stringPtr("Test PR"),
-		HTMLURL:  stringPtr("https://github.com/org/repo/pull/1"),
-		NodeID:   stringPtr("PR_node123"),
-		User:     &github.User{Login: stringPtr("author")},
-		Merged:   boolPtr(true),
-		MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
-		CreatedAt: timePtr(time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+func TestCountReviewRequestRounds(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	request := func(reviewer string, offset time.Duration) *github.Timeline {
+		return &github.Timeline{
+			Event:     stringPtr("review_requested"),
+			CreatedAt: timePtr(base.Add(offset)),
+			Reviewer:  &github.User{Login: stringPtr(reviewer)},
+		}
+	}
+	review := func(reviewer string, offset time.Duration) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: stringPtr(reviewer)}, SubmittedAt: timePtr(base.Add(offset))}
 	}
 
-	releases := []*github.RepositoryRelease{
-		{
-			Name:        stringPtr("v1.0.0"),
-			TagName:     stringPtr("v1.0.0"),
-			PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+	tests := []struct {
+		name     string
+		timeline []*github.Timeline
+		reviews  []*github.PullRequestReview
+		expected int
+	}{
+		{
+			name:     "no requests",
+			expected: 0,
+		},
+		{
+			name:     "different reviewers",
+			timeline: []*github.Timeline{request("reviewer1", 0), request("reviewer2", 0)},
+			expected: 2,
+		},
+		{
+			name:     "repeated request without review counts once",
+			timeline: []*github.Timeline{request("reviewer1", 0), request("reviewer1", 2*time.Hour)},
+			expected: 1,
+		},
+		{
+			name:     "re-request after review is a new round",
+			timeline: []*github.Timeline{request("reviewer1", 0), request("reviewer1", 3*time.Hour)},
+			reviews:  []*github.PullRequestReview{review("reviewer1", 1*time.Hour)},
+			expected: 2,
+		},
+		{
+			name: "mixed reviewers and rounds",
+			timeline: []*github.Timeline{
+				request("reviewer1", 0),
+				request("reviewer2", 0),
+				request("reviewer2", 1*time.Hour),
+				request("reviewer1", 4*time.Hour),
+				request("reviewer1", 5*time.Hour),
+			},
+			reviews:  []*github.PullRequestReview{review("reviewer1", 2*time.Hour), review("reviewer2", 6*time.Hour)},
+			expected: 3,
+		},
+		{
+			name: "team requests",
+			timeline: []*github.Timeline{
+				{Event: stringPtr("review_requested"), CreatedAt: timePtr(base), RequestedTeam: &github.Team{Slug: stringPtr("core")}},
+				{Event: stringPtr("review_requested"), CreatedAt: timePtr(base.Add(time.Hour)), RequestedTeam: &github.Team{Slug: stringPtr("core")}},
+			},
+			expected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := countReviewRequestRounds(tt.timeline, tt.reviews); result != tt.expected {
+				t.Errorf("countReviewRequestRounds() = %d, want %d", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountChangeRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		reviews  []*github.PullRequestReview
+		expected int
+	}{
+		{
+			name: "multiple change requests",
+			reviews: []*github.PullRequestReview{
+				{State: stringPtr("CHANGES_REQUESTED")},
+				{State: stringPtr("APPROVED")},
+				{State: stringPtr("CHANGES_REQUESTED")},
+				{State: stringPtr("COMMENTED")},
+			},
+			expected: 2,
+		},
+		{
+			name: "no change requests",
+			reviews: []*github.PullRequestReview{
+				{State: stringPtr("APPROVED")},
+				{State: stringPtr("COMMENTED")},
+			},
+			expected: 0,
+		},
+		{
+			name:     "no reviews",
+			reviews:  []*github.PullRequestReview{},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := countChangeRequests(tt.reviews)
+			if result != tt.expected {
+				t.Errorf("countChangeRequests() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountDistinctChangeRequesters(t *testing.T) {
+	tests := []struct {
+		name     string
+		reviews  []*github.PullRequestReview
+		expected int
+	}{
+		{
+			name: "same user requesting changes multiple times",
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("user1")}, State: stringPtr("CHANGES_REQUESTED")},
+				{User: &github.User{Login: stringPtr("user1")}, State: stringPtr("CHANGES_REQUESTED")},
+				{User: &github.User{Login: stringPtr("user1")}, State: stringPtr("CHANGES_REQUESTED")},
+			},
+			expected: 1,
+		},
+		{
+			name: "multiple users requesting changes",
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("user1")}, State: stringPtr("CHANGES_REQUESTED")},
+				{User: &github.User{Login: stringPtr("user2")}, State: stringPtr("CHANGES_REQUESTED")},
+				{User: &github.User{Login: stringPtr("user3")}, State: stringPtr("CHANGES_REQUESTED")},
+				{User: &github.User{Login: stringPtr("user4")}, State: stringPtr("APPROVED")},
+			},
+			expected: 3,
+		},
+		{
+			name: "no change requests",
+			reviews: []*github.PullRequestReview{
+				{User: &github.User{Login: stringPtr("user1")}, State: stringPtr("APPROVED")},
+			},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := countDistinctChangeRequesters(tt.reviews)
+			if result != tt.expected {
+				t.Errorf("countDistinctChangeRequesters() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountChangeRequestResolutions(t *testing.T) {
+	review := func(login, state string, hour int) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			User:        &github.User{Login: stringPtr(login)},
+			State:       stringPtr(state),
+			SubmittedAt: timePtr(time.Date(2023, 1, 15, hour, 0, 0, 0, time.UTC)),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		reviews  []*github.PullRequestReview
+		expected int
+	}{
+		{
+			name: "change request resolved by later approval",
+			reviews: []*github.PullRequestReview{
+				review("user1", "CHANGES_REQUESTED", 10),
+				review("user1", "APPROVED", 12),
+			},
+			expected: 1,
+		},
+		{
+			name: "unresolved change request",
+			reviews: []*github.PullRequestReview{
+				review("user1", "CHANGES_REQUESTED", 10),
+				review("user2", "APPROVED", 12),
+			},
+			expected: 0,
+		},
+		{
+			name: "approval before change request does not count",
+			reviews: []*github.PullRequestReview{
+				review("user1", "APPROVED", 10),
+				review("user1", "CHANGES_REQUESTED", 12),
+			},
+			expected: 0,
+		},
+		{
+			name: "unordered input is sorted chronologically",
+			reviews: []*github.PullRequestReview{
+				review("user1", "APPROVED", 14),
+				review("user1", "CHANGES_REQUESTED", 10),
+				review("user1", "COMMENTED", 12),
+			},
+			expected: 1,
+		},
+		{
+			name: "multiple reviewers and repeated cycles",
+			reviews: []*github.PullRequestReview{
+				review("user1", "CHANGES_REQUESTED", 9),
+				review("user1", "CHANGES_REQUESTED", 10),
+				review("user1", "APPROVED", 11),
+				review("user1", "CHANGES_REQUESTED", 12),
+				review("user1", "APPROVED", 13),
+				review("user2", "CHANGES_REQUESTED", 14),
+				review("user2", "APPROVED", 15),
+			},
+			expected: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := countChangeRequestResolutions(tt.reviews)
+			if result != tt.expected {
+				t.Errorf("countChangeRequestResolutions() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergedOverUnresolvedChangeRequest(t *testing.T) {
+	review := func(login, state string, hour int) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			User:        &github.User{Login: stringPtr(login)},
+			State:       stringPtr(state),
+			SubmittedAt: timePtr(time.Date(2023, 1, 15, hour, 0, 0, 0, time.UTC)),
+		}
+	}
+	mergedPR := &github.PullRequest{
+		Merged:   boolPtr(true),
+		MergedAt: timePtr(time.Date(2023, 1, 15, 16, 0, 0, 0, time.UTC)),
+	}
+
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		reviews  []*github.PullRequestReview
+		expected bool
+	}{
+		{
+			name: "change request resolved by later approval",
+			pr:   mergedPR,
+			reviews: []*github.PullRequestReview{
+				review("user1", "CHANGES_REQUESTED", 10),
+				review("user1", "COMMENTED", 11),
+				review("user1", "APPROVED", 12),
+			},
+			expected: false,
+		},
+		{
+			name: "change request never resolved",
+			pr:   mergedPR,
+			reviews: []*github.PullRequestReview{
+				review("user1", "CHANGES_REQUESTED", 10),
+				review("user2", "APPROVED", 12),
+			},
+			expected: true,
+		},
+		{
+			name: "approval followed by change request",
+			pr:   mergedPR,
+			reviews: []*github.PullRequestReview{
+				review("user1", "APPROVED", 10),
+				review("user1", "CHANGES_REQUESTED", 12),
+			},
+			expected: true,
+		},
+		{
+			name: "approval after merge does not resolve",
+			pr:   mergedPR,
+			reviews: []*github.PullRequestReview{
+				review("user1", "CHANGES_REQUESTED", 10),
+				review("user1", "APPROVED", 18),
+			},
+			expected: true,
+		},
+		{
+			name: "dismissed change request",
+			pr:   mergedPR,
+			reviews: []*github.PullRequestReview{
+				review("user1", "DISMISSED", 10),
+				review("user2", "APPROVED", 12),
+			},
+			expected: false,
+		},
+		{
+			name: "unmerged PR",
+			pr:   &github.PullRequest{State: stringPtr("open")},
+			reviews: []*github.PullRequestReview{
+				review("user1", "CHANGES_REQUESTED", 10),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := mergedOverUnresolvedChangeRequest(tt.pr, tt.reviews)
+			if result != tt.expected {
+				t.Errorf("mergedOverUnresolvedChangeRequest() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountApprovalsAroundLastCommit(t *testing.T) {
+	lastCommit := time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+	commitAt := func(at time.Time) *github.RepositoryCommit {
+		return &github.RepositoryCommit{Commit: &github.Commit{Author: &github.CommitAuthor{Date: timePtr(at)}}}
+	}
+	review := func(state string, at time.Time) *github.PullRequestReview {
+		return &github.PullRequestReview{State: stringPtr(state), SubmittedAt: timePtr(at)}
+	}
+
+	tests := []struct {
+		name           string
+		reviews        []*github.PullRequestReview
+		commits        []*github.RepositoryCommit
+		expectedBefore int
+		expectedAfter  int
+	}{
+		{
+			name: "approvals straddling the last commit",
+			reviews: []*github.PullRequestReview{
+				review("APPROVED", lastCommit.Add(-2*time.Hour)),
+				review("APPROVED", lastCommit.Add(time.Hour)),
+				review("APPROVED", lastCommit.Add(2*time.Hour)),
+			},
+			commits:        []*github.RepositoryCommit{commitAt(lastCommit), commitAt(lastCommit.Add(-3 * time.Hour))},
+			expectedBefore: 1,
+			expectedAfter:  2,
+		},
+		{
+			name: "approval at the last commit time counts as after",
+			reviews: []*github.PullRequestReview{
+				review("APPROVED", lastCommit),
+			},
+			commits:        []*github.RepositoryCommit{commitAt(lastCommit)},
+			expectedBefore: 0,
+			expectedAfter:  1,
+		},
+		{
+			name: "non-approval reviews are ignored",
+			reviews: []*github.PullRequestReview{
+				review("CHANGES_REQUESTED", lastCommit.Add(-time.Hour)),
+				review("COMMENTED", lastCommit.Add(time.Hour)),
+			},
+			commits:        []*github.RepositoryCommit{commitAt(lastCommit)},
+			expectedBefore: 0,
+			expectedAfter:  0,
+		},
+		{
+			name: "no commits",
+			reviews: []*github.PullRequestReview{
+				review("APPROVED", lastCommit),
+			},
+			commits:        nil,
+			expectedBefore: 0,
+			expectedAfter:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := countApprovalsAroundLastCommit(tt.reviews, tt.commits)
+			if before != tt.expectedBefore || after != tt.expectedAfter {
+				t.Errorf("countApprovalsAroundLastCommit() = (%v, %v), want (%v, %v)", before, after, tt.expectedBefore, tt.expectedAfter)
+			}
+		})
+	}
+}
+
+func TestCountPostMergeApprovals(t *testing.T) {
+	review := func(login, state string, hour int) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			User:        &github.User{Login: stringPtr(login)},
+			State:       stringPtr(state),
+			SubmittedAt: timePtr(time.Date(2023, 1, 15, hour, 0, 0, 0, time.UTC)),
+		}
+	}
+	mergedPR := &github.PullRequest{
+		Merged:   boolPtr(true),
+		MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+	}
+	reviews := []*github.PullRequestReview{
+		review("user1", "APPROVED", 10),
+		review("user2", "APPROVED", 14),
+		review("user3", "COMMENTED", 15),
+		review("user1", "APPROVED", 16),
+	}
+
+	if got := countPostMergeApprovals(mergedPR, reviews); got != 2 {
+		t.Errorf("countPostMergeApprovals() = %v, want 2", got)
+	}
+	if got := countPostMergeApprovals(&github.PullRequest{State: stringPtr("open")}, reviews); got != 0 {
+		t.Errorf("countPostMergeApprovals() for unmerged PR = %v, want 0", got)
+	}
+
+	approvers := getApprovers(reviewsBeforeMerge(mergedPR, reviews))
+	if len(approvers) != 1 || approvers[0] != "user1" {
+		t.Errorf("getApprovers(reviewsBeforeMerge()) = %v, want [user1]", approvers)
+	}
+}
+
+func TestAnalyzeFromPR_ExcludePostMergeApprovals(t *testing.T) {
+	tests := []struct {
+		name              string
+		config            Config
+		expectedApprovers int
+	}{
+		{name: "post-merge approvals counted by default", config: Config{}, expectedApprovers: 2},
+		{name: "post-merge approvals excluded", config: Config{ExcludePostMergeApprovals: true}, expectedApprovers: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"user":{"login":"reviewer1"},"state":"APPROVED","submitted_at":"2023-01-15T11:00:00Z"},
+					{"user":{"login":"reviewer2"},"state":"APPROVED","submitted_at":"2023-01-15T13:00:00Z"}
+				]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{
+				Number:    intPtr(1),
+				Title:     stringPtr("ABC-1 Fix"),
+				State:     stringPtr("closed"),
+				Merged:    boolPtr(true),
+				User:      &github.User{Login: stringPtr("author")},
+				CreatedAt: timePtr(time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+				MergedAt:  timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.NumApprovers != tt.expectedApprovers {
+				t.Errorf("AnalyzeFromPR().NumApprovers = %v, want %v", details.NumApprovers, tt.expectedApprovers)
+			}
+			if details.PostMergeApprovals != 1 {
+				t.Errorf("AnalyzeFromPR().PostMergeApprovals = %v, want 1", details.PostMergeApprovals)
+			}
+		})
+	}
+}
+
+func TestCountPushes(t *testing.T) {
+	start := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	commit := func(committer string, minutes int) *github.RepositoryCommit {
+		return &github.RepositoryCommit{
+			Committer: &github.User{Login: stringPtr(committer)},
+			Commit: &github.Commit{
+				Committer: &github.CommitAuthor{Date: timePtr(start.Add(time.Duration(minutes) * time.Minute))},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		commits  []*github.RepositoryCommit
+		timeline []*github.Timeline
+		expected int
+	}{
+		{
+			name:     "bursty commits pushed together",
+			commits:  []*github.RepositoryCommit{commit("dev", 0), commit("dev", 1), commit("dev", 2), commit("dev", 4)},
+			expected: 1,
+		},
+		{
+			name:     "incremental commits",
+			commits:  []*github.RepositoryCommit{commit("dev", 0), commit("dev", 60), commit("dev", 180), commit("dev", 1440)},
+			expected: 4,
+		},
+		{
+			name:     "mixed bursts, out of order",
+			commits:  []*github.RepositoryCommit{commit("dev", 121), commit("dev", 0), commit("dev", 120), commit("dev", 2)},
+			expected: 2,
+		},
+		{
+			name:     "different committers split a burst",
+			commits:  []*github.RepositoryCommit{commit("dev", 0), commit("reviewer", 1)},
+			expected: 2,
+		},
+		{
+			name:    "force pushes add a push",
+			commits: []*github.RepositoryCommit{commit("dev", 0), commit("dev", 0)},
+			timeline: []*github.Timeline{
+				{Event: stringPtr("head_ref_force_pushed")},
+				{Event: stringPtr("committed")},
+			},
+			expected: 2,
+		},
+		{
+			name:     "no commits",
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countPushes(tt.commits, tt.timeline); got != tt.expected {
+				t.Errorf("countPushes() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetReviewerStats(t *testing.T) {
+	start := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	review := func(login, state string, hours float64) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			User:        &github.User{Login: stringPtr(login)},
+			State:       stringPtr(state),
+			SubmittedAt: timePtr(start.Add(time.Duration(hours * float64(time.Hour)))),
+		}
+	}
+	requested := func(login string, hours float64) *github.Timeline {
+		return reviewRequestEvent(EventReviewRequested, login, start.Add(time.Duration(hours*float64(time.Hour))))
+	}
+
+	reviews := []*github.PullRequestReview{
+		review("fast", "APPROVED", 1),
+		review("slow", "CHANGES_REQUESTED", 2),
+		review("slow", "APPROVED", 26),
+		review("unrequested", "APPROVED", 3),
+		review("commenter", "COMMENTED", 4),
+		review("author", "COMMENTED", 5),
+		review("early", "APPROVED", 1),
+		review("early", "APPROVED", 8),
+	}
+	timeline := []*github.Timeline{
+		requested("fast", 0),
+		requested("slow", 0),
+		requested("slow", 20),
+		requested("commenter", 0),
+		requested("early", 2),
+	}
+
+	stats := getReviewerStats(reviews, timeline, "author")
+
+	expected := []struct {
+		username   string
+		numReviews int
+		hours      *float64
+	}{
+		{username: "commenter", numReviews: 1, hours: nil},
+		{username: "early", numReviews: 2, hours: float64Ptr(6)},
+		{username: "fast", numReviews: 1, hours: float64Ptr(1)},
+		{username: "slow", numReviews: 2, hours: float64Ptr(26)},
+		{username: "unrequested", numReviews: 1, hours: nil},
+	}
+	if len(stats) != len(expected) {
+		t.Fatalf("getReviewerStats() = %v, want %d reviewers", stats, len(expected))
+	}
+	for i, want := range expected {
+		if stats[i].Username != want.username || stats[i].NumReviews != want.numReviews {
+			t.Errorf("getReviewerStats()[%d] = %s with %d reviews, want %s with %d", i, stats[i].Username, stats[i].NumReviews, want.username, want.numReviews)
+		}
+		assertFloat64Ptr(t, "getReviewerStats()["+want.username+"].TimeToApprovalHours", stats[i].TimeToApprovalHours, want.hours)
+	}
+}
+
+func TestCountReviewCommentsByReviewer(t *testing.T) {
+	comment := func(login string) *github.PullRequestComment {
+		return &github.PullRequestComment{User: &github.User{Login: stringPtr(login)}}
+	}
+
+	tests := []struct {
+		name           string
+		reviewComments []*github.PullRequestComment
+		expected       map[string]int
+	}{
+		{
+			name: "comments spread across reviewers",
+			reviewComments: []*github.PullRequestComment{
+				comment("reviewer1"), comment("reviewer2"), comment("reviewer1"),
+				comment("author"), comment("reviewer1"), comment("reviewer3"),
+			},
+			expected: map[string]int{"reviewer1": 3, "reviewer2": 1, "reviewer3": 1},
+		},
+		{
+			name:           "deleted user",
+			reviewComments: []*github.PullRequestComment{{User: nil}, {User: nil}},
+			expected:       map[string]int{DeletedUserLogin: 2},
+		},
+		{
+			name:           "only author comments",
+			reviewComments: []*github.PullRequestComment{comment("author"), comment("author")},
+			expected:       nil,
+		},
+		{
+			name:           "no comments",
+			reviewComments: nil,
+			expected:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := countReviewCommentsByReviewer(tt.reviewComments, "author")
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("countReviewCommentsByReviewer() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPRDetails_ReviewCommentsByReviewerSortedKeys(t *testing.T) {
+	details := PRDetails{ReviewCommentsByReviewer: map[string]int{"zed": 1, "amy": 2, "mia": 3}}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"review_comments_by_reviewer":{"amy":2,"mia":3,"zed":1}`) {
+		t.Errorf("json.Marshal() = %s, want review_comments_by_reviewer keys sorted", data)
+	}
+}
+
+func TestCalculateMedianReviewerResponse(t *testing.T) {
+	created := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(hours float64) *github.Timestamp {
+		return timePtr(created.Add(time.Duration(hours * float64(time.Hour))))
+	}
+	review := func(login string, hours float64) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: stringPtr(login)}, State: stringPtr("COMMENTED"), SubmittedAt: at(hours)}
+	}
+	request := func(login string, hours float64) *github.Timeline {
+		return reviewRequestEvent(EventReviewRequested, login, at(hours).Time)
+	}
+
+	tests := []struct {
+		name           string
+		reviews        []*github.PullRequestReview
+		comments       []*github.IssueComment
+		reviewComments []*github.PullRequestComment
+		timeline       []*github.Timeline
+		expected       *float64
+	}{
+		{
+			name:     "odd number of reviewers",
+			reviews:  []*github.PullRequestReview{review("user1", 2), review("user2", 3), review("user3", 7)},
+			timeline: []*github.Timeline{request("user1", 1), request("user2", 1), request("user3", 1)},
+			expected: float64Ptr(2),
+		},
+		{
+			name:     "even number of reviewers",
+			reviews:  []*github.PullRequestReview{review("user1", 2), review("user2", 5)},
+			timeline: []*github.Timeline{request("user1", 1), request("user2", 1)},
+			expected: float64Ptr(2.5),
+		},
+		{
+			name:     "comments count as responses",
+			reviews:  []*github.PullRequestReview{review("user1", 6)},
+			comments: []*github.IssueComment{{User: &github.User{Login: stringPtr("user1")}, CreatedAt: at(3)}},
+			reviewComments: []*github.PullRequestComment{
+				{User: &github.User{Login: stringPtr("user1")}, CreatedAt: at(4)},
+			},
+			timeline: []*github.Timeline{request("user1", 1)},
+			expected: float64Ptr(2),
+		},
+		{
+			name:     "activity before the request is not a response",
+			reviews:  []*github.PullRequestReview{review("user1", 0.5), review("user1", 4)},
+			timeline: []*github.Timeline{request("user1", 1)},
+			expected: float64Ptr(3),
+		},
+		{
+			name:     "unrequested reviewers are ignored",
+			reviews:  []*github.PullRequestReview{review("user1", 2)},
+			timeline: []*github.Timeline{request("user2", 1)},
+			expected: nil,
+		},
+		{
+			name:     "no reviews",
+			timeline: []*github.Timeline{request("user1", 1)},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateMedianReviewerResponse(tt.reviews, tt.comments, tt.reviewComments, tt.timeline)
+			assertFloat64Ptr(t, "calculateMedianReviewerResponse()", result, tt.expected)
+		})
+	}
+}
+
+func TestIsBot(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		expected bool
+	}{
+		{
+			name:     "dependabot user",
+			username: "dependabot[bot]",
+			expected: true,
+		},
+		{
+			name:     "github actions bot",
+			username: "github-actions[bot]",
+			expected: true,
+		},
+		{
+			name:     "regular user",
+			username: "john_doe",
+			expected: false,
+		},
+		{
+			name:     "user with bot in name but not bracketed",
+			username: "robotuser",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isBot(tt.username)
+			if result != tt.expected {
+				t.Errorf("isBot(%s) = %v, want %v", tt.username, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsRevertAndAutoGenerated(t *testing.T) {
+	custom := []*regexp.Regexp{regexp.MustCompile(`^\[sync\]`)}
+
+	tests := []struct {
+		name                  string
+		title                 string
+		author                string
+		patterns              []*regexp.Regexp
+		expectedRevert        bool
+		expectedAutoGenerated bool
+	}{
+		{
+			name:                  "revert title",
+			title:                 `Revert "Add caching layer"`,
+			author:                "user1",
+			expectedRevert:        true,
+			expectedAutoGenerated: true,
+		},
+		{
+			name:                  "merge-back title",
+			title:                 "Merge branch 'release/1.2' into main",
+			author:                "user1",
+			expectedRevert:        false,
+			expectedAutoGenerated: true,
+		},
+		{
+			name:                  "normal title",
+			title:                 "Fix revert handling in parser",
+			author:                "user1",
+			expectedRevert:        false,
+			expectedAutoGenerated: false,
+		},
+		{
+			name:                  "bot author with normal title",
+			title:                 "Bump lodash from 4.17.20 to 4.17.21",
+			author:                "dependabot[bot]",
+			expectedRevert:        false,
+			expectedAutoGenerated: true,
+		},
+		{
+			name:                  "custom pattern replaces defaults",
+			title:                 "Merge branch 'release/1.2' into main",
+			author:                "user1",
+			patterns:              custom,
+			expectedRevert:        false,
+			expectedAutoGenerated: false,
+		},
+		{
+			name:                  "custom pattern match",
+			title:                 "[sync] upstream changes",
+			author:                "user1",
+			patterns:              custom,
+			expectedRevert:        false,
+			expectedAutoGenerated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				Title: stringPtr(tt.title),
+				User:  &github.User{Login: stringPtr(tt.author)},
+			}
+			if result := isRevert(pr); result != tt.expectedRevert {
+				t.Errorf("isRevert() = %v, want %v", result, tt.expectedRevert)
+			}
+			if result := isAutoGenerated(pr, tt.patterns); result != tt.expectedAutoGenerated {
+				t.Errorf("isAutoGenerated() = %v, want %v", result, tt.expectedAutoGenerated)
+			}
+		})
+	}
+}
+
+func TestNewAnalyzer_InvalidAutoGeneratedPattern(t *testing.T) {
+	if _, err := NewAnalyzer(Config{GitHubToken: "token", AutoGeneratedPatterns: []string{"("}}); err == nil {
+		t.Error("NewAnalyzer() with invalid pattern expected error, got nil")
+	}
+}
+
+func TestExtractJiraIssue(t *testing.T) {
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		expected string
+	}{
+		{
+			name: "Jira issue in title",
+			pr: &github.PullRequest{
+				Title: stringPtr("Fix bug in ABC-123 authentication"),
+				Body:  stringPtr("This fixes the auth issue"),
+				User:  &github.User{Login: stringPtr("developer")},
+				Head: &github.PullRequestBranch{
+					Ref: stringPtr("feature-branch"),
+				},
+			},
+			expected: "ABC-123",
+		},
+		{
+			name: "Jira issue in body when not in title",
+			pr: &github.PullRequest{
+				Title: stringPtr("Fix authentication bug"),
+				Body:  stringPtr("This addresses DEF-456 by updating the token validation"),
+				User:  &github.User{Login: stringPtr("developer")},
+				Head: &github.PullRequestBranch{
+					Ref: stringPtr("feature-branch"),
+				},
+			},
+			expected: "DEF-456",
+		},
+		{
+			name: "Jira issue in branch name when not in title or body",
+			pr: &github.PullRequest{
+				Title: stringPtr("Fix authentication bug"),
+				Body:  stringPtr("This fixes the auth issue"),
+				User:  &github.User{Login: stringPtr("developer")},
+				Head: &github.PullRequestBranch{
+					Ref: stringPtr("feature/GHI-789-fix-auth"),
+				},
+			},
+			expected: "GHI-789",
+		},
+		{
+			name: "Bot user with no Jira issue",
+			pr: &github.PullRequest{
+				Title: stringPtr("Update dependencies"),
+				Body:  stringPtr("Automated dependency update"),
+				User:  &github.User{Login: stringPtr("dependabot[bot]")},
+				Head: &github.PullRequestBranch{
+					Ref: stringPtr("dependabot/npm_and_yarn/package-update"),
+				},
+			},
+			expected: "BOT",
+		},
+		{
+			name: "Regular user with no Jira issue",
+			pr: &github.PullRequest{
+				Title: stringPtr("Update documentation"),
+				Body:  stringPtr("Updated the README file"),
+				User:  &github.User{Login: stringPtr("developer")},
+				Head: &github.PullRequestBranch{
+					Ref: stringPtr("update-docs"),
+				},
+			},
+			expected: "UNKNOWN",
+		},
+		{
+			name: "CVE identifier should be excluded",
+			pr: &github.PullRequest{
+				Title: stringPtr("Security fix for CVE-2023-1234"),
+				Body:  stringPtr("This addresses the security vulnerability"),
+				User:  &github.User{Login: stringPtr("developer")},
+				Head: &github.PullRequestBranch{
+					Ref: stringPtr("security-fix"),
+				},
+			},
+			expected: "UNKNOWN", // CVE should be excluded
+		},
+		{
+			name: "Jira issue with CVE present - Jira should win",
+			pr: &github.PullRequest{
+				Title: stringPtr("SECURITY-123: Fix CVE-2023-1234 vulnerability"),
+				Body:  stringPtr("This addresses the CVE-2023-1234 security issue"),
+				User:  &github.User{Login: stringPtr("developer")},
+				Head: &github.PullRequestBranch{
+					Ref: stringPtr("security-fix"),
+				},
+			},
+			expected: "SECURITY-123", // Valid Jira issue should be returned, CVE ignored
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractJiraIssue(tt.pr, DefaultJiraUnknownValue, DefaultJiraBotValue)
+			if result != tt.expected {
+				t.Errorf("extractJiraIssue() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatToUTC(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+		expected  string
+	}{
+		{
+			name:      "RFC3339 timestamp",
+			timestamp: "2023-01-15T10:30:45Z",
+			expected:  "2023-01-15T10:30:45Z",
+		},
+		{
+			name:      "timestamp with timezone",
+			timestamp: "2023-01-15T10:30:45-08:00",
+			expected:  "2023-01-15T18:30:45Z", // Converted to UTC
+		},
+		{
+			name:      "invalid timestamp",
+			timestamp: "invalid-timestamp",
+			expected:  "invalid-timestamp", // Should return original if parsing fails
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatToUTC(tt.timestamp)
+			if result != tt.expected {
+				t.Errorf("formatToUTC(%s) = %v, want %v", tt.timestamp, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCalculatePRSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []*github.CommitFile
+		expected *PRSize
+	}{
+		{
+			name: "multiple files with changes",
+			files: []*github.CommitFile{
+				{
+					Filename:  stringPtr("file1.go"),
+					Additions: intPtr(10),
+					Deletions: intPtr(5),
+				},
+				{
+					Filename:  stringPtr("file2.go"),
+					Additions: intPtr(20),
+					Deletions: intPtr(3),
+				},
+			},
+			expected: &PRSize{
+				LinesChanged: 38, // 10+5+20+3
+				FilesChanged: 2,
+			},
+		},
+		{
+			name: "single file",
+			files: []*github.CommitFile{
+				{
+					Filename:  stringPtr("file1.go"),
+					Additions: intPtr(15),
+					Deletions: intPtr(8),
+				},
+			},
+			expected: &PRSize{
+				LinesChanged: 23, // 15+8
+				FilesChanged: 1,
+			},
+		},
+		{
+			name:  "no files",
+			files: []*github.CommitFile{},
+			expected: &PRSize{
+				LinesChanged: 0,
+				FilesChanged: 0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculatePRSize(tt.files)
+			if result.LinesChanged != tt.expected.LinesChanged {
+				t.Errorf("calculatePRSize().LinesChanged = %v, want %v", result.LinesChanged, tt.expected.LinesChanged)
+			}
+			if result.FilesChanged != tt.expected.FilesChanged {
+				t.Errorf("calculatePRSize().FilesChanged = %v, want %v", result.FilesChanged, tt.expected.FilesChanged)
+			}
+		})
+	}
+}
+
+func TestIsEmptyPR(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []*github.CommitFile
+		truncated []string
+		expected  bool
+	}{
+		{
+			name:     "no files changed",
+			files:    nil,
+			expected: true,
+		},
+		{
+			name:     "files changed",
+			files:    []*github.CommitFile{{Filename: stringPtr("main.go"), Additions: intPtr(1)}},
+			expected: false,
+		},
+		{
+			name:     "only a rename without line changes",
+			files:    []*github.CommitFile{{Filename: stringPtr("new.go"), PreviousFilename: stringPtr("old.go"), Status: stringPtr("renamed")}},
+			expected: false,
+		},
+		{
+			name:      "file list cut short",
+			files:     nil,
+			truncated: []string{"timeline", "files"},
+			expected:  false,
+		},
+		{
+			name:      "other data cut short",
+			files:     nil,
+			truncated: []string{"commits"},
+			expected:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := isEmptyPR(calculatePRSize(tt.files), tt.truncated); result != tt.expected {
+				t.Errorf("isEmptyPR() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchChangedFiles(t *testing.T) {
+	file := func(name string) *github.CommitFile {
+		return &github.CommitFile{Filename: stringPtr(name)}
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		files    []*github.CommitFile
+		expected []string
+	}{
+		{
+			name:     "matching files",
+			patterns: []string{".github/workflows/**", "*.tf", "internal/auth/*"},
+			files: []*github.CommitFile{
+				file("README.md"),
+				file("internal/auth/token.go"),
+				file("infra/prod/main.tf"),
+				file(".github/workflows/ci.yml"),
+			},
+			expected: []string{".github/workflows/ci.yml", "infra/prod/main.tf", "internal/auth/token.go"},
+		},
+		{
+			name:     "no matching files",
+			patterns: []string{".github/workflows/**", "*.tf", "internal/auth/*"},
+			files: []*github.CommitFile{
+				file("README.md"),
+				file("internal/auth/oauth/client.go"),
+				file("docs/terraform.md"),
+			},
+			expected: nil,
+		},
+		{
+			name:     "double star across directories",
+			patterns: []string{"**/secrets/**"},
+			files:    []*github.CommitFile{file("secrets/key.pem"), file("deploy/secrets/prod/env"), file("secretsmanager.go")},
+			expected: []string{"deploy/secrets/prod/env", "secrets/key.pem"},
+		},
+		{
+			name:     "renamed away from a sensitive path",
+			patterns: []string{"auth/**"},
+			files:    []*github.CommitFile{{Filename: stringPtr("legacy/login.go"), PreviousFilename: stringPtr("auth/login.go")}},
+			expected: []string{"legacy/login.go"},
+		},
+		{
+			name:     "question mark and literal dots",
+			patterns: []string{"config/v?.yaml"},
+			files:    []*github.CommitFile{file("config/v1.yaml"), file("config/v10.yaml"), file("config/v1xyaml")},
+			expected: []string{"config/v1.yaml"},
+		},
+		{
+			name:     "no patterns",
+			files:    []*github.CommitFile{file(".github/workflows/ci.yml")},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := compileGlobs(tt.patterns)
+			if err != nil {
+				t.Fatalf("compileGlobs() error = %v", err)
+			}
+			result := matchChangedFiles(tt.files, patterns)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("matchChangedFiles() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_ExcludeFileGlobs(t *testing.T) {
+	pr := &github.PullRequest{Number: intPtr(1), User: &github.User{Login: stringPtr("author")}}
+
+	tests := []struct {
+		name             string
+		globs            []string
+		expectedFiles    int
+		expectedLines    int
+		expectedExcluded int
+		expectedPatch    int
+	}{
+		{
+			name:             "test files excluded",
+			globs:            []string{"**/*_test.go"},
+			expectedFiles:    3,
+			expectedLines:    25,
+			expectedExcluded: 2,
+			expectedPatch:    len("@@ -1 +1 @@") + len("@@ -2 +2 @@"),
+		},
+		{
+			name:             "no globs",
+			globs:            nil,
+			expectedFiles:    5,
+			expectedLines:    96,
+			expectedExcluded: 0,
+			expectedPatch:    len("@@ -1 +1 @@") + len("@@ -1,40 +1,40 @@") + len("@@ -2 +2 @@") + len("@@ -3,30 +3,30 @@"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"filename":"main.go","additions":10,"deletions":2,"patch":"@@ -1 +1 @@"},
+					{"filename":"main_test.go","additions":40,"deletions":0,"patch":"@@ -1,40 +1,40 @@"},
+					{"filename":"pkg/auth/token.go","additions":5,"deletions":5,"patch":"@@ -2 +2 @@"},
+					{"filename":"pkg/auth/token_test.go","additions":30,"deletions":1,"patch":"@@ -3,30 +3,30 @@"},
+					{"filename":"docs/testing.md","additions":3,"deletions":0}
+				]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			patterns, err := compileGlobs(tt.globs)
+			if err != nil {
+				t.Fatalf("compileGlobs() error = %v", err)
+			}
+			analyzer.config = Config{ExcludeFileGlobs: tt.globs, IncludePatchStats: true}
+			analyzer.excludeFilePatterns = patterns
+
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.FilesChanged != tt.expectedFiles || details.LinesChanged != tt.expectedLines {
+				t.Errorf("AnalyzeFromPR() files = %d, lines = %d, want %d, %d", details.FilesChanged, details.LinesChanged, tt.expectedFiles, tt.expectedLines)
+			}
+			if details.ExcludedFilesCount != tt.expectedExcluded {
+				t.Errorf("AnalyzeFromPR().ExcludedFilesCount = %d, want %d", details.ExcludedFilesCount, tt.expectedExcluded)
+			}
+			if details.TotalPatchBytes == nil || *details.TotalPatchBytes != tt.expectedPatch {
+				t.Errorf("AnalyzeFromPR().TotalPatchBytes = %v, want %d", details.TotalPatchBytes, tt.expectedPatch)
+			}
+		})
+	}
+}
+
+func TestIsHotfix(t *testing.T) {
+	tests := []struct {
+		name           string
+		baseBranch     string
+		labels         []string
+		branchPatterns []string
+		hotfixLabels   []string
+		expected       bool
+	}{
+		{
+			name:           "base branch matches release pattern",
+			baseBranch:     "release/2.4",
+			branchPatterns: []string{"release/*", "hotfix/*"},
+			expected:       true,
+		},
+		{
+			name:           "base branch matches hotfix pattern",
+			baseBranch:     "hotfix/login-crash",
+			branchPatterns: []string{"release/*", "hotfix/*"},
+			expected:       true,
+		},
+		{
+			name:           "base branch does not match",
+			baseBranch:     "main",
+			branchPatterns: []string{"release/*", "hotfix/*"},
+			expected:       false,
+		},
+		{
+			name:           "pattern does not cross segments",
+			baseBranch:     "release/2.4/rc",
+			branchPatterns: []string{"release/*"},
+			expected:       false,
+		},
+		{
+			name:         "hotfix label",
+			baseBranch:   "main",
+			labels:       []string{"bug", "Hotfix"},
+			hotfixLabels: []string{"hotfix"},
+			expected:     true,
+		},
+		{
+			name:         "no hotfix label",
+			baseBranch:   "main",
+			labels:       []string{"bug"},
+			hotfixLabels: []string{"hotfix"},
+			expected:     false,
+		},
+		{
+			name:       "nothing configured",
+			baseBranch: "hotfix/login-crash",
+			labels:     []string{"hotfix"},
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := compileGlobs(tt.branchPatterns)
+			if err != nil {
+				t.Fatalf("compileGlobs() error = %v", err)
+			}
+			pr := &github.PullRequest{Base: &github.PullRequestBranch{Ref: stringPtr(tt.baseBranch)}}
+			for _, label := range tt.labels {
+				pr.Labels = append(pr.Labels, &github.Label{Name: stringPtr(label)})
+			}
+			if result := isHotfix(pr, patterns, tt.hotfixLabels); result != tt.expected {
+				t.Errorf("isHotfix() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_SensitivePaths(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"filename":".github/workflows/release.yml"},{"filename":"main.go"}]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	patterns, err := compileGlobs([]string{".github/workflows/**"})
+	if err != nil {
+		t.Fatalf("compileGlobs() error = %v", err)
+	}
+	analyzer.sensitivePathPatterns = patterns
+
+	pr := &github.PullRequest{Number: intPtr(1), Title: stringPtr("ABC-1 Release"), User: &github.User{Login: stringPtr("author")}}
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+	if !details.TouchesSensitivePaths || !reflect.DeepEqual(details.SensitiveFilesTouched, []string{".github/workflows/release.yml"}) {
+		t.Errorf("AnalyzeFromPR() sensitive = %v %v, want true [.github/workflows/release.yml]", details.TouchesSensitivePaths, details.SensitiveFilesTouched)
+	}
+}
+
+func TestCalculatePatchBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []*github.CommitFile
+		expected int
+	}{
+		{
+			name: "text file",
+			files: []*github.CommitFile{
+				{Filename: stringPtr("main.go"), Patch: stringPtr("@@ -1 +1 @@\n-a\n+b")},
+			},
+			expected: 17,
+		},
+		{
+			name: "binary file has no patch",
+			files: []*github.CommitFile{
+				{Filename: stringPtr("logo.png"), Patch: nil},
+			},
+			expected: 0,
+		},
+		{
+			name: "binary and text files",
+			files: []*github.CommitFile{
+				{Filename: stringPtr("logo.png"), Patch: nil},
+				{Filename: stringPtr("main.go"), Patch: stringPtr("@@ -1 +1 @@\n-a\n+b")},
+				{Filename: stringPtr("util.go"), Patch: stringPtr("+c")},
+			},
+			expected: 19,
+		},
+		{
+			name:     "no files",
+			files:    []*github.CommitFile{},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculatePatchBytes(tt.files)
+			if result != tt.expected {
+				t.Errorf("calculatePatchBytes() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClassifyPRSize(t *testing.T) {
+	custom := SizeBuckets{XS: 5, S: 20, M: 100, L: 500}
+
+	tests := []struct {
+		name         string
+		linesChanged int
+		buckets      SizeBuckets
+		expected     string
+	}{
+		{name: "zero lines", linesChanged: 0, expected: "XS"},
+		{name: "just below XS threshold", linesChanged: 9, expected: "XS"},
+		{name: "at XS threshold", linesChanged: 10, expected: "S"},
+		{name: "just below S threshold", linesChanged: 49, expected: "S"},
+		{name: "at S threshold", linesChanged: 50, expected: "M"},
+		{name: "just below M threshold", linesChanged: 249, expected: "M"},
+		{name: "at M threshold", linesChanged: 250, expected: "L"},
+		{name: "just below L threshold", linesChanged: 999, expected: "L"},
+		{name: "at L threshold", linesChanged: 1000, expected: "XL"},
+		{name: "custom buckets below XS", linesChanged: 4, buckets: custom, expected: "XS"},
+		{name: "custom buckets at XS", linesChanged: 5, buckets: custom, expected: "S"},
+		{name: "custom buckets at L", linesChanged: 500, buckets: custom, expected: "XL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := classifyPRSize(tt.linesChanged, tt.buckets)
+			if result != tt.expected {
+				t.Errorf("classifyPRSize(%d) = %v, want %v", tt.linesChanged, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCalculateEngagementIndex(t *testing.T) {
+	details := &PRDetails{NumComments: 12, ChangeRequestsCount: 2, NumApprovers: 3, NumCommenters: 4}
+
+	tests := []struct {
+		name     string
+		details  *PRDetails
+		weights  EngagementWeights
+		expected float64
+	}{
+		{
+			name:     "default weights",
+			details:  details,
+			expected: 12 + 2*2 + 3 + 4,
+		},
+		{
+			name:     "custom weights",
+			details:  details,
+			weights:  EngagementWeights{Comments: 0.5, ChangeRequests: 3, Approvers: 2},
+			expected: 0.5*12 + 3*2 + 2*3,
+		},
+		{
+			name:     "no activity",
+			details:  &PRDetails{},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := calculateEngagementIndex(tt.details, tt.weights); result != tt.expected {
+				t.Errorf("calculateEngagementIndex() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCalculatePRMetrics_DraftTime(t *testing.T) {
+	tests := []struct {
+		name          string
+		timestamps    *Timestamps
+		expectedHours float64
+	}{
+		{
+			name: "draft time calculated when both timestamps exist",
+			timestamps: &Timestamps{
+				CreatedAt:          stringPtr("2023-01-15T10:00:00Z"),
+				FirstReviewRequest: stringPtr("2023-01-15T12:30:00Z"),
+			},
+			expectedHours: 2.5, // 2.5 hours
+		},
+		{
+			name: "zero draft time when created_at missing",
+			timestamps: &Timestamps{
+				FirstReviewRequest: stringPtr("2023-01-15T12:30:00Z"),
+			},
+			expectedHours: 0.0,
+		},
+		{
+			name: "zero draft time when first_review_request missing",
+			timestamps: &Timestamps{
+				CreatedAt: stringPtr("2023-01-15T10:00:00Z"),
+			},
+			expectedHours: 0.0,
+		},
+		{
+			name: "zero draft time when review request is before creation",
+			timestamps: &Timestamps{
+				CreatedAt:          stringPtr("2023-01-15T12:00:00Z"),
+				FirstReviewRequest: stringPtr("2023-01-15T10:00:00Z"), // Before creation
+			},
+			expectedHours: 0.0,
+		},
+		{
+			name: "zero draft time when review request is at same time as creation",
+			timestamps: &Timestamps{
+				CreatedAt:          stringPtr("2023-01-15T10:00:00Z"),
+				FirstReviewRequest: stringPtr("2023-01-15T10:00:00Z"), // Same time
+			},
+			expectedHours: 0.0, // Should be 0 since not after creation time
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := calculatePRMetrics(
+				&github.PullRequest{},
+				[]*github.PullRequestReview{},
+				[]*github.IssueComment{},
+				[]*github.Timeline{},
+				tt.timestamps,
+				time.Now(),
+			)
+
+			if metrics.DraftTimeHours != tt.expectedHours {
+				t.Errorf("calculatePRMetrics().DraftTimeHours = %v, want %v", metrics.DraftTimeHours, tt.expectedHours)
+			}
+		})
+	}
+}
+
+func TestComputeDraftIntervals(t *testing.T) {
+	created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	closed := created.Add(20 * time.Hour)
+	now := created.Add(30 * time.Hour)
+	event := func(name string, offset time.Duration) *github.Timeline {
+		return &github.Timeline{Event: stringPtr(name), CreatedAt: timePtr(created.Add(offset))}
+	}
+
+	tests := []struct {
+		name     string
+		timeline []*github.Timeline
+		draft    bool
+		open     bool
+		expected *float64
+	}{
+		{
+			name:     "never a draft",
+			timeline: []*github.Timeline{event("review_requested", time.Hour)},
+			expected: nil,
+		},
+		{
+			name:     "opened as draft",
+			timeline: []*github.Timeline{event("ready_for_review", 3*time.Hour)},
+			expected: float64Ptr(3.0),
+		},
+		{
+			name: "converted to draft after opening",
+			timeline: []*github.Timeline{
+				event("convert_to_draft", 2*time.Hour),
+				event("ready_for_review", 5*time.Hour),
+			},
+			expected: float64Ptr(3.0),
+		},
+		{
+			name: "multiple toggles",
+			timeline: []*github.Timeline{
+				event("ready_for_review", 1*time.Hour),
+				event("review_requested", 2*time.Hour),
+				event("convert_to_draft", 4*time.Hour),
+				event("ready_for_review", 6*time.Hour),
+				event("convert_to_draft", 10*time.Hour),
+				event("ready_for_review", 10*time.Hour+30*time.Minute),
+			},
+			expected: float64Ptr(3.5),
+		},
+		{
+			name:     "still a draft when closed",
+			timeline: []*github.Timeline{event("convert_to_draft", 16*time.Hour)},
+			draft:    true,
+			expected: float64Ptr(4.0),
+		},
+		{
+			name:     "draft for whole life without toggles",
+			draft:    true,
+			expected: float64Ptr(20.0),
+		},
+		{
+			name:     "still a draft while open",
+			timeline: []*github.Timeline{event("convert_to_draft", 25*time.Hour)},
+			draft:    true,
+			open:     true,
+			expected: float64Ptr(5.0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				Draft:     boolPtr(tt.draft),
+				CreatedAt: timePtr(created),
+			}
+			if !tt.open {
+				pr.ClosedAt = timePtr(closed)
+			}
+			assertFloat64Ptr(t, "computeDraftIntervals()", computeDraftIntervals(tt.timeline, pr, now), tt.expected)
+		})
+	}
+}
+
+func TestCalculateReopenedDraftHours(t *testing.T) {
+	created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := func(name string, offset time.Duration) *github.Timeline {
+		return &github.Timeline{Event: stringPtr(name), CreatedAt: timePtr(created.Add(offset))}
+	}
+	// draft -> ready -> draft -> ready -> draft -> ready
+	timeline := []*github.Timeline{
+		event("ready_for_review", 2*time.Hour),
+		event("review_requested", 2*time.Hour),
+		event("convert_to_draft", 5*time.Hour),
+		event("ready_for_review", 8*time.Hour),
+		event("convert_to_draft", 10*time.Hour),
+		event("ready_for_review", 11*time.Hour),
+	}
+	pr := &github.PullRequest{CreatedAt: timePtr(created), ClosedAt: timePtr(created.Add(20 * time.Hour))}
+
+	tests := []struct {
+		name               string
+		firstReviewRequest *string
+		expected           float64
+	}{
+		{"reconversions after review request", stringPtr("2024-01-01T12:00:00Z"), 4.0},
+		{"no review request", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := calculateReopenedDraftHours(timeline, pr, tt.firstReviewRequest, time.Now()); result != tt.expected {
+				t.Errorf("calculateReopenedDraftHours() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_DraftTimeIncludesReopens(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected float64
+	}{
+		{"initial draft time only", Config{}, 2.0},
+		{"includes reopened draft intervals", Config{DraftTimeIncludesReopens: true}, 6.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"event":"ready_for_review","created_at":"2024-01-01T12:00:00Z"},
+					{"event":"review_requested","created_at":"2024-01-01T12:00:00Z"},
+					{"event":"convert_to_draft","created_at":"2024-01-01T15:00:00Z"},
+					{"event":"ready_for_review","created_at":"2024-01-01T18:00:00Z"},
+					{"event":"convert_to_draft","created_at":"2024-01-01T20:00:00Z"},
+					{"event":"ready_for_review","created_at":"2024-01-01T21:00:00Z"}
+				]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{
+				Number:    intPtr(1),
+				User:      &github.User{Login: stringPtr("user1")},
+				CreatedAt: timePtr(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)),
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.Metrics.DraftTimeHours != tt.expected {
+				t.Errorf("AnalyzeFromPR().Metrics.DraftTimeHours = %v, want %v", details.Metrics.DraftTimeHours, tt.expected)
+			}
+			assertFloat64Ptr(t, "ActualDraftTimeHours", details.Metrics.ActualDraftTimeHours, float64Ptr(6.0))
+		})
+	}
+}
+
+func TestAnalyzeFromPR_DraftTimeUsesClock(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"event":"ready_for_review","created_at":"2024-01-01T12:00:00Z"},
+			{"event":"review_requested","created_at":"2024-01-01T12:00:00Z"},
+			{"event":"convert_to_draft","created_at":"2024-01-02T06:00:00Z"}
+		]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = Config{DraftTimeIncludesReopens: true}
+	analyzer.now = func() time.Time { return now }
+
+	pr := &github.PullRequest{
+		Number:    intPtr(1),
+		State:     stringPtr("open"),
+		Draft:     boolPtr(true),
+		User:      &github.User{Login: stringPtr("user1")},
+		CreatedAt: timePtr(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)),
+	}
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+	// Opened as a draft for 2 hours, then back in draft for the 4 hours up to the clock
+	assertFloat64Ptr(t, "ActualDraftTimeHours", details.Metrics.ActualDraftTimeHours, float64Ptr(6.0))
+	if details.Metrics.DraftTimeHours != 6.0 {
+		t.Errorf("AnalyzeFromPR().Metrics.DraftTimeHours = %v, want 6", details.Metrics.DraftTimeHours)
+	}
+}
+
+func TestExcludeClosedIntervals(t *testing.T) {
+	created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := func(name string, offset time.Duration) *github.Timeline {
+		return &github.Timeline{Event: stringPtr(name), CreatedAt: timePtr(created.Add(offset))}
+	}
+	// closed -> reopened -> closed -> reopened -> merged
+	timeline := []*github.Timeline{
+		event("closed", time.Hour),
+		event("reopened", 3*time.Hour),
+		event("review_requested", 4*time.Hour),
+		event("closed", 6*time.Hour),
+		event("reopened", 9*time.Hour),
+		event("closed", 12*time.Hour),
+	}
+
+	tests := []struct {
+		name        string
+		cycleHours  *float64
+		reviewStart *string
+		expected    *float64
+	}{
+		{"closed interval during review", float64Ptr(8), stringPtr("2024-01-01T14:00:00Z"), float64Ptr(5)},
+		{"closed interval straddling review start", float64Ptr(11), stringPtr("2024-01-01T12:00:00Z"), float64Ptr(7)},
+		{"no review cycle time", nil, stringPtr("2024-01-01T14:00:00Z"), nil},
+		{"no review start", float64Ptr(8), nil, float64Ptr(8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamps := &Timestamps{FirstReviewRequest: tt.reviewStart}
+			assertFloat64Ptr(t, "excludeClosedIntervals()", excludeClosedIntervals(tt.cycleHours, timestamps, timeline), tt.expected)
+		})
+	}
+
+	if got := countReopens(timeline); got != 2 {
+		t.Errorf("countReopens() = %d, want 2", got)
+	}
+}
+
+func TestAnalyzeFromPR_ExcludeClosedIntervals(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected float64
+	}{
+		{"includes closed interval", Config{}, 10.0},
+		{"excludes closed interval", Config{ExcludeClosedIntervals: true}, 7.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"event":"review_requested","created_at":"2024-01-01T12:00:00Z"},
+					{"event":"closed","created_at":"2024-01-01T14:00:00Z"},
+					{"event":"reopened","created_at":"2024-01-01T17:00:00Z"},
+					{"event":"merged","created_at":"2024-01-01T22:00:00Z"},
+					{"event":"closed","created_at":"2024-01-01T22:00:00Z"}
+				]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{
+				Number:    intPtr(1),
+				State:     stringPtr("closed"),
+				Merged:    boolPtr(true),
+				User:      &github.User{Login: stringPtr("user1")},
+				CreatedAt: timePtr(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)),
+				MergedAt:  timePtr(time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)),
+				ClosedAt:  timePtr(time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)),
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.ReopenCount != 1 {
+				t.Errorf("AnalyzeFromPR().ReopenCount = %d, want 1", details.ReopenCount)
+			}
+			assertFloat64Ptr(t, "ReviewCycleTimeHours", details.Metrics.ReviewCycleTimeHours, float64Ptr(tt.expected))
+		})
+	}
+}
+
+func TestCalculatePickupTime(t *testing.T) {
+	created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := func(name string, offset time.Duration) *github.Timeline {
+		return &github.Timeline{Event: stringPtr(name), CreatedAt: timePtr(created.Add(offset))}
+	}
+	review := func(offset time.Duration) *github.PullRequestReview {
+		return &github.PullRequestReview{State: stringPtr("COMMENTED"), SubmittedAt: timePtr(created.Add(offset))}
+	}
+
+	tests := []struct {
+		name     string
+		draft    bool
+		timeline []*github.Timeline
+		reviews  []*github.PullRequestReview
+		expected *float64
+	}{
+		{
+			name:     "non-draft PR measured from creation",
+			reviews:  []*github.PullRequestReview{review(5 * time.Hour), review(2 * time.Hour)},
+			expected: float64Ptr(2.0),
+		},
+		{
+			name:     "draft PR measured from ready for review",
+			timeline: []*github.Timeline{event("ready_for_review", 3*time.Hour)},
+			reviews:  []*github.PullRequestReview{review(1 * time.Hour), review(4 * time.Hour)},
+			expected: float64Ptr(1.0),
+		},
+		{
+			name:     "converted to draft later is ready from creation",
+			timeline: []*github.Timeline{event("convert_to_draft", 1*time.Hour), event("ready_for_review", 2*time.Hour)},
+			reviews:  []*github.PullRequestReview{review(3 * time.Hour)},
+			expected: float64Ptr(3.0),
+		},
+		{
+			name:     "still a draft",
+			draft:    true,
+			reviews:  []*github.PullRequestReview{review(1 * time.Hour)},
+			expected: nil,
+		},
+		{
+			name:     "no review",
+			expected: nil,
+		},
+		{
+			name:     "pending review without submission is ignored",
+			reviews:  []*github.PullRequestReview{{State: stringPtr("PENDING")}},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{Draft: boolPtr(tt.draft), CreatedAt: timePtr(created)}
+			assertFloat64Ptr(t, "calculatePickupTime()", calculatePickupTime(pr, tt.reviews, tt.timeline), tt.expected)
+		})
+	}
+}
+
+func TestFindReleaseForMergedPR_WithCreatedAt(t *testing.T) {
+	tests := []struct {
+		name                     string
+		pr                       *github.PullRequest
+		releases                 []*github.RepositoryRelease
+		expectedReleaseName      *string
+		expectedReleaseCreatedAt *string
+	}{
+		{
+			name: "merged PR with release and created timestamp",
+			pr: &github.PullRequest{
+				Merged:   boolPtr(true),
+				MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+			},
+			releases: []*github.RepositoryRelease{
+				{
+					Name:        stringPtr("v1.0.0"),
+					TagName:     stringPtr("v1.0.0"),
+					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+					CreatedAt:   timePtr(time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC)),
+				},
+			},
+			expectedReleaseName:      stringPtr("v1.0.0"),
+			expectedReleaseCreatedAt: stringPtr("2023-01-16T09:00:00Z"),
+		},
+		{
+			name: "merged PR with release but no created timestamp",
+			pr: &github.PullRequest{
+				Merged:   boolPtr(true),
+				MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+			},
+			releases: []*github.RepositoryRelease{
+				{
+					Name:        stringPtr("v1.0.0"),
+					TagName:     stringPtr("v1.0.0"),
+					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+					CreatedAt:   nil, // No creation timestamp
+				},
+			},
+			expectedReleaseName:      stringPtr("v1.0.0"),
+			expectedReleaseCreatedAt: nil,
+		},
+		{
+			name: "merged_at without merged flag",
+			pr: &github.PullRequest{
+				Merged:   boolPtr(false),
+				MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+			},
+			releases: []*github.RepositoryRelease{
+				{
+					Name:        stringPtr("v1.0.0"),
+					TagName:     stringPtr("v1.0.0"),
+					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+					CreatedAt:   timePtr(time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC)),
+				},
+			},
+			expectedReleaseName:      stringPtr("v1.0.0"),
+			expectedReleaseCreatedAt: stringPtr("2023-01-16T09:00:00Z"),
+		},
+		{
+			name: "unmerged PR",
+			pr: &github.PullRequest{
+				Merged:   boolPtr(false),
+				MergedAt: nil,
+			},
+			releases: []*github.RepositoryRelease{
+				{
+					Name:        stringPtr("v1.0.0"),
+					TagName:     stringPtr("v1.0.0"),
+					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+					CreatedAt:   timePtr(time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC)),
+				},
+			},
+			expectedReleaseName:      nil,
+			expectedReleaseCreatedAt: nil,
+		},
+		{
+			name: "merged PR with multiple releases, earliest selected",
+			pr: &github.PullRequest{
+				Merged:   boolPtr(true),
+				MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+			},
+			releases: []*github.RepositoryRelease{
+				{
+					Name:        stringPtr("v1.1.0"),
+					TagName:     stringPtr("v1.1.0"),
+					PublishedAt: timePtr(time.Date(2023, 1, 20, 10, 0, 0, 0, time.UTC)),
+					CreatedAt:   timePtr(time.Date(2023, 1, 20, 9, 0, 0, 0, time.UTC)),
+				},
+				{
+					Name:        stringPtr("v1.0.0"),
+					TagName:     stringPtr("v1.0.0"),
+					PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+					CreatedAt:   timePtr(time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC)),
+				},
+			},
+			expectedReleaseName:      stringPtr("v1.0.0"), // Earliest release
+			expectedReleaseCreatedAt: stringPtr("2023-01-16T09:00:00Z"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releaseName, releaseCreatedAt, _ := findReleaseForMergedPR(tt.pr, tt.releases, false)
+
+			if tt.expectedReleaseName == nil {
+				if releaseName != nil {
+					t.Errorf("findReleaseForMergedPR() releaseName = %v, want nil", *releaseName)
+				}
+			} else {
+				if releaseName == nil {
+					t.Errorf("findReleaseForMergedPR() releaseName = nil, want %v", *tt.expectedReleaseName)
+				} else if *releaseName != *tt.expectedReleaseName {
+					t.Errorf("findReleaseForMergedPR() releaseName = %v, want %v", *releaseName, *tt.expectedReleaseName)
+				}
+			}
+
+			if tt.expectedReleaseCreatedAt == nil {
+				if releaseCreatedAt != nil && *releaseCreatedAt != "" {
+					t.Errorf("findReleaseForMergedPR() releaseCreatedAt = %v, want nil or empty", *releaseCreatedAt)
+				}
+			} else {
+				if releaseCreatedAt == nil {
+					t.Errorf("findReleaseForMergedPR() releaseCreatedAt = nil, want %v", *tt.expectedReleaseCreatedAt)
+				} else if *releaseCreatedAt != *tt.expectedReleaseCreatedAt {
+					t.Errorf("findReleaseForMergedPR() releaseCreatedAt = %v, want %v", *releaseCreatedAt, *tt.expectedReleaseCreatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestFindReleaseForMergedPR_PreReleases(t *testing.T) {
+	pr := &github.PullRequest{
+		Merged:   boolPtr(true),
+		MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+	}
+	releases := []*github.RepositoryRelease{
+		{
+			Name:        stringPtr("v2.0.0"),
+			TagName:     stringPtr("v2.0.0"),
+			PublishedAt: timePtr(time.Date(2023, 1, 20, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			Name:        stringPtr("v2.0.0-rc1"),
+			TagName:     stringPtr("v2.0.0-rc1"),
+			Prerelease:  boolPtr(true),
+			PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			Name:        stringPtr("v2.0.0-draft"),
+			TagName:     stringPtr("v2.0.0-draft"),
+			Draft:       boolPtr(true),
+			PublishedAt: timePtr(time.Date(2023, 1, 17, 10, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	tests := []struct {
+		name               string
+		releases           []*github.RepositoryRelease
+		includePreReleases bool
+		expectedName       string
+		expectedPrerelease bool
+	}{
+		{
+			name:         "pre-release skipped by default",
+			releases:     releases,
+			expectedName: "v2.0.0",
+		},
+		{
+			name:               "pre-release matched when included",
+			releases:           releases,
+			includePreReleases: true,
+			expectedName:       "v2.0.0-rc1",
+			expectedPrerelease: true,
+		},
+		{
+			name:               "draft matched when included",
+			releases:           []*github.RepositoryRelease{releases[0], releases[2]},
+			includePreReleases: true,
+			expectedName:       "v2.0.0-draft",
+			expectedPrerelease: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releaseName, _, prerelease := findReleaseForMergedPR(pr, tt.releases, tt.includePreReleases)
+			if releaseName == nil || *releaseName != tt.expectedName {
+				t.Errorf("findReleaseForMergedPR() releaseName = %v, want %v", releaseName, tt.expectedName)
+			}
+			if prerelease != tt.expectedPrerelease {
+				t.Errorf("findReleaseForMergedPR() prerelease = %v, want %v", prerelease, tt.expectedPrerelease)
+			}
+		})
+	}
+
+	onlyPreRelease := releases[1:2]
+	if releaseName, _, _ := findReleaseForMergedPR(pr, onlyPreRelease, false); releaseName != nil {
+		t.Errorf("findReleaseForMergedPR() releaseName = %v, want nil when only a pre-release follows the merge", *releaseName)
+	}
+}
+
+func TestGetPRDetails_ReleaseCreatedAtInTimestamps(t *testing.T) {
+	// Test that release_created_at appears in timestamps object, not at top level
+	pr := &github.PullRequest{
+		Title:     stringPtr("Test PR"),
+		HTMLURL:   stringPtr("https://github.com/org/repo/pull/1"),
+		NodeID:    stringPtr("PR_node123"),
+		User:      &github.User{Login: stringPtr("author")},
+		Merged:    boolPtr(true),
+		MergedAt:  timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+		CreatedAt: timePtr(time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+	}
+
+	releases := []*github.RepositoryRelease{
+		{
+			Name:        stringPtr("v1.0.0"),
+			TagName:     stringPtr("v1.0.0"),
+			PublishedAt: timePtr(time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
 			CreatedAt:   timePtr(time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC)),
 		},
 	}
 
-	// Mock the functions that would normally be called
-	releaseName, releaseCreatedAt := findReleaseForMergedPR(pr, releases)
-	
-	// Verify the function returns expected values
-	if releaseName == nil || *releaseName != "v1.0.0" {
-		t.Errorf("Expected release name v1.0.0, got %v", releaseName)
+	// Mock the functions that would normally be called
+	releaseName, releaseCreatedAt, _ := findReleaseForMergedPR(pr, releases, false)
+
+	// Verify the function returns expected values
+	if releaseName == nil || *releaseName != "v1.0.0" {
+		t.Errorf("Expected release name v1.0.0, got %v", releaseName)
+	}
+	if releaseCreatedAt == nil || *releaseCreatedAt != "2023-01-16T09:00:00Z" {
+		t.Errorf("Expected release created at 2023-01-16T09:00:00Z, got %v", releaseCreatedAt)
+	}
+
+	// Create a timestamps object similar to how getPRDetails does
+	timestamps := &Timestamps{
+		CreatedAt: stringPtr("2023-01-15T10:00:00Z"),
+		MergedAt:  stringPtr("2023-01-15T12:00:00Z"),
+	}
+
+	prTimestamps := &PRTimestamps{
+		FirstCommit:        timestamps.FirstCommit,
+		CreatedAt:          timestamps.CreatedAt,
+		FirstReviewRequest: timestamps.FirstReviewRequest,
+		FirstComment:       timestamps.FirstComment,
+		FirstApproval:      timestamps.FirstApproval,
+		SecondApproval:     timestamps.SecondApproval,
+		MergedAt:           timestamps.MergedAt,
+		ClosedAt:           timestamps.ClosedAt,
+	}
+
+	// Add release creation timestamp if it exists (like getPRDetails does)
+	if releaseCreatedAt != nil && *releaseCreatedAt != "" {
+		prTimestamps.ReleaseCreatedAt = releaseCreatedAt
+	}
+
+	// Verify release_created_at is in timestamps object
+	if prTimestamps.ReleaseCreatedAt == nil {
+		t.Error("Expected ReleaseCreatedAt to be set in timestamps object")
+	} else if *prTimestamps.ReleaseCreatedAt != "2023-01-16T09:00:00Z" {
+		t.Errorf("Expected ReleaseCreatedAt to be 2023-01-16T09:00:00Z, got %v", *prTimestamps.ReleaseCreatedAt)
+	}
+}
+
+func TestAnalyzeFromPR(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/42", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("AnalyzeFromPR() should not re-fetch the PR")
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/42/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"user":{"login":"reviewer1"},"state":"APPROVED","submitted_at":"2023-01-15T14:00:00Z"}]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	pr := &github.PullRequest{
+		Number:    intPtr(42),
+		Title:     stringPtr("PROJ-7 Prebuilt PR"),
+		HTMLURL:   stringPtr("https://github.com/org/repo/pull/42"),
+		NodeID:    stringPtr("PR_node42"),
+		State:     stringPtr("open"),
+		User:      &github.User{Login: stringPtr("author")},
+		CreatedAt: timePtr(time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+	}
+
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+
+	if details.PRNumber != 42 {
+		t.Errorf("AnalyzeFromPR().PRNumber = %v, want 42", details.PRNumber)
+	}
+	if details.PRTitle != "PROJ-7 Prebuilt PR" {
+		t.Errorf("AnalyzeFromPR().PRTitle = %v, want %v", details.PRTitle, "PROJ-7 Prebuilt PR")
+	}
+	if details.JiraIssue != "PROJ-7" {
+		t.Errorf("AnalyzeFromPR().JiraIssue = %v, want PROJ-7", details.JiraIssue)
+	}
+	if details.NumApprovers != 1 {
+		t.Errorf("AnalyzeFromPR().NumApprovers = %v, want 1", details.NumApprovers)
+	}
+	if details.State != "open" {
+		t.Errorf("AnalyzeFromPR().State = %v, want open", details.State)
+	}
+}
+
+func TestAnalyzeFromPR_NilPR(t *testing.T) {
+	analyzer := newTestAnalyzer(t, http.NewServeMux())
+
+	if _, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", nil); err == nil {
+		t.Error("AnalyzeFromPR() with nil PR expected error, got nil")
+	}
+}
+
+func TestFormatInLocation(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		timestamp string
+		loc       *time.Location
+		expected  string
+	}{
+		{
+			name:      "nil location falls back to UTC",
+			timestamp: "2023-01-15T10:00:00-05:00",
+			loc:       nil,
+			expected:  "2023-01-15T15:00:00Z",
+		},
+		{
+			name:      "standard time offset",
+			timestamp: "2023-01-15T15:00:00Z",
+			loc:       newYork,
+			expected:  "2023-01-15T10:00:00-05:00",
+		},
+		{
+			name:      "just before DST starts",
+			timestamp: "2023-03-12T06:59:00Z",
+			loc:       newYork,
+			expected:  "2023-03-12T01:59:00-05:00",
+		},
+		{
+			name:      "just after DST starts",
+			timestamp: "2023-03-12T07:00:00Z",
+			loc:       newYork,
+			expected:  "2023-03-12T03:00:00-04:00",
+		},
+		{
+			name:      "invalid timestamp returned unchanged",
+			timestamp: "not-a-time",
+			loc:       newYork,
+			expected:  "not-a-time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatInLocation(tt.timestamp, tt.loc)
+			if result != tt.expected {
+				t.Errorf("formatInLocation() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsWeekend(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		utc      string
+		loc      *time.Location
+		expected bool
+	}{
+		{"Friday evening local is Saturday in UTC", "2024-01-06T07:00:00Z", losAngeles, false},
+		{"Saturday local", "2024-01-06T18:00:00Z", losAngeles, true},
+		{"Sunday evening local is Monday in UTC", "2024-01-08T06:00:00Z", losAngeles, true},
+		{"Monday local", "2024-01-08T16:00:00Z", losAngeles, false},
+		{"Saturday in UTC with nil location", "2024-01-06T07:00:00Z", nil, true},
+		{"Monday in UTC with nil location", "2024-01-08T06:00:00Z", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instant, err := time.Parse(time.RFC3339, tt.utc)
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", tt.utc, err)
+			}
+			if result := isWeekend(instant, tt.loc); result != tt.expected {
+				t.Errorf("isWeekend(%s) = %v, want %v", tt.utc, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLocalizeTimestamps_DurationsUnaffected(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+	analyzer := &Analyzer{location: newYork}
+
+	// Spans the DST change, so wall-clock difference (3h) differs from elapsed time (2h)
+	timestamps := &Timestamps{
+		CreatedAt:          stringPtr("2023-03-12T06:00:00Z"),
+		FirstReviewRequest: stringPtr("2023-03-12T08:00:00Z"),
+	}
+	metrics := calculatePRMetrics(&github.PullRequest{}, nil, nil, nil, timestamps, time.Now())
+
+	localized := analyzer.localizeTimestamps(&PRTimestamps{
+		CreatedAt:          timestamps.CreatedAt,
+		FirstReviewRequest: timestamps.FirstReviewRequest,
+	})
+
+	if *localized.CreatedAt != "2023-03-12T01:00:00-05:00" {
+		t.Errorf("localizeTimestamps().CreatedAt = %v, want 2023-03-12T01:00:00-05:00", *localized.CreatedAt)
+	}
+	if *localized.FirstReviewRequest != "2023-03-12T04:00:00-04:00" {
+		t.Errorf("localizeTimestamps().FirstReviewRequest = %v, want 2023-03-12T04:00:00-04:00", *localized.FirstReviewRequest)
+	}
+	if metrics.DraftTimeHours != 2.0 {
+		t.Errorf("calculatePRMetrics().DraftTimeHours = %v, want 2.0", metrics.DraftTimeHours)
+	}
+}
+
+func TestNewAnalyzer_OutputTimezone(t *testing.T) {
+	if _, err := NewAnalyzer(Config{GitHubToken: "token", OutputTimezone: "Europe/Berlin"}); err != nil {
+		t.Errorf("NewAnalyzer() with valid timezone error = %v", err)
+	}
+	if _, err := NewAnalyzer(Config{GitHubToken: "token", OutputTimezone: "Mars/Olympus_Mons"}); err == nil {
+		t.Error("NewAnalyzer() with invalid timezone expected error, got nil")
+	}
+}
+
+func TestNewAnalyzer_ReviewStartAnchor(t *testing.T) {
+	for _, anchor := range []ReviewStartAnchor{"", ReviewStartFirstReviewRequest, ReviewStartCreation, ReviewStartReadyForReview} {
+		if _, err := NewAnalyzer(Config{GitHubToken: "token", ReviewStartAnchor: anchor}); err != nil {
+			t.Errorf("NewAnalyzer() with review start anchor %q error = %v", anchor, err)
+		}
+	}
+	_, err := NewAnalyzer(Config{GitHubToken: "token", ReviewStartAnchor: "ready_for_reveiw"})
+	if err == nil || !strings.Contains(err.Error(), "ready_for_reveiw") {
+		t.Errorf("NewAnalyzer() with unknown review start anchor error = %v, want error naming it", err)
+	}
+}
+
+func TestNewAnalyzer_GitHubTokens(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          Config
+		expectedClients int
+		expectErr       bool
+	}{
+		{name: "single token", config: Config{GitHubToken: "a"}, expectedClients: 1},
+		{name: "token and additional tokens", config: Config{GitHubToken: "a", GitHubTokens: []string{"b", "c"}}, expectedClients: 3},
+		{name: "additional tokens only", config: Config{GitHubTokens: []string{"b", "c"}}, expectedClients: 2},
+		{name: "empty and repeated tokens skipped", config: Config{GitHubToken: "a", GitHubTokens: []string{"", "a", "b", "b"}}, expectedClients: 2},
+		{name: "no tokens", config: Config{GitHubTokens: []string{""}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer, err := NewAnalyzer(tt.config)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("NewAnalyzer() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewAnalyzer() error = %v", err)
+			}
+			if len(analyzer.clients) != tt.expectedClients {
+				t.Errorf("NewAnalyzer() built %d clients, want %d", len(analyzer.clients), tt.expectedClients)
+			}
+		})
+	}
+}
+
+func TestAnalyzePRs_DistributesCallsAcrossTokens(t *testing.T) {
+	var clients []*github.Client
+	requests := make([]int32, 3)
+	for i := range requests {
+		i := i
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/org/repo/", func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests[i], 1)
+			if strings.HasPrefix(r.URL.Path, "/repos/org/repo/pulls/") && strings.Count(r.URL.Path, "/") == 5 {
+				fmt.Fprint(w, `{"number":1,"user":{"login":"author"}}`)
+				return
+			}
+			fmt.Fprint(w, "[]")
+		})
+		clients = append(clients, newTestAnalyzer(t, mux).client)
+	}
+	analyzer := &Analyzer{client: clients[0], clients: clients}
+
+	// Each analysis of an open PR makes 7 calls, so 21 in total whatever the interleaving
+	if _, err := analyzer.AnalyzePRs(context.Background(), "org", "repo", []int{1, 2, 3}, 3); err != nil {
+		t.Fatalf("AnalyzePRs() error = %v", err)
+	}
+	for i := range requests {
+		if got := atomic.LoadInt32(&requests[i]); got != 7 {
+			t.Errorf("token %d served %d calls, want 7", i, got)
+		}
+	}
+}
+
+func TestApplyBranchProtection(t *testing.T) {
+	tests := []struct {
+		name                  string
+		protection            *github.Protection
+		numApprovers          int
+		expectedRequired      int
+		expectedCodeowner     bool
+		expectedLinearHistory bool
+		expectedMet           bool
+	}{
+		{
+			name: "protection with requirements met",
+			protection: &github.Protection{
+				RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+					RequiredApprovingReviewCount: 2,
+					RequireCodeOwnerReviews:      true,
+				},
+				RequireLinearHistory: &github.RequireLinearHistory{Enabled: true},
+			},
+			numApprovers:          2,
+			expectedRequired:      2,
+			expectedCodeowner:     true,
+			expectedLinearHistory: true,
+			expectedMet:           true,
+		},
+		{
+			name: "protection with too few approvals",
+			protection: &github.Protection{
+				RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+					RequiredApprovingReviewCount: 2,
+				},
+			},
+			numApprovers:     1,
+			expectedRequired: 2,
+			expectedMet:      false,
+		},
+		{
+			name:             "unprotected branch",
+			protection:       nil,
+			numApprovers:     0,
+			expectedRequired: 0,
+			expectedMet:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := &PRDetails{NumApprovers: tt.numApprovers}
+			applyBranchProtection(details, tt.protection)
+
+			if *details.RequiredReviewsFromConfig != tt.expectedRequired {
+				t.Errorf("RequiredReviewsFromConfig = %v, want %v", *details.RequiredReviewsFromConfig, tt.expectedRequired)
+			}
+			if *details.RequiresCodeownerReview != tt.expectedCodeowner {
+				t.Errorf("RequiresCodeownerReview = %v, want %v", *details.RequiresCodeownerReview, tt.expectedCodeowner)
+			}
+			if *details.RequiresLinearHistory != tt.expectedLinearHistory {
+				t.Errorf("RequiresLinearHistory = %v, want %v", *details.RequiresLinearHistory, tt.expectedLinearHistory)
+			}
+			if *details.MetBranchProtection != tt.expectedMet {
+				t.Errorf("MetBranchProtection = %v, want %v", *details.MetBranchProtection, tt.expectedMet)
+			}
+		})
+	}
+}
+
+func TestFetchBranchProtection(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/branches/main/protection", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"required_pull_request_reviews":{"required_approving_review_count":1,"require_code_owner_reviews":true},"required_linear_history":{"enabled":true}}`)
+	})
+	mux.HandleFunc("/repos/org/repo/branches/develop/protection", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Branch not protected"}`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	protection, err := analyzer.fetchBranchProtection(context.Background(), "org", "repo", "main")
+	if err != nil {
+		t.Fatalf("fetchBranchProtection() error = %v", err)
+	}
+	if protection.GetRequiredPullRequestReviews().RequiredApprovingReviewCount != 1 {
+		t.Errorf("fetchBranchProtection() required reviews = %v, want 1", protection.GetRequiredPullRequestReviews().RequiredApprovingReviewCount)
+	}
+
+	protection, err = analyzer.fetchBranchProtection(context.Background(), "org", "repo", "develop")
+	if err != nil {
+		t.Fatalf("fetchBranchProtection() for unprotected branch error = %v", err)
+	}
+	if protection != nil {
+		t.Errorf("fetchBranchProtection() for unprotected branch = %v, want nil", protection)
+	}
+}
+
+func TestCalculateTimeToFirstApproval(t *testing.T) {
+	tests := []struct {
+		name          string
+		timestamps    *Timestamps
+		fromCreation  bool
+		expectedHours *float64
+	}{
+		{
+			name: "no approval",
+			timestamps: &Timestamps{
+				CreatedAt:          stringPtr("2023-01-15T10:00:00Z"),
+				FirstReviewRequest: stringPtr("2023-01-15T11:00:00Z"),
+				FirstComment:       stringPtr("2023-01-15T12:00:00Z"),
+			},
+			expectedHours: nil,
+		},
+		{
+			name: "approval before comment",
+			timestamps: &Timestamps{
+				CreatedAt:          stringPtr("2023-01-15T10:00:00Z"),
+				FirstReviewRequest: stringPtr("2023-01-15T11:00:00Z"),
+				FirstApproval:      stringPtr("2023-01-15T13:00:00Z"),
+				FirstComment:       stringPtr("2023-01-15T15:00:00Z"),
+			},
+			expectedHours: float64Ptr(2.0),
+		},
+		{
+			name: "approval after comment is measured independently",
+			timestamps: &Timestamps{
+				CreatedAt:          stringPtr("2023-01-15T10:00:00Z"),
+				FirstReviewRequest: stringPtr("2023-01-15T11:00:00Z"),
+				FirstComment:       stringPtr("2023-01-15T11:30:00Z"),
+				FirstApproval:      stringPtr("2023-01-15T15:00:00Z"),
+			},
+			expectedHours: float64Ptr(4.0),
+		},
+		{
+			name: "approval only, measured from creation",
+			timestamps: &Timestamps{
+				CreatedAt:     stringPtr("2023-01-15T10:00:00Z"),
+				FirstApproval: stringPtr("2023-01-15T16:00:00Z"),
+			},
+			fromCreation:  true,
+			expectedHours: float64Ptr(6.0),
+		},
+		{
+			name: "approval only, no review request",
+			timestamps: &Timestamps{
+				CreatedAt:     stringPtr("2023-01-15T10:00:00Z"),
+				FirstApproval: stringPtr("2023-01-15T16:00:00Z"),
+			},
+			expectedHours: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateTimeToFirstApproval(tt.timestamps, tt.fromCreation)
+			assertFloat64Ptr(t, "calculateTimeToFirstApproval()", result, tt.expectedHours)
+		})
+	}
+}
+
+func TestAnalyzeFromPR_ReviewStartAnchor(t *testing.T) {
+	tests := []struct {
+		name             string
+		anchor           ReviewStartAnchor
+		expectedReview   float64
+		expectedCycle    float64
+		expectedApproval float64
+	}{
+		{name: "default is first review request", anchor: "", expectedReview: 3, expectedCycle: 7, expectedApproval: 3},
+		{name: "first review request", anchor: ReviewStartFirstReviewRequest, expectedReview: 3, expectedCycle: 7, expectedApproval: 3},
+		{name: "creation", anchor: ReviewStartCreation, expectedReview: 6, expectedCycle: 10, expectedApproval: 6},
+		{name: "ready for review", anchor: ReviewStartReadyForReview, expectedReview: 4, expectedCycle: 8, expectedApproval: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"event":"ready_for_review","created_at":"2023-01-15T12:00:00Z"},
+					{"event":"review_requested","created_at":"2023-01-15T13:00:00Z","requested_reviewer":{"login":"reviewer1"}}
+				]`)
+			})
+			mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"user":{"login":"reviewer1"},"state":"APPROVED","submitted_at":"2023-01-15T16:00:00Z"}]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = Config{ReviewStartAnchor: tt.anchor}
+
+			pr := &github.PullRequest{
+				Number:    intPtr(1),
+				Title:     stringPtr("ABC-1 Feature"),
+				State:     stringPtr("closed"),
+				Merged:    boolPtr(true),
+				User:      &github.User{Login: stringPtr("author")},
+				CreatedAt: timePtr(time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+				MergedAt:  timePtr(time.Date(2023, 1, 15, 20, 0, 0, 0, time.UTC)),
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.Metrics == nil {
+				t.Fatal("AnalyzeFromPR().Metrics = nil, want metrics")
+			}
+			assertFloat64Ptr(t, "TimeToFirstReviewHours", details.Metrics.TimeToFirstReviewHours, float64Ptr(tt.expectedReview))
+			assertFloat64Ptr(t, "ReviewCycleTimeHours", details.Metrics.ReviewCycleTimeHours, float64Ptr(tt.expectedCycle))
+			assertFloat64Ptr(t, "TimeToFirstApprovalHours", details.Metrics.TimeToFirstApprovalHours, float64Ptr(tt.expectedApproval))
+			assertFloat64Ptr(t, "TimeToFirstReviewRequestHours", details.Metrics.TimeToFirstReviewRequestHours, float64Ptr(3))
+		})
+	}
+}
+
+func TestAnalyzeFromPR_MaxAnalysisDuration(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			// A slow page that outlasts the analysis deadline
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			fmt.Fprint(w, "[]")
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s?page=2>; rel="next"`, r.URL.Path))
+		fmt.Fprint(w, `[{"event":"review_requested","created_at":"2023-01-15T11:00:00Z","requested_reviewer":{"login":"reviewer1"}}]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = Config{MaxAnalysisDuration: 100 * time.Millisecond}
+
+	pr := &github.PullRequest{
+		Number:    intPtr(1),
+		Title:     stringPtr("ABC-1 Huge change"),
+		State:     stringPtr("open"),
+		User:      &github.User{Login: stringPtr("author")},
+		CreatedAt: timePtr(time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+	}
+
+	start := time.Now()
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v, want best-effort results", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("AnalyzeFromPR() took %v, want it to stop at the deadline", elapsed)
+	}
+	if !details.Truncated {
+		t.Error("AnalyzeFromPR().Truncated = false, want true")
+	}
+	expected := []string{"timeline", "files", "commits"}
+	if !reflect.DeepEqual(details.TruncatedData, expected) {
+		t.Errorf("AnalyzeFromPR().TruncatedData = %v, want %v", details.TruncatedData, expected)
+	}
+	if details.NumRequestedReviewers != 1 {
+		t.Errorf("AnalyzeFromPR().NumRequestedReviewers = %d, want 1 from the first timeline page", details.NumRequestedReviewers)
+	}
+}
+
+func TestAnalyzeFromPR_MaxAnalysisDurationNotReached(t *testing.T) {
+	analyzer := newTestAnalyzer(t, http.NewServeMux())
+	analyzer.config = Config{MaxAnalysisDuration: time.Minute}
+
+	pr := &github.PullRequest{Number: intPtr(1), Title: stringPtr("ABC-1 Fix"), User: &github.User{Login: stringPtr("author")}}
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+	if details.Truncated || details.TruncatedData != nil {
+		t.Errorf("AnalyzeFromPR() truncated = %v %v, want complete data", details.Truncated, details.TruncatedData)
+	}
+}
+
+func TestAnalyzeFromPR_CallerCancellationIsNotTruncation(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = Config{MaxAnalysisDuration: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	pr := &github.PullRequest{Number: intPtr(1), Title: stringPtr("ABC-1 Fix"), User: &github.User{Login: stringPtr("author")}}
+	if _, err := analyzer.AnalyzeFromPR(ctx, "org", "repo", pr); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AnalyzeFromPR() error = %v, want the caller's context error", err)
+	}
+}
+
+func TestReadyForReviewTime(t *testing.T) {
+	created := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	ready := created.Add(2 * time.Hour)
+
+	tests := []struct {
+		name       string
+		pr         *github.PullRequest
+		timeline   []*github.Timeline
+		expected   time.Time
+		expectedOK bool
+	}{
+		{
+			name:       "opened ready",
+			pr:         &github.PullRequest{CreatedAt: timePtr(created)},
+			expected:   created,
+			expectedOK: true,
+		},
+		{
+			name:       "opened as draft",
+			pr:         &github.PullRequest{CreatedAt: timePtr(created)},
+			timeline:   []*github.Timeline{{Event: stringPtr("ready_for_review"), CreatedAt: timePtr(ready)}},
+			expected:   ready,
+			expectedOK: true,
+		},
+		{
+			name:       "converted to draft later",
+			pr:         &github.PullRequest{CreatedAt: timePtr(created)},
+			timeline:   []*github.Timeline{{Event: stringPtr("convert_to_draft"), CreatedAt: timePtr(ready)}},
+			expected:   created,
+			expectedOK: true,
+		},
+		{
+			name:       "draft never marked ready",
+			pr:         &github.PullRequest{CreatedAt: timePtr(created), Draft: boolPtr(true)},
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := readyForReviewTime(tt.pr, tt.timeline)
+			if ok != tt.expectedOK || !result.Equal(tt.expected) {
+				t.Errorf("readyForReviewTime() = %v, %v, want %v, %v", result, ok, tt.expected, tt.expectedOK)
+			}
+		})
+	}
+}
+
+func TestCalculateAvgTimeToAddressComment(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	comment := func(user string, offset time.Duration) *github.PullRequestComment {
+		return &github.PullRequestComment{User: &github.User{Login: stringPtr(user)}, CreatedAt: timePtr(base.Add(offset))}
+	}
+	commit := func(user string, offset time.Duration) *github.RepositoryCommit {
+		return &github.RepositoryCommit{
+			Author: &github.User{Login: stringPtr(user)},
+			Commit: &github.Commit{Author: &github.CommitAuthor{Date: timePtr(base.Add(offset))}},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		reviewComments []*github.PullRequestComment
+		commits        []*github.RepositoryCommit
+		expected       *float64
+	}{
+		{
+			name:     "no review comments",
+			commits:  []*github.RepositoryCommit{commit("author", time.Hour)},
+			expected: nil,
+		},
+		{
+			name:           "no commit after comments",
+			reviewComments: []*github.PullRequestComment{comment("reviewer1", 2*time.Hour)},
+			commits:        []*github.RepositoryCommit{commit("author", time.Hour)},
+			expected:       nil,
+		},
+		{
+			name: "comments averaged against next author commit",
+			reviewComments: []*github.PullRequestComment{
+				comment("reviewer1", 1*time.Hour),
+				comment("reviewer2", 3*time.Hour),
+			},
+			commits: []*github.RepositoryCommit{
+				commit("author", 0),
+				commit("author", 5*time.Hour),
+			},
+			expected: float64Ptr(3.0), // (4 + 2) / 2
+		},
+		{
+			name: "comment with no following commit is excluded",
+			reviewComments: []*github.PullRequestComment{
+				comment("reviewer1", 1*time.Hour),
+				comment("reviewer1", 6*time.Hour),
+			},
+			commits:  []*github.RepositoryCommit{commit("author", 3*time.Hour)},
+			expected: float64Ptr(2.0),
+		},
+		{
+			name: "author replies and other committers are ignored",
+			reviewComments: []*github.PullRequestComment{
+				comment("author", 1*time.Hour),
+				comment("reviewer1", 2*time.Hour),
+			},
+			commits: []*github.RepositoryCommit{
+				commit("reviewer1", 3*time.Hour),
+				commit("author", 6*time.Hour),
+			},
+			expected: float64Ptr(4.0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateAvgTimeToAddressComment(tt.reviewComments, tt.commits, "author")
+			assertFloat64Ptr(t, "calculateAvgTimeToAddressComment()", result, tt.expected)
+		})
+	}
+}
+
+func TestCountCommentsWithoutFollowupCommit(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	commit := func(login string, offset time.Duration) *github.RepositoryCommit {
+		return &github.RepositoryCommit{
+			Author: &github.User{Login: stringPtr(login)},
+			Commit: &github.Commit{Author: &github.CommitAuthor{Date: timePtr(base.Add(offset))}},
+		}
+	}
+	comment := func(login string, offset time.Duration) *github.PullRequestComment {
+		return &github.PullRequestComment{User: &github.User{Login: stringPtr(login)}, CreatedAt: timePtr(base.Add(offset))}
+	}
+
+	tests := []struct {
+		name           string
+		pr             *github.PullRequest
+		reviewComments []*github.PullRequestComment
+		commits        []*github.RepositoryCommit
+		expected       int
+	}{
+		{
+			name:           "comments addressed by a later commit",
+			pr:             &github.PullRequest{},
+			reviewComments: []*github.PullRequestComment{comment("reviewer1", 0), comment("reviewer2", time.Hour)},
+			commits:        []*github.RepositoryCommit{commit("author", 2*time.Hour)},
+			expected:       0,
+		},
+		{
+			name:           "comments addressed by discussion",
+			pr:             &github.PullRequest{},
+			reviewComments: []*github.PullRequestComment{comment("reviewer1", 3*time.Hour), comment("author", 4*time.Hour)},
+			commits:        []*github.RepositoryCommit{commit("author", time.Hour)},
+			expected:       1,
+		},
+		{
+			name:           "commits by others don't count as follow-up",
+			pr:             &github.PullRequest{},
+			reviewComments: []*github.PullRequestComment{comment("reviewer1", 0)},
+			commits:        []*github.RepositoryCommit{commit("reviewer1", time.Hour)},
+			expected:       1,
+		},
+		{
+			name:           "commits after merge don't count",
+			pr:             &github.PullRequest{MergedAt: timePtr(base.Add(2 * time.Hour))},
+			reviewComments: []*github.PullRequestComment{comment("reviewer1", time.Hour)},
+			commits:        []*github.RepositoryCommit{commit("author", 3*time.Hour)},
+			expected:       1,
+		},
+		{
+			name:     "no review comments",
+			pr:       &github.PullRequest{},
+			commits:  []*github.RepositoryCommit{commit("author", time.Hour)},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countCommentsWithoutFollowupCommit(tt.pr, tt.reviewComments, tt.commits, "author"); got != tt.expected {
+				t.Errorf("countCommentsWithoutFollowupCommit() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCalculateReviewCommentDensity(t *testing.T) {
+	comments := func(n int) []*github.PullRequestComment {
+		result := make([]*github.PullRequestComment, n)
+		for i := range result {
+			result[i] = &github.PullRequestComment{}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name           string
+		reviewComments []*github.PullRequestComment
+		linesChanged   int
+		expected       *float64
+	}{
+		{"dense review", comments(10), 20, float64Ptr(0.5)},
+		{"sparse review", comments(2), 1000, float64Ptr(0.002)},
+		{"no review comments", nil, 100, float64Ptr(0.0)},
+		{"no lines changed", comments(3), 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateReviewCommentDensity(tt.reviewComments, tt.linesChanged)
+			assertFloat64Ptr(t, "calculateReviewCommentDensity()", result, tt.expected)
+		})
+	}
+}
+
+func TestUnionDuration(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	span := func(start, end int) timeInterval {
+		return timeInterval{start: base.Add(time.Duration(start) * time.Hour), end: base.Add(time.Duration(end) * time.Hour)}
+	}
+
+	tests := []struct {
+		name      string
+		intervals []timeInterval
+		expected  time.Duration
+	}{
+		{"no intervals", nil, 0},
+		{"disjoint", []timeInterval{span(0, 2), span(5, 6)}, 3 * time.Hour},
+		{"overlapping", []timeInterval{span(0, 3), span(2, 5)}, 5 * time.Hour},
+		{"contained", []timeInterval{span(0, 10), span(2, 4)}, 10 * time.Hour},
+		{"touching and unsorted", []timeInterval{span(4, 6), span(0, 2), span(2, 4)}, 6 * time.Hour},
+		{"inverted interval ignored", []timeInterval{span(3, 1), span(0, 1)}, 1 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := unionDuration(tt.intervals); result != tt.expected {
+				t.Errorf("unionDuration() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCalculateActiveReviewHours(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	review := func(user string, offset time.Duration) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: stringPtr(user)}, SubmittedAt: timePtr(base.Add(offset))}
+	}
+	comment := func(user string, offset time.Duration) *github.IssueComment {
+		return &github.IssueComment{User: &github.User{Login: stringPtr(user)}, CreatedAt: timePtr(base.Add(offset))}
+	}
+	reviewComment := func(user string, offset time.Duration) *github.PullRequestComment {
+		return &github.PullRequestComment{User: &github.User{Login: stringPtr(user)}, CreatedAt: timePtr(base.Add(offset))}
+	}
+
+	tests := []struct {
+		name           string
+		reviews        []*github.PullRequestReview
+		comments       []*github.IssueComment
+		reviewComments []*github.PullRequestComment
+		expected       *float64
+	}{
+		{
+			name:     "no reviewer activity",
+			comments: []*github.IssueComment{comment("author", time.Hour)},
+			expected: nil,
+		},
+		{
+			name:     "single activity",
+			reviews:  []*github.PullRequestReview{review("reviewer1", time.Hour)},
+			expected: float64Ptr(0.0),
+		},
+		{
+			name:           "continuous activity across sources",
+			reviewComments: []*github.PullRequestComment{reviewComment("reviewer1", 0), reviewComment("reviewer1", 1*time.Hour)},
+			comments:       []*github.IssueComment{comment("reviewer2", 3*time.Hour)},
+			reviews:        []*github.PullRequestReview{review("reviewer1", 4*time.Hour)},
+			expected:       float64Ptr(4.0),
+		},
+		{
+			name:     "long author-only gap excluded",
+			reviews:  []*github.PullRequestReview{review("reviewer1", 0), review("reviewer1", 2*time.Hour), review("reviewer1", 50*time.Hour), review("reviewer1", 51*time.Hour)},
+			comments: []*github.IssueComment{comment("author", 20*time.Hour)},
+			expected: float64Ptr(3.0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateActiveReviewHours(tt.reviews, tt.comments, tt.reviewComments, "author")
+			assertFloat64Ptr(t, "calculateActiveReviewHours()", result, tt.expected)
+		})
+	}
+}
+
+func TestCalculateLongestIdle(t *testing.T) {
+	created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(hours float64) *github.Timestamp {
+		return timePtr(created.Add(time.Duration(hours * float64(time.Hour))))
+	}
+	reviews := []*github.PullRequestReview{{SubmittedAt: at(30)}}
+	comments := []*github.IssueComment{{CreatedAt: at(2)}}
+	reviewComments := []*github.PullRequestComment{{CreatedAt: at(3)}}
+	commits := []*github.RepositoryCommit{{Commit: &github.Commit{Author: &github.CommitAuthor{Date: at(1)}}}}
+
+	event := func(name string, hours float64) *github.Timeline {
+		return &github.Timeline{Event: stringPtr(name), CreatedAt: at(hours)}
+	}
+	defaultIgnored := (&Analyzer{}).ignoredTimelineEvents()
+
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		timeline []*github.Timeline
+		ignored  map[string]bool
+		now      time.Time
+		expected *float64
+	}{
+		{
+			name:     "open PR idle until now",
+			pr:       &github.PullRequest{CreatedAt: at(0)},
+			now:      created.Add(100 * time.Hour),
+			expected: float64Ptr(70),
+		},
+		{
+			name:     "merged PR ends at merge",
+			pr:       &github.PullRequest{CreatedAt: at(0), MergedAt: at(31)},
+			now:      created.Add(100 * time.Hour),
+			expected: float64Ptr(27),
+		},
+		{
+			name:     "ignored events don't split idle gaps",
+			pr:       &github.PullRequest{CreatedAt: at(0), MergedAt: at(31)},
+			timeline: []*github.Timeline{event("labeled", 15), event("renamed", 16), event("subscribed", 17), event("mentioned", 18), event("unlabeled", 19)},
+			ignored:  defaultIgnored,
+			now:      created.Add(100 * time.Hour),
+			expected: float64Ptr(27),
+		},
+		{
+			name:     "other timeline events split idle gaps",
+			pr:       &github.PullRequest{CreatedAt: at(0), MergedAt: at(31)},
+			timeline: []*github.Timeline{event("ready_for_review", 15)},
+			ignored:  defaultIgnored,
+			now:      created.Add(100 * time.Hour),
+			expected: float64Ptr(15),
+		},
+		{
+			name:     "events outside the ignored set count",
+			pr:       &github.PullRequest{CreatedAt: at(0), MergedAt: at(31)},
+			timeline: []*github.Timeline{event("labeled", 15)},
+			ignored:  map[string]bool{"renamed": true},
+			now:      created.Add(100 * time.Hour),
+			expected: float64Ptr(15),
+		},
+		{
+			name:     "unknown creation time",
+			pr:       &github.PullRequest{},
+			now:      created,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateLongestIdle(tt.pr, reviews, comments, reviewComments, commits, tt.timeline, tt.ignored, tt.now)
+			assertFloat64Ptr(t, "calculateLongestIdle()", result, tt.expected)
+		})
+	}
+}
+
+func TestCalculateHoursSinceLastUpdate(t *testing.T) {
+	now := time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC)
+	updated := timePtr(now.Add(-30 * time.Hour))
+
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		expected *float64
+	}{
+		{
+			name:     "open PR",
+			pr:       &github.PullRequest{State: stringPtr("open"), UpdatedAt: updated},
+			expected: float64Ptr(30),
+		},
+		{
+			name:     "draft PR",
+			pr:       &github.PullRequest{State: stringPtr("open"), Draft: boolPtr(true), UpdatedAt: updated},
+			expected: float64Ptr(30),
+		},
+		{
+			name:     "updated after the clock",
+			pr:       &github.PullRequest{State: stringPtr("open"), UpdatedAt: timePtr(now.Add(time.Minute))},
+			expected: float64Ptr(0),
+		},
+		{
+			name:     "merged PR",
+			pr:       &github.PullRequest{State: stringPtr("closed"), Merged: boolPtr(true), MergedAt: updated, UpdatedAt: updated},
+			expected: nil,
+		},
+		{
+			name:     "closed PR",
+			pr:       &github.PullRequest{State: stringPtr("closed"), UpdatedAt: updated},
+			expected: nil,
+		},
+		{
+			name:     "unknown updated_at",
+			pr:       &github.PullRequest{State: stringPtr("open")},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFloat64Ptr(t, "calculateHoursSinceLastUpdate()", calculateHoursSinceLastUpdate(tt.pr, now), tt.expected)
+		})
+	}
+}
+
+func TestAnalyzeFromPR_HoursSinceLastUpdateUsesClock(t *testing.T) {
+	now := time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC)
+	analyzer := newTestAnalyzer(t, http.NewServeMux())
+	analyzer.now = func() time.Time { return now }
+	pr := &github.PullRequest{
+		Number:    intPtr(1),
+		State:     stringPtr("open"),
+		User:      &github.User{Login: stringPtr("author")},
+		CreatedAt: timePtr(now.Add(-72 * time.Hour)),
+		UpdatedAt: timePtr(now.Add(-7*time.Hour - 30*time.Minute)),
+	}
+
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+	assertFloat64Ptr(t, "HoursSinceLastUpdate", details.Metrics.HoursSinceLastUpdate, float64Ptr(7.5))
+	if details.GeneratedAt != "2023-01-20T12:00:00Z" {
+		t.Errorf("AnalyzeFromPR().GeneratedAt = %v, want the clock's time", details.GeneratedAt)
+	}
+}
+
+func TestIgnoredTimelineEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected []string
+	}{
+		{
+			name:     "defaults to cosmetic events",
+			config:   Config{},
+			expected: DefaultIgnoredTimelineEvents,
+		},
+		{
+			name:     "configured events replace the defaults",
+			config:   Config{IgnoredTimelineEvents: []string{"renamed", "pinned"}},
+			expected: []string{"renamed", "pinned"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &Analyzer{config: tt.config}
+			ignored := analyzer.ignoredTimelineEvents()
+			if len(ignored) != len(tt.expected) {
+				t.Errorf("ignoredTimelineEvents() = %v, want %v", ignored, tt.expected)
+			}
+			for _, event := range tt.expected {
+				if !ignored[event] {
+					t.Errorf("ignoredTimelineEvents() missing %q", event)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_ReleaseLookup(t *testing.T) {
+	mergedPR := func() *github.PullRequest {
+		return &github.PullRequest{
+			Number:   intPtr(1),
+			User:     &github.User{Login: stringPtr("author")},
+			Merged:   boolPtr(true),
+			MergedAt: timePtr(time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)),
+		}
+	}
+
+	tests := []struct {
+		name                string
+		config              Config
+		pr                  *github.PullRequest
+		expectedSearched    bool
+		expectedFetchCalled bool
+	}{
+		{
+			name:                "merged PR searches releases",
+			pr:                  mergedPR(),
+			expectedSearched:    true,
+			expectedFetchCalled: true,
+		},
+		{
+			name:                "merged PR with release lookup skipped",
+			config:              Config{SkipReleaseLookup: true},
+			pr:                  mergedPR(),
+			expectedSearched:    false,
+			expectedFetchCalled: false,
+		},
+		{
+			name: "open PR never searches releases",
+			pr: &github.PullRequest{
+				Number: intPtr(1),
+				User:   &github.User{Login: stringPtr("author")},
+				Merged: boolPtr(false),
+			},
+			expectedSearched:    false,
+			expectedFetchCalled: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetchCalled := false
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+				fetchCalled = true
+				fmt.Fprint(w, "[]")
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", tt.pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.ReleaseSearched != tt.expectedSearched {
+				t.Errorf("AnalyzeFromPR().ReleaseSearched = %v, want %v", details.ReleaseSearched, tt.expectedSearched)
+			}
+			if fetchCalled != tt.expectedFetchCalled {
+				t.Errorf("release fetch called = %v, want %v", fetchCalled, tt.expectedFetchCalled)
+			}
+			if details.ReleaseName != nil {
+				t.Errorf("AnalyzeFromPR().ReleaseName = %v, want nil", *details.ReleaseName)
+			}
+		})
+	}
+}
+
+func TestAnalyzePRWithReleases(t *testing.T) {
+	fetchCalled := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":1,"user":{"login":"author"},"merged":true,"merged_at":"2023-01-15T12:00:00Z"}`)
+	})
+	mux.HandleFunc("/repos/org/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		fetchCalled = true
+		fmt.Fprint(w, "[]")
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	releases := []*github.RepositoryRelease{
+		{Name: stringPtr("v1.0.0"), PublishedAt: timePtr(time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC))},
+		{Name: stringPtr("v1.1.0"), PublishedAt: timePtr(time.Date(2023, 1, 20, 0, 0, 0, 0, time.UTC))},
+	}
+
+	details, err := analyzer.AnalyzePRWithReleases(context.Background(), "org", "repo", 1, releases)
+	if err != nil {
+		t.Fatalf("AnalyzePRWithReleases() error = %v", err)
+	}
+	if fetchCalled {
+		t.Error("AnalyzePRWithReleases() fetched releases, want the supplied list used")
+	}
+	if !details.ReleaseSearched {
+		t.Error("AnalyzePRWithReleases().ReleaseSearched = false, want true")
+	}
+	if details.ReleaseName == nil || *details.ReleaseName != "v1.1.0" {
+		t.Errorf("AnalyzePRWithReleases().ReleaseName = %v, want v1.1.0", details.ReleaseName)
+	}
+}
+
+func TestAnalyzePRDelta(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":1,"title":"ABC-1 Add feature","user":{"login":"author"},"state":"open"}`)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"user":{"login":"reviewer1"},"state":"APPROVED","submitted_at":"2024-01-01T12:00:00Z"}]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	ctx := context.Background()
+
+	// Baseline captured before the approval arrived
+	baseline, err := analyzer.AnalyzePR(ctx, "org", "repo", 1)
+	if err != nil {
+		t.Fatalf("AnalyzePR() error = %v", err)
+	}
+	baseline.NumApprovers = 0
+	baseline.ApproverUsernames = []string{}
+	baseline.NumRequestedReviewers = 0
+
+	changes, err := analyzer.AnalyzePRDelta(ctx, "org", "repo", 1, baseline)
+	if err != nil {
+		t.Fatalf("AnalyzePRDelta() error = %v", err)
+	}
+
+	changed := make(map[string]FieldChange)
+	for _, change := range changes {
+		changed[change.Field] = change
+	}
+	if len(changed) != 3 {
+		t.Errorf("AnalyzePRDelta() returned %d changes, want 3: %+v", len(changes), changes)
+	}
+	if change, ok := changed["num_approvers"]; !ok || change.Old != 0 || change.New != 1 {
+		t.Errorf("AnalyzePRDelta() num_approvers change = %+v, want 0 -> 1", change)
+	}
+	if _, ok := changed["approver_usernames"]; !ok {
+		t.Error("AnalyzePRDelta() missing approver_usernames change")
+	}
+	if _, ok := changed["generated_at"]; ok {
+		t.Error("AnalyzePRDelta() reported generated_at, want it ignored")
+	}
+}
+
+func TestVerifyAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		expectedErr error
+	}{
+		{name: "access granted", status: http.StatusOK, expectedErr: nil},
+		{name: "invalid token", status: http.StatusUnauthorized, expectedErr: ErrInvalidToken},
+		{name: "no access", status: http.StatusForbidden, expectedErr: ErrNoAccess},
+		{name: "repo not found", status: http.StatusNotFound, expectedErr: ErrRepoNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				if tt.status == http.StatusOK {
+					fmt.Fprint(w, `{"name":"repo"}`)
+					return
+				}
+				fmt.Fprint(w, `{"message":"error"}`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+
+			err := analyzer.VerifyAccess(context.Background(), "org", "repo")
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Errorf("VerifyAccess() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("VerifyAccess() error = %v, want %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"resources":{
+			"core":{"limit":5000,"remaining":4200,"reset":1700000000},
+			"search":{"limit":30,"remaining":28,"reset":1700000000},
+			"graphql":{"limit":5000,"remaining":4999,"reset":1700000000}
+		}}`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	limits, err := analyzer.RateLimit(context.Background())
+	if err != nil {
+		t.Fatalf("RateLimit() error = %v", err)
+	}
+	if limits.GetCore().Remaining != 4200 || limits.GetCore().Limit != 5000 {
+		t.Errorf("RateLimit().Core = %+v, want 4200/5000", limits.GetCore())
+	}
+	if limits.GetSearch().Remaining != 28 {
+		t.Errorf("RateLimit().Search.Remaining = %d, want 28", limits.GetSearch().Remaining)
+	}
+	if limits.GetGraphQL().Remaining != 4999 {
+		t.Errorf("RateLimit().GraphQL.Remaining = %d, want 4999", limits.GetGraphQL().Remaining)
+	}
+}
+
+func TestRateLimit_Error(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"Bad credentials"}`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	if _, err := analyzer.RateLimit(context.Background()); err == nil {
+		t.Error("RateLimit() error = nil, want error")
+	}
+}
+
+func TestClassifyCommitTypes(t *testing.T) {
+	commit := func(message string) *github.RepositoryCommit {
+		return &github.RepositoryCommit{Commit: &github.Commit{Message: stringPtr(message)}}
+	}
+
+	tests := []struct {
+		name            string
+		commits         []*github.RepositoryCommit
+		expectedCounts  map[string]int
+		expectedPrimary string
+	}{
+		{
+			name: "scoped, breaking and plain prefixes",
+			commits: []*github.RepositoryCommit{
+				commit("feat(api): add endpoint"),
+				commit("fix!: drop legacy flag\n\nBREAKING CHANGE: flag removed"),
+				commit("feat: second feature"),
+				commit("chore: bump deps"),
+			},
+			expectedCounts:  map[string]int{"feat": 2, "fix": 1, "chore": 1},
+			expectedPrimary: "feat",
+		},
+		{
+			name: "non-conventional messages are ignored",
+			commits: []*github.RepositoryCommit{
+				commit("Update README"),
+				commit("Merge branch 'main' into feature"),
+				commit("WIP: not a known type"),
+				commit("fix: real fix"),
+			},
+			expectedCounts:  map[string]int{"fix": 1},
+			expectedPrimary: "fix",
+		},
+		{
+			name: "ties resolved by type priority",
+			commits: []*github.RepositoryCommit{
+				commit("docs: update guide"),
+				commit("fix: correct typo"),
+			},
+			expectedCounts:  map[string]int{"docs": 1, "fix": 1},
+			expectedPrimary: "fix",
+		},
+		{
+			name:            "no commits",
+			commits:         nil,
+			expectedCounts:  map[string]int{},
+			expectedPrimary: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts, primary := classifyCommitTypes(tt.commits)
+			if len(counts) != len(tt.expectedCounts) {
+				t.Errorf("classifyCommitTypes() counts = %v, want %v", counts, tt.expectedCounts)
+			}
+			for commitType, want := range tt.expectedCounts {
+				if counts[commitType] != want {
+					t.Errorf("classifyCommitTypes() counts[%q] = %v, want %v", commitType, counts[commitType], want)
+				}
+			}
+			if primary != tt.expectedPrimary {
+				t.Errorf("classifyCommitTypes() primary = %v, want %v", primary, tt.expectedPrimary)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_RepoArchived(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		response string
+		expected *bool
+	}{
+		{
+			name:     "archived repository",
+			config:   Config{IncludeRepoMetadata: true},
+			response: `{"name":"repo","archived":true}`,
+			expected: boolPtr(true),
+		},
+		{
+			name:     "active repository",
+			config:   Config{IncludeRepoMetadata: true},
+			response: `{"name":"repo","archived":false}`,
+			expected: boolPtr(false),
+		},
+		{
+			name:     "repo metadata not requested",
+			config:   Config{},
+			response: `{"name":"repo","archived":true}`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.response)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{Number: intPtr(1), User: &github.User{Login: stringPtr("author")}}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+
+			if tt.expected == nil {
+				if details.RepoArchived != nil {
+					t.Errorf("AnalyzeFromPR().RepoArchived = %v, want nil", *details.RepoArchived)
+				}
+				return
+			}
+			if details.RepoArchived == nil || *details.RepoArchived != *tt.expected {
+				t.Errorf("AnalyzeFromPR().RepoArchived = %v, want %v", details.RepoArchived, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_OmitGeneratedAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected bool
+	}{
+		{name: "generated_at omitted", config: Config{OmitGeneratedAt: true}, expected: false},
+		{name: "generated_at included by default", config: Config{}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := newTestAnalyzer(t, http.NewServeMux())
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{Number: intPtr(1), User: &github.User{Login: stringPtr("author")}}
+			var outputs [][]byte
+			for run := 0; run < 2; run++ {
+				details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+				if err != nil {
+					t.Fatalf("AnalyzeFromPR() error = %v", err)
+				}
+				output, err := json.Marshal(details)
+				if err != nil {
+					t.Fatalf("json.Marshal() error = %v", err)
+				}
+				outputs = append(outputs, output)
+			}
+
+			if got := strings.Contains(string(outputs[0]), `"generated_at"`); got != tt.expected {
+				t.Errorf("output contains generated_at = %v, want %v: %s", got, tt.expected, outputs[0])
+			}
+			if !tt.expected && string(outputs[0]) != string(outputs[1]) {
+				t.Errorf("output differs between runs:\n%s\n%s", outputs[0], outputs[1])
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_Deployments(t *testing.T) {
+	deployedTimeline := `[{"event":"deployed","created_at":"2023-01-02T10:00:00Z"}]`
+	baseRepo := &github.Repository{FullName: stringPtr("org/repo")}
+	branch := &github.PullRequestBranch{Ref: stringPtr("feature"), SHA: stringPtr("abc123"), Repo: baseRepo}
+
+	tests := []struct {
+		name     string
+		config   Config
+		timeline string
+		head     *github.PullRequestBranch
+		wantRef  string
+		wantSHA  string
+		expected []DeploymentInfo
+	}{
+		{
+			name:     "deployed event maps deployments oldest first",
+			config:   Config{IncludeDeployments: true},
+			timeline: deployedTimeline,
+			head:     branch,
+			wantRef:  "feature",
+			expected: []DeploymentInfo{
+				{Environment: "staging", CreatedAt: "2023-01-02T10:00:00Z", State: "success"},
+				{Environment: "production", CreatedAt: "2023-01-03T10:00:00Z", State: "failure"},
+			},
+		},
+		{
+			name:     "environment changed event maps deployments",
+			config:   Config{IncludeDeployments: true},
+			timeline: `[{"event":"deployment_environment_changed","created_at":"2023-01-03T10:00:00Z"}]`,
+			head:     branch,
+			wantRef:  "feature",
+			expected: []DeploymentInfo{
+				{Environment: "staging", CreatedAt: "2023-01-02T10:00:00Z", State: "success"},
+				{Environment: "production", CreatedAt: "2023-01-03T10:00:00Z", State: "failure"},
+			},
+		},
+		{
+			name:     "fork PR lists deployments by head SHA",
+			config:   Config{IncludeDeployments: true},
+			timeline: deployedTimeline,
+			head: &github.PullRequestBranch{
+				Ref:  stringPtr("feature"),
+				SHA:  stringPtr("abc123"),
+				Repo: &github.Repository{FullName: stringPtr("fork/repo")},
+			},
+			wantSHA: "abc123",
+			expected: []DeploymentInfo{
+				{Environment: "staging", CreatedAt: "2023-01-02T10:00:00Z", State: "success"},
+				{Environment: "production", CreatedAt: "2023-01-03T10:00:00Z", State: "failure"},
+			},
+		},
+		{
+			name:     "no deployment events",
+			config:   Config{IncludeDeployments: true},
+			timeline: `[{"event":"labeled","created_at":"2023-01-02T10:00:00Z"}]`,
+			expected: nil,
+		},
+		{
+			name:     "deployments not requested",
+			config:   Config{},
+			timeline: deployedTimeline,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.timeline)
+			})
+			mux.HandleFunc("/repos/org/repo/deployments", func(w http.ResponseWriter, r *http.Request) {
+				if ref, sha := r.URL.Query().Get("ref"), r.URL.Query().Get("sha"); ref != tt.wantRef || sha != tt.wantSHA {
+					t.Errorf("deployments ref, sha = %q, %q, want %q, %q", ref, sha, tt.wantRef, tt.wantSHA)
+				}
+				fmt.Fprint(w, `[
+					{"id":2,"environment":"production","created_at":"2023-01-03T10:00:00Z"},
+					{"id":1,"environment":"staging","created_at":"2023-01-02T10:00:00Z"}
+				]`)
+			})
+			mux.HandleFunc("/repos/org/repo/deployments/1/statuses", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"state":"success"}]`)
+			})
+			mux.HandleFunc("/repos/org/repo/deployments/2/statuses", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"state":"failure"}]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{
+				Number: intPtr(1),
+				User:   &github.User{Login: stringPtr("author")},
+				Head:   tt.head,
+				Base:   &github.PullRequestBranch{Ref: stringPtr("main"), Repo: baseRepo},
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+
+			if len(details.Deployments) != len(tt.expected) {
+				t.Fatalf("AnalyzeFromPR().Deployments = %v, want %v", details.Deployments, tt.expected)
+			}
+			for i := range tt.expected {
+				if details.Deployments[i] != tt.expected[i] {
+					t.Errorf("AnalyzeFromPR().Deployments[%d] = %v, want %v", i, details.Deployments[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLabelEvents(t *testing.T) {
+	start := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	labelEvent := func(event TimelineEvent, label string, hours int) *github.Timeline {
+		return &github.Timeline{
+			Event:     stringPtr(string(event)),
+			Label:     &github.Label{Name: stringPtr(label)},
+			CreatedAt: timePtr(start.Add(time.Duration(hours) * time.Hour)),
+		}
+	}
+
+	timeline := []*github.Timeline{
+		labelEvent(EventLabeled, "needs-review", 0),
+		reviewRequestEvent(EventReviewRequested, "reviewer1", start.Add(time.Hour)),
+		labelEvent(EventLabeled, "needs-qa", 5),
+		labelEvent(EventUnlabeled, "needs-review", 3),
+		{Event: stringPtr(string(EventLabeled)), Label: &github.Label{Name: stringPtr("undated")}},
+		labelEvent(EventUnlabeled, "needs-qa", 5),
+	}
+
+	tests := []struct {
+		name     string
+		location *time.Location
+		expected []LabelEvent
+	}{
+		{
+			name:     "UTC",
+			location: time.UTC,
+			expected: []LabelEvent{
+				{Label: "needs-review", Action: LabelAdded, CreatedAt: "2023-01-15T10:00:00Z"},
+				{Label: "needs-review", Action: LabelRemoved, CreatedAt: "2023-01-15T13:00:00Z"},
+				{Label: "needs-qa", Action: LabelAdded, CreatedAt: "2023-01-15T15:00:00Z"},
+				{Label: "needs-qa", Action: LabelRemoved, CreatedAt: "2023-01-15T15:00:00Z"},
+			},
+		},
+		{
+			name:     "output timezone",
+			location: time.FixedZone("UTC+1", 3600),
+			expected: []LabelEvent{
+				{Label: "needs-review", Action: LabelAdded, CreatedAt: "2023-01-15T11:00:00+01:00"},
+				{Label: "needs-review", Action: LabelRemoved, CreatedAt: "2023-01-15T14:00:00+01:00"},
+				{Label: "needs-qa", Action: LabelAdded, CreatedAt: "2023-01-15T16:00:00+01:00"},
+				{Label: "needs-qa", Action: LabelRemoved, CreatedAt: "2023-01-15T16:00:00+01:00"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := &Analyzer{location: tt.location}
+			if result := analyzer.labelEvents(timeline); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("labelEvents() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_LabelTimeline(t *testing.T) {
+	pr := &github.PullRequest{Number: intPtr(1), User: &github.User{Login: stringPtr("author")}}
+
+	for _, include := range []bool{false, true} {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"event":"labeled","label":{"name":"needs-review"},"created_at":"2023-01-15T10:00:00Z"}]`)
+		})
+		analyzer := newTestAnalyzer(t, mux)
+		analyzer.config = Config{IncludeLabelTimeline: include}
+
+		details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+		if err != nil {
+			t.Fatalf("AnalyzeFromPR() error = %v", err)
+		}
+		if got := len(details.LabelEvents); (got == 1) != include {
+			t.Errorf("AnalyzeFromPR() with IncludeLabelTimeline = %v has %d label events", include, got)
+		}
+	}
+}
+
+func TestCommitEntries(t *testing.T) {
+	authored := time.Date(2023, 1, 15, 10, 0, 0, 0, time.FixedZone("PST", -8*60*60))
+	commits := []*github.RepositoryCommit{
+		{
+			SHA:    stringPtr("aaa111"),
+			Author: &github.User{Login: stringPtr("alice")},
+			Commit: &github.Commit{
+				Message: stringPtr("feat: add widgets\n\nLonger description"),
+				Author:  &github.CommitAuthor{Date: timePtr(authored)},
+			},
+		},
+		{
+			// Author email not linked to a GitHub account
+			SHA:    stringPtr("bbb222"),
+			Commit: &github.Commit{Message: stringPtr("fix typo")},
+		},
+		{
+			SHA:    stringPtr("ccc333"),
+			Author: &github.User{Login: stringPtr("bob")},
+			Commit: &github.Commit{Message: stringPtr("docs: update README")},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		maxCommits int
+		expected   []CommitEntry
+		truncated  bool
+	}{
+		{
+			name: "all commits",
+			expected: []CommitEntry{
+				{SHA: "aaa111", AuthorLogin: "alice", AuthoredAt: "2023-01-15T18:00:00Z", Subject: "feat: add widgets"},
+				{SHA: "bbb222", Subject: "fix typo"},
+				{SHA: "ccc333", AuthorLogin: "bob", Subject: "docs: update README"},
+			},
+		},
+		{
+			name:       "capped by MaxCommits",
+			maxCommits: 2,
+			expected: []CommitEntry{
+				{SHA: "aaa111", AuthorLogin: "alice", AuthoredAt: "2023-01-15T18:00:00Z", Subject: "feat: add widgets"},
+				{SHA: "bbb222", Subject: "fix typo"},
+			},
+			truncated: true,
+		},
+		{
+			name:       "MaxCommits above the commit count",
+			maxCommits: 3,
+			expected: []CommitEntry{
+				{SHA: "aaa111", AuthorLogin: "alice", AuthoredAt: "2023-01-15T18:00:00Z", Subject: "feat: add widgets"},
+				{SHA: "bbb222", Subject: "fix typo"},
+				{SHA: "ccc333", AuthorLogin: "bob", Subject: "docs: update README"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, truncated := commitEntries(commits, tt.maxCommits)
+			if !reflect.DeepEqual(entries, tt.expected) {
+				t.Errorf("commitEntries() = %+v, want %+v", entries, tt.expected)
+			}
+			if truncated != tt.truncated {
+				t.Errorf("commitEntries() truncated = %v, want %v", truncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_IncludeCommits(t *testing.T) {
+	pr := &github.PullRequest{Number: intPtr(1), User: &github.User{Login: stringPtr("author")}}
+
+	tests := []struct {
+		name      string
+		config    Config
+		expected  []string
+		truncated bool
+	}{
+		{"disabled", Config{}, nil, false},
+		{"all commits", Config{IncludeCommits: true}, []string{"aaa111", "bbb222"}, false},
+		{"capped", Config{IncludeCommits: true, MaxCommits: 1}, []string{"aaa111"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/pulls/1/commits", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"sha":"aaa111","author":{"login":"author"},"commit":{"message":"first","author":{"date":"2023-01-15T10:00:00Z"}}},
+					{"sha":"bbb222","author":{"login":"author"},"commit":{"message":"second","author":{"date":"2023-01-15T11:00:00Z"}}}
+				]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			var shas []string
+			for _, commit := range details.Commits {
+				shas = append(shas, commit.SHA)
+			}
+			if !reflect.DeepEqual(shas, tt.expected) {
+				t.Errorf("AnalyzeFromPR().Commits = %v, want %v", shas, tt.expected)
+			}
+			if details.CommitsTruncated != tt.truncated {
+				t.Errorf("AnalyzeFromPR().CommitsTruncated = %v, want %v", details.CommitsTruncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_CommitDatesStayUTC(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1/commits", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"sha":"aaa111","commit":{"message":"first","author":{"date":"2023-01-15T10:00:00-08:00"}}}]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = Config{IncludeCommits: true, OutputTimezone: "Europe/Berlin"}
+	analyzer.location = time.FixedZone("CET", 60*60)
+
+	pr := &github.PullRequest{
+		Number:    intPtr(1),
+		User:      &github.User{Login: stringPtr("author")},
+		CreatedAt: timePtr(time.Date(2023, 1, 15, 9, 0, 0, 0, time.UTC)),
+	}
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+	if got := *details.Timestamps.CreatedAt; got != "2023-01-15T10:00:00+01:00" {
+		t.Errorf("AnalyzeFromPR().Timestamps.CreatedAt = %v, want it in the output timezone", got)
+	}
+	if len(details.Commits) != 1 || details.Commits[0].AuthoredAt != "2023-01-15T18:00:00Z" {
+		t.Errorf("AnalyzeFromPR().Commits = %+v, want authored_at 2023-01-15T18:00:00Z", details.Commits)
+	}
+}
+
+func TestCalculateTimeInLabel(t *testing.T) {
+	start := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	now := start.Add(100 * time.Hour)
+	at := func(hours int) *github.Timestamp { return timePtr(start.Add(time.Duration(hours) * time.Hour)) }
+	labelEvent := func(event TimelineEvent, label string, hours int) *github.Timeline {
+		return &github.Timeline{Event: stringPtr(string(event)), Label: &github.Label{Name: stringPtr(label)}, CreatedAt: at(hours)}
+	}
+	open := &github.PullRequest{State: stringPtr("open")}
+	merged := &github.PullRequest{State: stringPtr("closed"), Merged: boolPtr(true), MergedAt: at(20)}
+
+	tests := []struct {
+		name     string
+		pr       *github.PullRequest
+		timeline []*github.Timeline
+		label    string
+		expected *float64
+	}{
+		{
+			name: "added and removed",
+			pr:   merged,
+			timeline: []*github.Timeline{
+				labelEvent(EventLabeled, "needs-review", 1),
+				labelEvent(EventLabeled, "needs-qa", 2),
+				labelEvent(EventUnlabeled, "needs-review", 4),
+			},
+			label:    "needs-review",
+			expected: float64Ptr(3),
+		},
+		{
+			name: "added twice sums both intervals",
+			pr:   merged,
+			timeline: []*github.Timeline{
+				labelEvent(EventUnlabeled, "needs-review", 6),
+				labelEvent(EventLabeled, "needs-review", 1),
+				labelEvent(EventLabeled, "needs-review", 10),
+				labelEvent(EventUnlabeled, "needs-review", 12),
+				labelEvent(EventLabeled, "needs-review", 3),
+			},
+			label:    "needs-review",
+			expected: float64Ptr(7),
+		},
+		{
+			name:     "still applied at merge",
+			pr:       merged,
+			timeline: []*github.Timeline{labelEvent(EventLabeled, "needs-review", 15)},
+			label:    "needs-review",
+			expected: float64Ptr(5),
+		},
+		{
+			name: "removed after merge",
+			pr:   merged,
+			timeline: []*github.Timeline{
+				labelEvent(EventLabeled, "needs-review", 15),
+				labelEvent(EventUnlabeled, "needs-review", 30),
+			},
+			label:    "needs-review",
+			expected: float64Ptr(5),
+		},
+		{
+			name:     "still applied on open PR",
+			pr:       open,
+			timeline: []*github.Timeline{labelEvent(EventLabeled, "Needs-Review", 40)},
+			label:    "needs-review",
+			expected: float64Ptr(60),
+		},
+		{
+			name:     "never applied",
+			pr:       merged,
+			timeline: []*github.Timeline{labelEvent(EventLabeled, "needs-qa", 1)},
+			label:    "needs-review",
+			expected: nil,
+		},
+		{
+			name:     "no workflow label configured",
+			pr:       merged,
+			timeline: []*github.Timeline{labelEvent(EventLabeled, "needs-review", 1)},
+			label:    "",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFloat64Ptr(t, "calculateTimeInLabel()", calculateTimeInLabel(tt.pr, tt.timeline, tt.label, now), tt.expected)
+		})
 	}
-	if releaseCreatedAt == nil || *releaseCreatedAt != "2023-01-16T09:00:00Z" {
-		t.Errorf("Expected release created at 2023-01-16T09:00:00Z, got %v", releaseCreatedAt)
+}
+
+func TestAnalyzeFromPR_TimeInWorkflowLabel(t *testing.T) {
+	now := time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"event":"labeled","label":{"name":"needs-review"},"created_at":"2023-01-20T08:30:00Z"}]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = Config{WorkflowLabel: "needs-review"}
+	analyzer.now = func() time.Time { return now }
+	pr := &github.PullRequest{Number: intPtr(1), State: stringPtr("open"), User: &github.User{Login: stringPtr("author")}}
+
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
 	}
+	assertFloat64Ptr(t, "TimeInWorkflowLabelHours", details.Metrics.TimeInWorkflowLabelHours, float64Ptr(3.5))
+}
 
-	// Create a timestamps object similar to how getPRDetails does
-	timestamps := &Timestamps{
-		CreatedAt: stringPtr("2023-01-15T10:00:00Z"),
-		MergedAt:  stringPtr("2023-01-15T12:00:00Z"),
+func TestEvaluateReviewSLA(t *testing.T) {
+	now := time.Date(2023, 1, 20, 10, 0, 0, 0, time.UTC)
+	review := func(login string, submitted time.Time) *github.PullRequestReview {
+		return &github.PullRequestReview{
+			User:        &github.User{Login: stringPtr(login)},
+			State:       stringPtr("COMMENTED"),
+			SubmittedAt: timePtr(submitted),
+		}
 	}
 
-	prTimestamps := &PRTimestamps{
-		FirstCommit:        timestamps.FirstCommit,
-		CreatedAt:          timestamps.CreatedAt,
-		FirstReviewRequest: timestamps.FirstReviewRequest,
-		FirstComment:       timestamps.FirstComment,
-		FirstApproval:      timestamps.FirstApproval,
-		SecondApproval:     timestamps.SecondApproval,
-		MergedAt:           timestamps.MergedAt,
-		ClosedAt:           timestamps.ClosedAt,
+	tests := []struct {
+		name           string
+		pr             *github.PullRequest
+		request        *string
+		reviews        []*github.PullRequestReview
+		expectedMet    *bool
+		expectedBreach *float64
+	}{
+		{
+			name:    "review just inside the SLA",
+			request: stringPtr("2023-01-15T10:00:00Z"),
+			reviews: []*github.PullRequestReview{
+				review("reviewer1", time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)),
+			},
+			expectedMet:    boolPtr(true),
+			expectedBreach: nil,
+		},
+		{
+			name:    "review just outside the SLA",
+			request: stringPtr("2023-01-15T10:00:00Z"),
+			reviews: []*github.PullRequestReview{
+				review("reviewer1", time.Date(2023, 1, 16, 10, 30, 0, 0, time.UTC)),
+			},
+			expectedMet:    boolPtr(false),
+			expectedBreach: float64Ptr(0.5),
+		},
+		{
+			name:    "bot and author reviews are ignored",
+			request: stringPtr("2023-01-15T10:00:00Z"),
+			reviews: []*github.PullRequestReview{
+				review("ci[bot]", time.Date(2023, 1, 15, 10, 5, 0, 0, time.UTC)),
+				review("author", time.Date(2023, 1, 15, 11, 0, 0, 0, time.UTC)),
+				review("reviewer1", time.Date(2023, 1, 17, 10, 0, 0, 0, time.UTC)),
+			},
+			expectedMet:    boolPtr(false),
+			expectedBreach: float64Ptr(24),
+		},
+		{
+			name:           "no human review yet is measured until now",
+			request:        stringPtr("2023-01-19T12:00:00Z"),
+			reviews:        nil,
+			expectedMet:    boolPtr(true),
+			expectedBreach: nil,
+		},
+		{
+			name:           "closed without a review is measured until closed",
+			pr:             &github.PullRequest{ClosedAt: timePtr(time.Date(2023, 1, 16, 16, 0, 0, 0, time.UTC))},
+			request:        stringPtr("2023-01-15T10:00:00Z"),
+			reviews:        nil,
+			expectedMet:    boolPtr(false),
+			expectedBreach: float64Ptr(6),
+		},
+		{
+			name: "merged without a review is measured until merged",
+			pr: &github.PullRequest{
+				MergedAt: timePtr(time.Date(2023, 1, 16, 8, 0, 0, 0, time.UTC)),
+				ClosedAt: timePtr(time.Date(2023, 1, 16, 8, 0, 0, 0, time.UTC)),
+			},
+			request:        stringPtr("2023-01-15T10:00:00Z"),
+			reviews:        nil,
+			expectedMet:    boolPtr(true),
+			expectedBreach: nil,
+		},
+		{
+			name:           "no review request",
+			request:        nil,
+			expectedMet:    nil,
+			expectedBreach: nil,
+		},
 	}
 
-	// Add release creation timestamp if it exists (like getPRDetails does)
-	if releaseCreatedAt != nil && *releaseCreatedAt != "" {
-		prTimestamps.ReleaseCreatedAt = releaseCreatedAt
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := tt.pr
+			if pr == nil {
+				pr = &github.PullRequest{}
+			}
+			met, breach := evaluateReviewSLA(pr, tt.request, tt.reviews, "author", 24, now)
+			if tt.expectedMet == nil {
+				if met != nil {
+					t.Errorf("evaluateReviewSLA() met = %v, want nil", *met)
+				}
+			} else if met == nil || *met != *tt.expectedMet {
+				t.Errorf("evaluateReviewSLA() met = %v, want %v", met, *tt.expectedMet)
+			}
+			assertFloat64Ptr(t, "evaluateReviewSLA() breach", breach, tt.expectedBreach)
+		})
 	}
+}
 
-	// Verify release_created_at is in timestamps object
-	if prTimestamps.ReleaseCreatedAt == nil {
-		t.Error("Expected ReleaseCreatedAt to be set in timestamps object")
-	} else if *prTimestamps.ReleaseCreatedAt != "2023-01-16T09:00:00Z" {
-		t.Errorf("Expected ReleaseCreatedAt to be 2023-01-16T09:00:00Z, got %v", *prTimestamps.ReleaseCreatedAt)
+func TestResolveUserProfiles_CachesUsers(t *testing.T) {
+	calls := make(map[string]int)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		login := strings.TrimPrefix(r.URL.Path, "/users/")
+		calls[login]++
+		fmt.Fprintf(w, `{"login":%q,"name":"Name of %s","company":"Acme"}`, login, login)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	ctx := context.Background()
+
+	// user1 is both an approver and a commenter
+	profiles, err := analyzer.resolveUserProfiles(ctx, []string{"user1", "user2"}, []string{"user1"})
+	if err != nil {
+		t.Fatalf("resolveUserProfiles() error = %v", err)
+	}
+	if len(profiles) != 2 {
+		t.Errorf("resolveUserProfiles() returned %d profiles, want 2", len(profiles))
+	}
+	if profiles["user1"].Name != "Name of user1" || profiles["user1"].Company != "Acme" {
+		t.Errorf("resolveUserProfiles()[user1] = %+v, want name and company", profiles["user1"])
+	}
+
+	// A second PR analyzed by the same Analyzer reuses the cache
+	if _, err := analyzer.resolveUserProfiles(ctx, []string{"user2", "user1"}); err != nil {
+		t.Fatalf("resolveUserProfiles() second call error = %v", err)
+	}
+
+	for login, count := range calls {
+		if count != 1 {
+			t.Errorf("user %s fetched %d times, want 1", login, count)
+		}
+	}
+}
+
+func TestAnalyzeFromPR_RequireJiraIssue(t *testing.T) {
+	tests := []struct {
+		name            string
+		title           string
+		author          string
+		config          Config
+		expectedErr     error
+		expectedMissing bool
+	}{
+		{
+			name:   "PR with ticket passes",
+			title:  "ABC-123 Add feature",
+			author: "user1",
+			config: Config{RequireJiraIssue: true},
+		},
+		{
+			name:        "PR without ticket fails",
+			title:       "Add feature",
+			author:      "user1",
+			config:      Config{RequireJiraIssue: true},
+			expectedErr: ErrJiraIssueMissing,
+		},
+		{
+			name:            "PR without ticket is flagged",
+			title:           "Add feature",
+			author:          "user1",
+			config:          Config{RequireJiraIssue: true, JiraMissingAsFlag: true},
+			expectedMissing: true,
+		},
+		{
+			name:   "bot PR without ticket is exempt",
+			title:  "Bump dependency",
+			author: "dependabot[bot]",
+			config: Config{RequireJiraIssue: true},
+		},
+		{
+			name:        "custom unknown value still fails",
+			title:       "Add feature",
+			author:      "user1",
+			config:      Config{RequireJiraIssue: true, JiraUnknownValue: stringPtr("")},
+			expectedErr: ErrJiraIssueMissing,
+		},
+		{
+			name:   "bot PR is exempt when sentinels match",
+			title:  "Bump dependency",
+			author: "dependabot[bot]",
+			config: Config{RequireJiraIssue: true, JiraUnknownValue: stringPtr(""), JiraBotValue: stringPtr("")},
+		},
+		{
+			name:   "policy disabled",
+			title:  "Add feature",
+			author: "user1",
+			config: Config{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := newTestAnalyzer(t, http.NewServeMux())
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{
+				Number: intPtr(1),
+				Title:  stringPtr(tt.title),
+				User:   &github.User{Login: stringPtr(tt.author)},
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("AnalyzeFromPR() error = %v, want %v", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.JiraMissing != tt.expectedMissing {
+				t.Errorf("AnalyzeFromPR().JiraMissing = %v, want %v", details.JiraMissing, tt.expectedMissing)
+			}
+		})
+	}
+}
+
+func TestAnalyzePR_RequireClosed(t *testing.T) {
+	tests := []struct {
+		name        string
+		prJSON      string
+		expectedErr error
+	}{
+		{
+			name:        "open PR",
+			prJSON:      `{"number":1,"state":"open","user":{"login":"author"}}`,
+			expectedErr: ErrPRStillOpen,
+		},
+		{
+			name:        "open draft",
+			prJSON:      `{"number":1,"state":"open","draft":true,"user":{"login":"author"}}`,
+			expectedErr: ErrPRStillOpen,
+		},
+		{
+			name:   "merged PR",
+			prJSON: `{"number":1,"state":"closed","merged":true,"merged_at":"2023-01-16T10:00:00Z","user":{"login":"author"}}`,
+		},
+		{
+			name:   "closed without merging",
+			prJSON: `{"number":1,"state":"closed","user":{"login":"author"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reviewFetches int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.prJSON)
+			})
+			mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&reviewFetches, 1)
+				fmt.Fprint(w, "[]")
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = Config{RequireClosed: true, SkipReleaseLookup: true}
+
+			details, err := analyzer.AnalyzePR(context.Background(), "org", "repo", 1)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("AnalyzePR() error = %v, want %v", err, tt.expectedErr)
+				}
+				if details != nil || atomic.LoadInt32(&reviewFetches) != 0 {
+					t.Errorf("AnalyzePR() = %v after %d review fetches, want no result and no further fetches", details, reviewFetches)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AnalyzePR() error = %v", err)
+			}
+			if details == nil || details.Metrics == nil {
+				t.Errorf("AnalyzePR() = %v, want full details", details)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_ResolveBotViaAPI(t *testing.T) {
+	tests := []struct {
+		name          string
+		author        string
+		userType      string
+		config        Config
+		expectedBot   bool
+		expectedJira  string
+		expectedCalls int
+	}{
+		{
+			name:          "API reports bot without suffix",
+			author:        "renovate-app",
+			userType:      "Bot",
+			config:        Config{ResolveBotViaAPI: true},
+			expectedBot:   true,
+			expectedJira:  "BOT",
+			expectedCalls: 1,
+		},
+		{
+			name:          "API reports user",
+			author:        "user1",
+			userType:      "User",
+			config:        Config{ResolveBotViaAPI: true},
+			expectedBot:   false,
+			expectedJira:  "UNKNOWN",
+			expectedCalls: 1,
+		},
+		{
+			name:          "suffix skips the lookup",
+			author:        "dependabot[bot]",
+			config:        Config{ResolveBotViaAPI: true},
+			expectedBot:   true,
+			expectedJira:  "BOT",
+			expectedCalls: 0,
+		},
+		{
+			name:          "lookup disabled",
+			author:        "renovate-app",
+			userType:      "Bot",
+			config:        Config{},
+			expectedBot:   false,
+			expectedJira:  "UNKNOWN",
+			expectedCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				fmt.Fprintf(w, `{"login":%q,"type":%q}`, strings.TrimPrefix(r.URL.Path, "/users/"), tt.userType)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{
+				Number: intPtr(1),
+				Title:  stringPtr("Update dependencies"),
+				User:   &github.User{Login: stringPtr(tt.author)},
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.IsBot != tt.expectedBot {
+				t.Errorf("AnalyzeFromPR().IsBot = %v, want %v", details.IsBot, tt.expectedBot)
+			}
+			if details.JiraIssue != tt.expectedJira {
+				t.Errorf("AnalyzeFromPR().JiraIssue = %v, want %v", details.JiraIssue, tt.expectedJira)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("user lookups = %d, want %d", calls, tt.expectedCalls)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_JiraSentinels(t *testing.T) {
+	tests := []struct {
+		name         string
+		title        string
+		author       string
+		config       Config
+		expectedJira string
+	}{
+		{
+			name:         "default unknown value",
+			title:        "Add feature",
+			author:       "user1",
+			expectedJira: DefaultJiraUnknownValue,
+		},
+		{
+			name:         "default bot value",
+			title:        "Bump dependency",
+			author:       "dependabot[bot]",
+			expectedJira: DefaultJiraBotValue,
+		},
+		{
+			name:         "custom unknown value",
+			title:        "Add feature",
+			author:       "user1",
+			config:       Config{JiraUnknownValue: stringPtr("NO-TICKET")},
+			expectedJira: "NO-TICKET",
+		},
+		{
+			name:         "custom bot value",
+			title:        "Bump dependency",
+			author:       "dependabot[bot]",
+			config:       Config{JiraBotValue: stringPtr("AUTOMATION")},
+			expectedJira: "AUTOMATION",
+		},
+		{
+			name:         "empty unknown value",
+			title:        "Add feature",
+			author:       "user1",
+			config:       Config{JiraUnknownValue: stringPtr("")},
+			expectedJira: "",
+		},
+		{
+			name:         "empty bot value",
+			title:        "Bump dependency",
+			author:       "dependabot[bot]",
+			config:       Config{JiraBotValue: stringPtr("")},
+			expectedJira: "",
+		},
+		{
+			name:         "real issue is unaffected",
+			title:        "ABC-123 Add feature",
+			author:       "user1",
+			config:       Config{JiraUnknownValue: stringPtr(""), JiraBotValue: stringPtr("")},
+			expectedJira: "ABC-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := newTestAnalyzer(t, http.NewServeMux())
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{
+				Number: intPtr(1),
+				Title:  stringPtr(tt.title),
+				User:   &github.User{Login: stringPtr(tt.author)},
+			}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.JiraIssue != tt.expectedJira {
+				t.Errorf("AnalyzeFromPR().JiraIssue = %q, want %q", details.JiraIssue, tt.expectedJira)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_ReviewerFilter(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"user":{"login":"team1"},"state":"APPROVED","submitted_at":"2024-01-01T12:00:00Z"},
+			{"user":{"login":"outsider"},"state":"APPROVED","submitted_at":"2024-01-01T12:00:00Z"},
+			{"user":{"login":"team2"},"state":"CHANGES_REQUESTED","submitted_at":"2024-01-01T11:00:00Z"},
+			{"user":{"login":"outsider"},"state":"CHANGES_REQUESTED","submitted_at":"2024-01-01T11:00:00Z"}
+		]`)
+	})
+	mux.HandleFunc("/repos/org/repo/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"user":{"login":"team1"},"created_at":"2024-01-01T10:00:00Z"},
+			{"user":{"login":"outsider"},"created_at":"2024-01-01T10:00:00Z"}
+		]`)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"user":{"login":"outsider"},"created_at":"2024-01-01T10:30:00Z"}]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = Config{ReviewerFilter: []string{"team1", "team2"}}
+
+	pr := &github.PullRequest{
+		Number: intPtr(1),
+		User:   &github.User{Login: stringPtr("author")},
+	}
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+
+	if details.NumApprovers != 1 || len(details.ApproverUsernames) != 1 || details.ApproverUsernames[0] != "team1" {
+		t.Errorf("AnalyzeFromPR() approvers = %v (%d), want [team1]", details.ApproverUsernames, details.NumApprovers)
+	}
+	if details.ChangeRequestsCount != 1 {
+		t.Errorf("AnalyzeFromPR().ChangeRequestsCount = %d, want 1", details.ChangeRequestsCount)
+	}
+	if details.DistinctChangeRequesters != 1 {
+		t.Errorf("AnalyzeFromPR().DistinctChangeRequesters = %d, want 1", details.DistinctChangeRequesters)
+	}
+	if details.NumComments != 1 {
+		t.Errorf("AnalyzeFromPR().NumComments = %d, want 1", details.NumComments)
+	}
+	if details.NumCommenters != 1 || details.CommenterUsernames[0] != "team1" {
+		t.Errorf("AnalyzeFromPR().CommenterUsernames = %v, want [team1]", details.CommenterUsernames)
+	}
+	if details.NumRequestedReviewers != 2 {
+		t.Errorf("AnalyzeFromPR().NumRequestedReviewers = %d, want 2", details.NumRequestedReviewers)
+	}
+}
+
+func TestAnalyzeFromPR_ReviewerFilterRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"user":{"login":"alice"},"state":"APPROVED","submitted_at":"2024-01-01T12:00:00Z"}]`)
+	})
+	mux.HandleFunc("/repos/org/repo/issues/1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"event":"review_requested","requested_reviewer":{"login":"alice"},"created_at":"2024-01-01T10:00:00Z"},
+			{"event":"review_requested","requested_reviewer":{"login":"bob"},"created_at":"2024-01-01T10:00:00Z"}
+		]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = Config{ReviewerFilter: []string{"alice"}}
+
+	pr := &github.PullRequest{
+		Number:             intPtr(1),
+		User:               &github.User{Login: stringPtr("author")},
+		RequestedReviewers: []*github.User{{Login: stringPtr("bob")}},
+	}
+	details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+	if err != nil {
+		t.Fatalf("AnalyzeFromPR() error = %v", err)
+	}
+
+	if details.NumRequestedReviewers != 1 {
+		t.Errorf("AnalyzeFromPR().NumRequestedReviewers = %d, want 1", details.NumRequestedReviewers)
+	}
+	if details.UnfulfilledReviewRequests != 0 {
+		t.Errorf("AnalyzeFromPR().UnfulfilledReviewRequests = %d, want 0", details.UnfulfilledReviewRequests)
+	}
+	if details.DistinctReviewRequestRounds != 1 {
+		t.Errorf("AnalyzeFromPR().DistinctReviewRequestRounds = %d, want 1", details.DistinctReviewRequestRounds)
+	}
+	if details.TotalReviewersEverRequested != 1 {
+		t.Errorf("AnalyzeFromPR().TotalReviewersEverRequested = %d, want 1", details.TotalReviewersEverRequested)
+	}
+	assertFloat64Ptr(t, "ReviewerParticipationRatio", details.Metrics.ReviewerParticipationRatio, float64Ptr(1))
+	if len(pr.RequestedReviewers) != 1 {
+		t.Errorf("AnalyzeFromPR() changed the caller's RequestedReviewers to %v", pr.RequestedReviewers)
+	}
+}
+
+func TestExportedConstantsMatchGitHubValues(t *testing.T) {
+	reviewStates := map[ReviewState]string{
+		ReviewApproved:         "APPROVED",
+		ReviewChangesRequested: "CHANGES_REQUESTED",
+		ReviewCommented:        "COMMENTED",
+		ReviewDismissed:        "DISMISSED",
+		ReviewPending:          "PENDING",
+	}
+	for constant, want := range reviewStates {
+		if string(constant) != want {
+			t.Errorf("review state constant = %q, want %q", constant, want)
+		}
+	}
+
+	events := map[TimelineEvent]string{
+		EventReviewRequested:      "review_requested",
+		EventReviewRequestRemoved: "review_request_removed",
+		EventReviewed:             "reviewed",
+		EventReadyForReview:       "ready_for_review",
+		EventConvertToDraft:       "convert_to_draft",
+		EventCommitted:            "committed",
+		EventLabeled:              "labeled",
+		EventUnlabeled:            "unlabeled",
+		EventClosed:               "closed",
+		EventReopened:             "reopened",
+		EventMerged:               "merged",
+	}
+	for constant, want := range events {
+		if string(constant) != want {
+			t.Errorf("timeline event constant = %q, want %q", constant, want)
+		}
+	}
+}
+
+func TestAnalyzeFromPR_ReviewerCountDeviation(t *testing.T) {
+	tests := []struct {
+		name              string
+		expectedReviewers int
+		expected          *int
+	}{
+		{"under-reviewed", 3, intPtr(-1)},
+		{"over-reviewed", 1, intPtr(1)},
+		{"matches policy", 2, intPtr(0)},
+		{"not configured", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[
+					{"user":{"login":"reviewer1"},"state":"APPROVED"},
+					{"user":{"login":"reviewer2"},"state":"APPROVED"}
+				]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = Config{ExpectedReviewers: tt.expectedReviewers}
+
+			pr := &github.PullRequest{Number: intPtr(1), User: &github.User{Login: stringPtr("author")}}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if (details.ReviewerCountDeviation == nil) != (tt.expected == nil) ||
+				(tt.expected != nil && *details.ReviewerCountDeviation != *tt.expected) {
+				t.Errorf("AnalyzeFromPR().ReviewerCountDeviation = %v, want %v", details.ReviewerCountDeviation, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_MinLinesForMetrics(t *testing.T) {
+	tests := []struct {
+		name               string
+		minLines           int
+		expectedSuppressed bool
+	}{
+		{"below threshold", 11, true},
+		{"at threshold", 10, false},
+		{"above threshold", 9, false},
+		{"not configured", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/org/repo/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"filename":"README.md","additions":6,"deletions":4}]`)
+			})
+			mux.HandleFunc("/repos/org/repo/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"user":{"login":"reviewer1"},"created_at":"2024-01-01T10:00:00Z"}]`)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = Config{MinLinesForMetrics: tt.minLines}
+
+			pr := &github.PullRequest{Number: intPtr(1), User: &github.User{Login: stringPtr("author")}}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if details.LinesChanged != 10 {
+				t.Fatalf("AnalyzeFromPR().LinesChanged = %d, want 10", details.LinesChanged)
+			}
+			if details.MetricsSuppressed != tt.expectedSuppressed {
+				t.Errorf("AnalyzeFromPR().MetricsSuppressed = %v, want %v", details.MetricsSuppressed, tt.expectedSuppressed)
+			}
+			if (details.Metrics == nil) != tt.expectedSuppressed {
+				t.Errorf("AnalyzeFromPR().Metrics = %v, want nil only when suppressed", details.Metrics)
+			}
+			// Counts are still reported for trivial PRs
+			if details.NumComments != 1 {
+				t.Errorf("AnalyzeFromPR().NumComments = %d, want 1", details.NumComments)
+			}
+		})
 	}
 }
@@ -0,0 +1,396 @@
+package pullmetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// AnalyzePRs analyzes multiple PRs in the same repository using up to concurrency
+// parallel workers (minimum 1). Results are returned in the same order as prNumbers;
+// entries for PRs that failed or were never analyzed are nil.
+//
+// A failure on one PR doesn't stop the batch; all per-PR errors are returned joined
+// together. If ctx is cancelled, no further PRs are started and the results completed
+// so far are returned along with the context's error.
+//
+// When Config.BatchFailureThreshold is set, the batch is aborted once that many PRs in a
+// row fail with a 401 or 403 response (such as a revoked token), since the remaining PRs
+// would fail the same way. The results completed so far are returned with an error
+// wrapping ErrBatchAborted and the error that tripped the threshold.
+func (a *Analyzer) AnalyzePRs(ctx context.Context, org, repo string, prNumbers []int, concurrency int) ([]*PRDetails, error) {
+	return runBatch(ctx, prNumbers, concurrency, a.config.BatchFailureThreshold, func(ctx context.Context, i int) (*PRDetails, error) {
+		return a.AnalyzePR(ctx, org, repo, prNumbers[i])
+	})
+}
+
+// AnalyzeOpenPRs lists every open PR in the repository and analyzes them with up to
+// concurrency parallel workers, for triage reports. Results follow the listing order
+// (newest first) and share AnalyzePRs' handling of per-PR failures, cancellation and
+// Config.BatchFailureThreshold.
+// Rate limit responses carrying Retry-After are waited out by the client's transport.
+// Use SortByLongestIdle to order the results by staleness.
+func (a *Analyzer) AnalyzeOpenPRs(ctx context.Context, org, repo string, concurrency int) ([]*PRDetails, error) {
+	prs, err := a.fetchOpenPRs(ctx, org, repo, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	prNumbers := make([]int, len(prs))
+	for i, pr := range prs {
+		prNumbers[i] = pr.GetNumber()
+	}
+
+	// The listing already returns full PR objects, so they aren't fetched again
+	return runBatch(ctx, prNumbers, concurrency, a.config.BatchFailureThreshold, func(ctx context.Context, i int) (*PRDetails, error) {
+		return a.AnalyzeFromPR(ctx, org, repo, prs[i])
+	})
+}
+
+// fetchOpenPRs lists the repository's open PRs, optionally only those whose head is the
+// given "owner:branch" or whose base is the given branch
+func (a *Analyzer) fetchOpenPRs(ctx context.Context, org, repo, head, base string) ([]*github.PullRequest, error) {
+	var allPRs []*github.PullRequest
+	opts := &github.PullRequestListOptions{
+		State:       "open",
+		Head:        head,
+		Base:        base,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		prs, resp, err := a.apiClient().PullRequests.List(ctx, org, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list open PRs: %w", err)
+		}
+		allPRs = append(allPRs, prs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allPRs, nil
+}
+
+// maxSearchResults is the most results the GitHub search API returns for a single query
+const maxSearchResults = 1000
+
+// AnalyzeSearch runs a GitHub issue search, such as "is:pr is:merged repo:org/repo
+// label:foo", and analyzes each matching PR with up to concurrency parallel workers. The
+// query may span repositories. Only the first 1000 results are available through the
+// search API. Results follow the search order and share AnalyzePRs' handling of per-PR
+// failures, cancellation and Config.BatchFailureThreshold.
+//
+// If the query matches any issue that isn't a pull request, nothing is analyzed and an
+// error wrapping ErrSearchMatchedIssue is returned.
+func (a *Analyzer) AnalyzeSearch(ctx context.Context, query string, concurrency int) ([]*PRDetails, error) {
+	issues, err := a.searchIssues(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	type target struct{ org, repo string }
+	targets := make([]target, len(issues))
+	prNumbers := make([]int, len(issues))
+	for i, issue := range issues {
+		org, repo, ok := repoFromURL(issue.GetRepositoryURL())
+		if !issue.IsPullRequest() || !ok {
+			return nil, fmt.Errorf("%w: #%d %q", ErrSearchMatchedIssue, issue.GetNumber(), issue.GetTitle())
+		}
+		targets[i] = target{org: org, repo: repo}
+		prNumbers[i] = issue.GetNumber()
+	}
+
+	// Search results are issues, so each PR is fetched in full before it's analyzed
+	return runBatch(ctx, prNumbers, concurrency, a.config.BatchFailureThreshold, func(ctx context.Context, i int) (*PRDetails, error) {
+		return a.AnalyzePR(ctx, targets[i].org, targets[i].repo, prNumbers[i])
+	})
+}
+
+func (a *Analyzer) searchIssues(ctx context.Context, query string) ([]*github.Issue, error) {
+	var allIssues []*github.Issue
+	opts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		result, resp, err := a.apiClient().Search.Issues(ctx, query, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search pull requests: %w", err)
+		}
+		allIssues = append(allIssues, result.Issues...)
+		if resp.NextPage == 0 || len(allIssues) >= maxSearchResults {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if len(allIssues) > maxSearchResults {
+		allIssues = allIssues[:maxSearchResults]
+	}
+	return allIssues, nil
+}
+
+// repoFromURL extracts the owner and name from an API repository URL such as
+// https://api.github.com/repos/org/repo
+func repoFromURL(repositoryURL string) (string, string, bool) {
+	_, path, found := strings.Cut(repositoryURL, "/repos/")
+	if !found {
+		return "", "", false
+	}
+	org, repo, found := strings.Cut(strings.Trim(path, "/"), "/")
+	if !found || org == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", false
+	}
+	return org, repo, true
+}
+
+// SortByLongestIdle orders PRs by Metrics.LongestIdleHours, longest first. PRs without
+// the metric, and nil entries, are placed last.
+func SortByLongestIdle(details []*PRDetails) {
+	idle := func(d *PRDetails) (float64, bool) {
+		if d == nil || d.Metrics == nil || d.Metrics.LongestIdleHours == nil {
+			return 0, false
+		}
+		return *d.Metrics.LongestIdleHours, true
+	}
+	sort.SliceStable(details, func(i, j int) bool {
+		left, leftOK := idle(details[i])
+		right, rightOK := idle(details[j])
+		if leftOK != rightOK {
+			return leftOK
+		}
+		return left > right
+	})
+}
+
+// runBatch calls analyze for each index of prNumbers using up to concurrency workers.
+// See AnalyzePRs for how results, errors, cancellation and failureThreshold (zero
+// disables it) are reported.
+func runBatch(ctx context.Context, prNumbers []int, concurrency, failureThreshold int, analyze func(ctx context.Context, i int) (*PRDetails, error)) ([]*PRDetails, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*PRDetails, len(prNumbers))
+	errs := make([]error, len(prNumbers))
+
+	batchCtx, abort := context.WithCancel(ctx)
+	defer abort()
+	var (
+		mu                  sync.Mutex
+		consecutiveFailures int
+		abortErr            error
+	)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				details, err := analyze(batchCtx, i)
+
+				mu.Lock()
+				if err != nil && isAuthFailure(err) {
+					consecutiveFailures++
+				} else {
+					consecutiveFailures = 0
+				}
+				if failureThreshold > 0 && consecutiveFailures >= failureThreshold && abortErr == nil {
+					abortErr = fmt.Errorf("%w after %d consecutive failures: PR #%d: %w", ErrBatchAborted, consecutiveFailures, prNumbers[i], err)
+					abort()
+				}
+				mu.Unlock()
+
+				if err != nil {
+					errs[i] = fmt.Errorf("PR #%d: %w", prNumbers[i], err)
+					continue
+				}
+				results[i] = details
+			}
+		}()
+	}
+
+feed:
+	for i := range prNumbers {
+		select {
+		case <-batchCtx.Done():
+			break feed
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if abortErr != nil {
+		return results, abortErr
+	}
+	if err := ctx.Err(); err != nil {
+		return results, fmt.Errorf("batch cancelled: %w", err)
+	}
+	return results, errors.Join(errs...)
+}
+
+// isAuthFailure reports whether err comes from a 401 or 403 response, which retrying
+// with the same token won't fix. Rate limit errors are reported by go-github as
+// different error types and don't match.
+func isAuthFailure(err error) bool {
+	if errors.Is(err, ErrSSOAuthorizationRequired) {
+		return true
+	}
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	return errResp.Response.StatusCode == http.StatusUnauthorized || errResp.Response.StatusCode == http.StatusForbidden
+}
+
+// BatchSummary aggregates timing metrics across a set of analyzed PRs
+type BatchSummary struct {
+	TotalPRs               int                `json:"total_prs"`
+	TimeToFirstReviewHours *PercentileSummary `json:"time_to_first_review_hours,omitempty"`
+	ReviewCycleTimeHours   *PercentileSummary `json:"review_cycle_time_hours,omitempty"`
+	TimeToMergeHours       *PercentileSummary `json:"time_to_merge_hours,omitempty"`
+}
+
+// PercentileSummary describes the distribution of a single metric across a batch
+type PercentileSummary struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P95   float64 `json:"p95"`
+}
+
+// SummarizeBatch computes p50/p90/p95 for the timing metrics of the given PRs. Time to
+// merge is measured from creation to merge from the PR's timestamps, so unmerged PRs have
+// none. PRs without a value for a metric are excluded from that metric's distribution
+// only; a metric with no values at all is left nil.
+func SummarizeBatch(details []*PRDetails) *BatchSummary {
+	summary := &BatchSummary{}
+
+	var firstReview, reviewCycle, toMerge []float64
+	for _, d := range details {
+		if d == nil {
+			continue
+		}
+		summary.TotalPRs++
+		if d.Timestamps != nil {
+			if hours := hoursBetween(d.Timestamps.CreatedAt, d.Timestamps.MergedAt); hours != nil {
+				toMerge = append(toMerge, *hours)
+			}
+		}
+		if d.Metrics == nil {
+			continue
+		}
+		if d.Metrics.TimeToFirstReviewHours != nil {
+			firstReview = append(firstReview, *d.Metrics.TimeToFirstReviewHours)
+		}
+		if d.Metrics.ReviewCycleTimeHours != nil {
+			reviewCycle = append(reviewCycle, *d.Metrics.ReviewCycleTimeHours)
+		}
+	}
+
+	summary.TimeToFirstReviewHours = summarizePercentiles(firstReview)
+	summary.ReviewCycleTimeHours = summarizePercentiles(reviewCycle)
+	summary.TimeToMergeHours = summarizePercentiles(toMerge)
+
+	return summary
+}
+
+// GroupPRDetailsByAuthor groups PRs by AuthorUsername, keeping each author's PRs in their
+// original order. Bots get their own group per login like any other author; use IsBot or
+// SummarizeByAuthor to tell them apart or leave them out. nil entries are skipped.
+func GroupPRDetailsByAuthor(details []*PRDetails) map[string][]*PRDetails {
+	groups := make(map[string][]*PRDetails)
+	for _, d := range details {
+		if d == nil {
+			continue
+		}
+		groups[d.AuthorUsername] = append(groups[d.AuthorUsername], d)
+	}
+	return groups
+}
+
+// AuthorSummary aggregates the PRs of one author, for per-engineer reports
+type AuthorSummary struct {
+	Author                     string   `json:"author"`
+	IsBot                      bool     `json:"is_bot"`
+	TotalPRs                   int      `json:"total_prs"`
+	MedianReviewCycleTimeHours *float64 `json:"median_review_cycle_time_hours,omitempty"`
+}
+
+// SummarizeByAuthor returns each author's PR count and median review cycle time, sorted
+// by author. PRs without a review cycle time only count towards TotalPRs, and the median
+// is nil for authors with none. An author is a bot when any of their PRs has IsBot set;
+// bots are left out unless includeBots is true.
+func SummarizeByAuthor(details []*PRDetails, includeBots bool) []AuthorSummary {
+	summaries := []AuthorSummary{}
+	for author, prs := range GroupPRDetailsByAuthor(details) {
+		summary := AuthorSummary{Author: author, TotalPRs: len(prs)}
+		var reviewCycle []float64
+		for _, d := range prs {
+			summary.IsBot = summary.IsBot || d.IsBot
+			if d.Metrics != nil && d.Metrics.ReviewCycleTimeHours != nil {
+				reviewCycle = append(reviewCycle, *d.Metrics.ReviewCycleTimeHours)
+			}
+		}
+		if summary.IsBot && !includeBots {
+			continue
+		}
+		if len(reviewCycle) > 0 {
+			median := percentile(reviewCycle, 50)
+			summary.MedianReviewCycleTimeHours = &median
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Author < summaries[j].Author })
+	return summaries
+}
+
+func summarizePercentiles(values []float64) *PercentileSummary {
+	if len(values) == 0 {
+		return nil
+	}
+	return &PercentileSummary{
+		Count: len(values),
+		P50:   percentile(values, 50),
+		P90:   percentile(values, 90),
+		P95:   percentile(values, 95),
+	}
+}
+
+// percentile returns the p-th percentile (0-100) of values using linear interpolation
+// between the closest ranks: rank = p/100 * (n-1) over the sorted values.
+// The input slice is not modified. Returns 0 for an empty slice.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 100 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	fraction := rank - float64(lower)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + fraction*(sorted[lower+1]-sorted[lower])
+}
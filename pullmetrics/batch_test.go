@@ -0,0 +1,523 @@
+package pullmetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestPercentile(t *testing.T) {
+	// 1..10: rank = p/100 * 9
+	values := []float64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5}
+
+	tests := []struct {
+		name     string
+		values   []float64
+		p        float64
+		expected float64
+	}{
+		{name: "p50 of 1..10", values: values, p: 50, expected: 5.5},
+		{name: "p90 of 1..10", values: values, p: 90, expected: 9.1},
+		{name: "p95 of 1..10", values: values, p: 95, expected: 9.55},
+		{name: "p0 is the minimum", values: values, p: 0, expected: 1},
+		{name: "p100 is the maximum", values: values, p: 100, expected: 10},
+		{name: "single value", values: []float64{4}, p: 90, expected: 4},
+		{name: "empty", values: nil, p: 50, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := percentile(tt.values, tt.p)
+			if math.Abs(result-tt.expected) > 1e-9 {
+				t.Errorf("percentile(%v) = %v, want %v", tt.p, result, tt.expected)
+			}
+		})
+	}
+
+	if values[0] != 10 {
+		t.Error("percentile() should not modify its input")
+	}
+}
+
+func TestSummarizeBatch(t *testing.T) {
+	merged := func(created, mergedAt string) *PRTimestamps {
+		return &PRTimestamps{CreatedAt: stringPtr(created), MergedAt: stringPtr(mergedAt)}
+	}
+	details := []*PRDetails{
+		{
+			Metrics:    &PRMetrics{TimeToFirstReviewHours: float64Ptr(1), ReviewCycleTimeHours: float64Ptr(10)},
+			Timestamps: merged("2023-01-15T10:00:00Z", "2023-01-15T14:00:00Z"),
+		},
+		{
+			Metrics:    &PRMetrics{TimeToFirstReviewHours: float64Ptr(2), ReviewCycleTimeHours: float64Ptr(20)},
+			Timestamps: merged("2023-01-15T10:00:00Z", "2023-01-16T10:00:00Z"),
+		},
+		{
+			// Merge time in another offset: 8 hours after creation
+			Metrics:    &PRMetrics{TimeToFirstReviewHours: float64Ptr(3)},
+			Timestamps: merged("2023-01-15T10:00:00Z", "2023-01-15T13:00:00-05:00"),
+		},
+		{
+			// Not merged
+			Metrics:    &PRMetrics{TimeToFirstReviewHours: float64Ptr(4)},
+			Timestamps: &PRTimestamps{CreatedAt: stringPtr("2023-01-15T10:00:00Z")},
+		},
+		{Metrics: nil},
+		nil,
+	}
+
+	summary := SummarizeBatch(details)
+
+	if summary.TotalPRs != 5 {
+		t.Errorf("SummarizeBatch().TotalPRs = %v, want 5", summary.TotalPRs)
+	}
+
+	if summary.TimeToFirstReviewHours == nil {
+		t.Fatal("SummarizeBatch().TimeToFirstReviewHours = nil, want summary")
+	}
+	if summary.TimeToFirstReviewHours.Count != 4 {
+		t.Errorf("TimeToFirstReviewHours.Count = %v, want 4", summary.TimeToFirstReviewHours.Count)
+	}
+	if math.Abs(summary.TimeToFirstReviewHours.P50-2.5) > 1e-9 {
+		t.Errorf("TimeToFirstReviewHours.P50 = %v, want 2.5", summary.TimeToFirstReviewHours.P50)
+	}
+	if math.Abs(summary.TimeToFirstReviewHours.P90-3.7) > 1e-9 {
+		t.Errorf("TimeToFirstReviewHours.P90 = %v, want 3.7", summary.TimeToFirstReviewHours.P90)
+	}
+
+	if summary.ReviewCycleTimeHours == nil || summary.ReviewCycleTimeHours.Count != 2 {
+		t.Errorf("SummarizeBatch().ReviewCycleTimeHours = %+v, want count 2", summary.ReviewCycleTimeHours)
+	} else if math.Abs(summary.ReviewCycleTimeHours.P50-15) > 1e-9 {
+		t.Errorf("ReviewCycleTimeHours.P50 = %v, want 15", summary.ReviewCycleTimeHours.P50)
+	}
+
+	// Time to merge: 4, 8 and 24 hours
+	if summary.TimeToMergeHours == nil {
+		t.Fatal("SummarizeBatch().TimeToMergeHours = nil, want summary")
+	}
+	if summary.TimeToMergeHours.Count != 3 {
+		t.Errorf("TimeToMergeHours.Count = %v, want 3", summary.TimeToMergeHours.Count)
+	}
+	if math.Abs(summary.TimeToMergeHours.P50-8) > 1e-9 {
+		t.Errorf("TimeToMergeHours.P50 = %v, want 8", summary.TimeToMergeHours.P50)
+	}
+	if math.Abs(summary.TimeToMergeHours.P90-20.8) > 1e-9 {
+		t.Errorf("TimeToMergeHours.P90 = %v, want 20.8", summary.TimeToMergeHours.P90)
+	}
+	if math.Abs(summary.TimeToMergeHours.P95-22.4) > 1e-9 {
+		t.Errorf("TimeToMergeHours.P95 = %v, want 22.4", summary.TimeToMergeHours.P95)
+	}
+}
+
+func TestSummarizeBatch_NoMetrics(t *testing.T) {
+	summary := SummarizeBatch([]*PRDetails{{}})
+
+	if summary.TimeToFirstReviewHours != nil || summary.ReviewCycleTimeHours != nil || summary.TimeToMergeHours != nil {
+		t.Errorf("SummarizeBatch() with no metric values = %+v, want nil distributions", summary)
+	}
+}
+
+func TestGroupPRDetailsByAuthor(t *testing.T) {
+	details := []*PRDetails{
+		{PRNumber: 1, AuthorUsername: "alice"},
+		{PRNumber: 2, AuthorUsername: "bob"},
+		nil,
+		{PRNumber: 3, AuthorUsername: "alice"},
+		{PRNumber: 4, AuthorUsername: "dependabot[bot]", IsBot: true},
+	}
+
+	groups := GroupPRDetailsByAuthor(details)
+
+	numbers := make(map[string][]int)
+	for author, prs := range groups {
+		for _, d := range prs {
+			numbers[author] = append(numbers[author], d.PRNumber)
+		}
+	}
+	expected := map[string][]int{"alice": {1, 3}, "bob": {2}, "dependabot[bot]": {4}}
+	if !reflect.DeepEqual(numbers, expected) {
+		t.Errorf("GroupPRDetailsByAuthor() = %v, want %v", numbers, expected)
+	}
+}
+
+func TestSummarizeByAuthor(t *testing.T) {
+	details := []*PRDetails{
+		{AuthorUsername: "bob", Metrics: &PRMetrics{ReviewCycleTimeHours: float64Ptr(4)}},
+		{AuthorUsername: "alice", Metrics: &PRMetrics{ReviewCycleTimeHours: float64Ptr(10)}},
+		{AuthorUsername: "alice", Metrics: &PRMetrics{ReviewCycleTimeHours: float64Ptr(2)}},
+		{AuthorUsername: "alice", Metrics: &PRMetrics{ReviewCycleTimeHours: float64Ptr(30)}},
+		{AuthorUsername: "alice", Metrics: &PRMetrics{}},
+		{AuthorUsername: "carol"},
+		{AuthorUsername: "renovate[bot]", IsBot: true, Metrics: &PRMetrics{ReviewCycleTimeHours: float64Ptr(1)}},
+	}
+
+	tests := []struct {
+		name        string
+		includeBots bool
+		expected    []AuthorSummary
+	}{
+		{
+			name: "bots excluded",
+			expected: []AuthorSummary{
+				{Author: "alice", TotalPRs: 4, MedianReviewCycleTimeHours: float64Ptr(10)},
+				{Author: "bob", TotalPRs: 1, MedianReviewCycleTimeHours: float64Ptr(4)},
+				{Author: "carol", TotalPRs: 1},
+			},
+		},
+		{
+			name:        "bots included",
+			includeBots: true,
+			expected: []AuthorSummary{
+				{Author: "alice", TotalPRs: 4, MedianReviewCycleTimeHours: float64Ptr(10)},
+				{Author: "bob", TotalPRs: 1, MedianReviewCycleTimeHours: float64Ptr(4)},
+				{Author: "carol", TotalPRs: 1},
+				{Author: "renovate[bot]", IsBot: true, TotalPRs: 1, MedianReviewCycleTimeHours: float64Ptr(1)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if summaries := SummarizeByAuthor(details, tt.includeBots); !reflect.DeepEqual(summaries, tt.expected) {
+				t.Errorf("SummarizeByAuthor() = %+v, want %+v", summaries, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzePRs(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, number := range []int{1, 2, 3} {
+		number := number
+		mux.HandleFunc(fmt.Sprintf("/repos/org/repo/pulls/%d", number), func(w http.ResponseWriter, r *http.Request) {
+			if number == 2 {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message":"Not Found"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"number":%d,"user":{"login":"author"}}`, number)
+		})
+	}
+	analyzer := newTestAnalyzer(t, mux)
+
+	results, err := analyzer.AnalyzePRs(context.Background(), "org", "repo", []int{1, 2, 3}, 2)
+	if err == nil || !strings.Contains(err.Error(), "PR #2") {
+		t.Errorf("AnalyzePRs() error = %v, want error mentioning PR #2", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("AnalyzePRs() returned %d results, want 3", len(results))
+	}
+	if results[0] == nil || results[0].PRNumber != 1 {
+		t.Errorf("AnalyzePRs()[0] = %v, want PR 1", results[0])
+	}
+	if results[1] != nil {
+		t.Errorf("AnalyzePRs()[1] = %v, want nil for failed PR", results[1])
+	}
+	if results[2] == nil || results[2].PRNumber != 3 {
+		t.Errorf("AnalyzePRs()[2] = %v, want PR 3", results[2])
+	}
+}
+
+func TestAnalyzePRs_CancelReturnsPartialResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":1,"user":{"login":"author"}}`)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/2", func(w http.ResponseWriter, r *http.Request) {
+		// Simulate the user pressing Ctrl-C while PR 2 is being analyzed
+		cancel()
+		fmt.Fprint(w, `{"number":2,"user":{"login":"author"}}`)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("PR 3 should not be analyzed after cancellation")
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	results, err := analyzer.AnalyzePRs(ctx, "org", "repo", []int{1, 2, 3}, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("AnalyzePRs() error = %v, want context.Canceled", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("AnalyzePRs() returned %d results, want 3", len(results))
+	}
+	if results[0] == nil || results[0].PRNumber != 1 {
+		t.Errorf("AnalyzePRs()[0] = %v, want completed PR 1", results[0])
+	}
+	if results[2] != nil {
+		t.Errorf("AnalyzePRs()[2] = %v, want nil", results[2])
+	}
+}
+
+func TestAnalyzePRs_AbortsAfterConsecutiveAuthFailures(t *testing.T) {
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":1,"user":{"login":"author"}}`)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/", func(w http.ResponseWriter, r *http.Request) {
+		// Reviews, comments and files of PR 1
+		if strings.HasPrefix(r.URL.Path, "/repos/org/repo/pulls/1/") {
+			fmt.Fprint(w, "[]")
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"Bad credentials"}`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = Config{BatchFailureThreshold: 3}
+
+	prNumbers := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	results, err := analyzer.AnalyzePRs(context.Background(), "org", "repo", prNumbers, 1)
+	if !errors.Is(err, ErrBatchAborted) {
+		t.Fatalf("AnalyzePRs() error = %v, want %v", err, ErrBatchAborted)
+	}
+	if !strings.Contains(err.Error(), "PR #4") || !strings.Contains(err.Error(), "401") {
+		t.Errorf("AnalyzePRs() error = %v, want the 401 for PR #4 that tripped the threshold", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Errorf("failing PR requests = %d, want 3", got)
+	}
+	if len(results) != len(prNumbers) {
+		t.Fatalf("AnalyzePRs() returned %d results, want %d", len(results), len(prNumbers))
+	}
+	if results[0] == nil || results[0].PRNumber != 1 {
+		t.Errorf("AnalyzePRs()[0] = %v, want completed PR 1", results[0])
+	}
+	for i, result := range results[1:] {
+		if result != nil {
+			t.Errorf("AnalyzePRs()[%d] = %v, want nil", i+1, result)
+		}
+	}
+}
+
+func TestAnalyzePRs_NoAbortWithoutThreshold(t *testing.T) {
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"Bad credentials"}`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	_, err := analyzer.AnalyzePRs(context.Background(), "org", "repo", []int{1, 2, 3, 4, 5}, 2)
+	if err == nil || errors.Is(err, ErrBatchAborted) {
+		t.Errorf("AnalyzePRs() error = %v, want per-PR errors without abort", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 5 {
+		t.Errorf("failing PR requests = %d, want 5", got)
+	}
+}
+
+func TestIsAuthFailure(t *testing.T) {
+	response := func(status int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status}}
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "unauthorized", err: fmt.Errorf("failed to fetch PR: %w", response(http.StatusUnauthorized)), expected: true},
+		{name: "forbidden", err: response(http.StatusForbidden), expected: true},
+		{name: "SSO authorization", err: &SSOAuthorizationError{URL: "https://github.com/orgs/org/sso"}, expected: true},
+		{name: "not found", err: response(http.StatusNotFound), expected: false},
+		{name: "rate limited", err: &github.RateLimitError{Response: &http.Response{StatusCode: http.StatusForbidden}}, expected: false},
+		{name: "other error", err: errors.New("connection reset"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAuthFailure(tt.err); got != tt.expected {
+				t.Errorf("isAuthFailure() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeOpenPRs(t *testing.T) {
+	var inFlight, maxInFlight int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != "open" {
+			t.Errorf("list state = %q, want open", r.URL.Query().Get("state"))
+		}
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"number":3,"user":{"login":"author"}},{"number":4,"user":{"login":"author"}}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s?state=open&page=2>; rel="next"`, r.URL.Path))
+		fmt.Fprint(w, `[{"number":1,"user":{"login":"author"}},{"number":2,"user":{"login":"author"}}]`)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/reviews") {
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				observed := atomic.LoadInt32(&maxInFlight)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		} else if !strings.Contains(strings.TrimPrefix(r.URL.Path, "/repos/org/repo/pulls/"), "/") {
+			t.Errorf("PR %s was re-fetched, want the listed object reused", r.URL.Path)
+		}
+		fmt.Fprint(w, "[]")
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	results, err := analyzer.AnalyzeOpenPRs(context.Background(), "org", "repo", 2)
+	if err != nil {
+		t.Fatalf("AnalyzeOpenPRs() error = %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("AnalyzeOpenPRs() returned %d results, want 4 across both pages", len(results))
+	}
+	for i, details := range results {
+		if details == nil || details.PRNumber != i+1 {
+			t.Errorf("AnalyzeOpenPRs()[%d] = %v, want PR %d", i, details, i+1)
+		}
+	}
+	if max := atomic.LoadInt32(&maxInFlight); max > 2 {
+		t.Errorf("AnalyzeOpenPRs() ran %d PRs at once, want at most 2", max)
+	}
+}
+
+func TestAnalyzeSearch(t *testing.T) {
+	var queries []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("q"))
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"total_count":3,"items":[
+				{"number":7,"repository_url":"https://api.github.com/repos/org/other","pull_request":{"url":"x"}}
+			]}`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s?q=x&page=2>; rel="next"`, r.URL.Path))
+		fmt.Fprint(w, `{"total_count":3,"items":[
+			{"number":5,"repository_url":"https://api.github.com/repos/org/repo","pull_request":{"url":"x"}},
+			{"number":3,"repository_url":"https://api.github.com/repos/org/repo","pull_request":{"url":"x"}}
+		]}`)
+	})
+	for _, repo := range []string{"repo", "other"} {
+		prefix := "/repos/org/" + repo + "/pulls/"
+		mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+			number := strings.TrimPrefix(r.URL.Path, prefix)
+			if strings.Contains(number, "/") {
+				fmt.Fprint(w, "[]")
+				return
+			}
+			fmt.Fprintf(w, `{"number":%s,"user":{"login":"author"}}`, number)
+		})
+	}
+	analyzer := newTestAnalyzer(t, mux)
+
+	results, err := analyzer.AnalyzeSearch(context.Background(), "is:pr is:merged label:foo", 2)
+	if err != nil {
+		t.Fatalf("AnalyzeSearch() error = %v", err)
+	}
+
+	expected := []struct {
+		repo   string
+		number int
+	}{{"repo", 5}, {"repo", 3}, {"other", 7}}
+	if len(results) != len(expected) {
+		t.Fatalf("AnalyzeSearch() returned %d results, want %d across both pages", len(results), len(expected))
+	}
+	for i, want := range expected {
+		if results[i] == nil || results[i].RepositoryName != want.repo || results[i].PRNumber != want.number {
+			t.Errorf("AnalyzeSearch()[%d] = %v, want org/%s#%d", i, results[i], want.repo, want.number)
+		}
+	}
+	if len(queries) != 2 || queries[0] != "is:pr is:merged label:foo" {
+		t.Errorf("search queries = %q, want the query on both pages", queries)
+	}
+}
+
+func TestAnalyzeSearch_MatchedIssue(t *testing.T) {
+	analyzed := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count":2,"items":[
+			{"number":1,"repository_url":"https://api.github.com/repos/org/repo","pull_request":{"url":"x"}},
+			{"number":2,"title":"Bug report","repository_url":"https://api.github.com/repos/org/repo"}
+		]}`)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/", func(w http.ResponseWriter, r *http.Request) {
+		analyzed = true
+		fmt.Fprint(w, "[]")
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	results, err := analyzer.AnalyzeSearch(context.Background(), "label:foo", 1)
+	if !errors.Is(err, ErrSearchMatchedIssue) {
+		t.Fatalf("AnalyzeSearch() error = %v, want ErrSearchMatchedIssue", err)
+	}
+	if !strings.Contains(err.Error(), "#2") {
+		t.Errorf("AnalyzeSearch() error = %v, want it to name issue #2", err)
+	}
+	if results != nil || analyzed {
+		t.Errorf("AnalyzeSearch() analyzed PRs despite matching an issue")
+	}
+}
+
+func TestRepoFromURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		expectedOrg  string
+		expectedRepo string
+		expectedOK   bool
+	}{
+		{"https://api.github.com/repos/org/repo", "org", "repo", true},
+		{"https://ghe.example.com/api/v3/repos/org/repo/", "org", "repo", true},
+		{"https://api.github.com/repos/org", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			org, repo, ok := repoFromURL(tt.url)
+			if org != tt.expectedOrg || repo != tt.expectedRepo || ok != tt.expectedOK {
+				t.Errorf("repoFromURL() = %q, %q, %v, want %q, %q, %v", org, repo, ok, tt.expectedOrg, tt.expectedRepo, tt.expectedOK)
+			}
+		})
+	}
+}
+
+func TestSortByLongestIdle(t *testing.T) {
+	details := []*PRDetails{
+		{PRNumber: 1, Metrics: &PRMetrics{LongestIdleHours: float64Ptr(5)}},
+		{PRNumber: 2},
+		nil,
+		{PRNumber: 3, Metrics: &PRMetrics{LongestIdleHours: float64Ptr(48)}},
+		{PRNumber: 4, Metrics: &PRMetrics{LongestIdleHours: float64Ptr(12)}},
+	}
+
+	SortByLongestIdle(details)
+
+	expected := []int{3, 4, 1, 2}
+	for i, number := range expected {
+		if details[i] == nil || details[i].PRNumber != number {
+			t.Errorf("SortByLongestIdle()[%d] = %v, want PR %d", i, details[i], number)
+		}
+	}
+	if details[4] != nil {
+		t.Errorf("SortByLongestIdle()[4] = %v, want nil last", details[4])
+	}
+}
@@ -0,0 +1,128 @@
+package pullmetrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// AnalyzePRsWithCheckpoint works like AnalyzePRs but appends each completed result to the
+// JSON-lines file at checkpointPath as soon as it finishes. When the file already exists,
+// PRs of the same repository recorded in it are returned from the file instead of being
+// analyzed again, so a crashed or cancelled batch can be rerun with the same arguments to
+// resume where it stopped. Failed PRs aren't recorded and are retried on the next run.
+//
+// A final line left incomplete by a crash is discarded. Errors writing the checkpoint are
+// returned joined with the batch's errors; the affected results are still returned.
+func (a *Analyzer) AnalyzePRsWithCheckpoint(ctx context.Context, org, repo string, prNumbers []int, checkpointPath string, concurrency int) ([]*PRDetails, error) {
+	completed, size, err := readCheckpoint(checkpointPath, org, repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, details := range completed {
+		details.compact = a.config.CompactOutput
+	}
+
+	results := make([]*PRDetails, len(prNumbers))
+	var pending []int
+	for i, prNumber := range prNumbers {
+		if details, ok := completed[prNumber]; ok {
+			results[i] = details
+			continue
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	checkpoint, err := openCheckpoint(checkpointPath, size)
+	if err != nil {
+		return nil, err
+	}
+	defer checkpoint.Close()
+
+	var (
+		mu        sync.Mutex
+		writeErrs []error
+	)
+	pendingNumbers := make([]int, len(pending))
+	for j, i := range pending {
+		pendingNumbers[j] = prNumbers[i]
+	}
+	analyzed, batchErr := runBatch(ctx, pendingNumbers, concurrency, a.config.BatchFailureThreshold, func(ctx context.Context, j int) (*PRDetails, error) {
+		details, err := a.AnalyzePR(ctx, org, repo, pendingNumbers[j])
+		if err != nil {
+			return nil, err
+		}
+
+		line, err := json.Marshal(details)
+		if err == nil {
+			mu.Lock()
+			_, err = checkpoint.Write(append(line, '\n'))
+			mu.Unlock()
+		}
+		if err != nil {
+			mu.Lock()
+			writeErrs = append(writeErrs, fmt.Errorf("writing checkpoint for PR #%d: %w", pendingNumbers[j], err))
+			mu.Unlock()
+		}
+		return details, nil
+	})
+
+	for j, i := range pending {
+		results[i] = analyzed[j]
+	}
+	return results, errors.Join(append([]error{batchErr}, writeErrs...)...)
+}
+
+// readCheckpoint returns the results recorded in the checkpoint file for org/repo, keyed
+// by PR number, and the length of the file up to the end of its last complete line. A
+// missing file has no results. Lines for other repositories are skipped.
+func readCheckpoint(path, org, repo string) (map[int]*PRDetails, int64, error) {
+	completed := make(map[int]*PRDetails)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return completed, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading checkpoint: %w", err)
+	}
+
+	// Anything after the last newline was cut short by a crash mid-write
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	for n, line := range bytes.Split(data[:complete], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var details PRDetails
+		if err := json.Unmarshal(line, &details); err != nil {
+			return nil, 0, fmt.Errorf("reading checkpoint: line %d: %w", n+1, err)
+		}
+		if !strings.EqualFold(details.OrganizationName, org) || !strings.EqualFold(details.RepositoryName, repo) {
+			continue
+		}
+		completed[details.PRNumber] = &details
+	}
+	return completed, int64(complete), nil
+}
+
+// openCheckpoint opens the checkpoint file for appending, creating it if needed, and cuts
+// it to size so that new records don't run into an incomplete line left by a crash.
+func openCheckpoint(path string, size int64) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening checkpoint: %w", err)
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("opening checkpoint: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,149 @@
+package pullmetrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// checkpointServer serves PRs 1 to 3 of org/repo, failing the numbers in failing, and
+// counts how often each PR is fetched
+type checkpointServer struct {
+	mu      sync.Mutex
+	fetches map[int]int
+	failing map[int]bool
+}
+
+func newCheckpointAnalyzer(t *testing.T, server *checkpointServer) *Analyzer {
+	t.Helper()
+	server.fetches = make(map[int]int)
+
+	mux := http.NewServeMux()
+	for _, number := range []int{1, 2, 3} {
+		number := number
+		mux.HandleFunc(fmt.Sprintf("/repos/org/repo/pulls/%d", number), func(w http.ResponseWriter, r *http.Request) {
+			server.mu.Lock()
+			server.fetches[number]++
+			failing := server.failing[number]
+			server.mu.Unlock()
+
+			if failing {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message":"Not Found"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"number":%d,"title":"PR %d","user":{"login":"author"}}`, number, number)
+		})
+	}
+	return newTestAnalyzer(t, mux)
+}
+
+func checkpointLines(t *testing.T, path string) []PRDetails {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	var lines []PRDetails
+	for _, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		var details PRDetails
+		if err := json.Unmarshal(line, &details); err != nil {
+			t.Fatalf("checkpoint line %q is not valid JSON: %v", line, err)
+		}
+		lines = append(lines, details)
+	}
+	return lines
+}
+
+func TestAnalyzePRsWithCheckpoint_Resume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+
+	// First run: PR 2 fails, as if the process died before it could be analyzed
+	first := &checkpointServer{failing: map[int]bool{2: true}}
+	results, err := newCheckpointAnalyzer(t, first).AnalyzePRsWithCheckpoint(context.Background(), "org", "repo", []int{1, 2, 3}, path, 2)
+	if err == nil || !strings.Contains(err.Error(), "PR #2") {
+		t.Errorf("AnalyzePRsWithCheckpoint() error = %v, want error mentioning PR #2", err)
+	}
+	if results[0] == nil || results[1] != nil || results[2] == nil {
+		t.Fatalf("AnalyzePRsWithCheckpoint() = %v, want PRs 1 and 3 only", results)
+	}
+	if lines := checkpointLines(t, path); len(lines) != 2 {
+		t.Fatalf("checkpoint has %d lines after first run, want 2", len(lines))
+	}
+
+	// Second run: only PR 2 is fetched, the others come from the checkpoint
+	second := &checkpointServer{}
+	results, err = newCheckpointAnalyzer(t, second).AnalyzePRsWithCheckpoint(context.Background(), "org", "repo", []int{1, 2, 3}, path, 2)
+	if err != nil {
+		t.Fatalf("AnalyzePRsWithCheckpoint() resumed error = %v", err)
+	}
+	if second.fetches[1] != 0 || second.fetches[3] != 0 || second.fetches[2] != 1 {
+		t.Errorf("resumed run fetched %v, want only PR 2 once", second.fetches)
+	}
+	for i, want := range []int{1, 2, 3} {
+		if results[i] == nil || results[i].PRNumber != want || results[i].PRTitle != fmt.Sprintf("PR %d", want) {
+			t.Errorf("AnalyzePRsWithCheckpoint()[%d] = %v, want PR %d", i, results[i], want)
+		}
+	}
+	if lines := checkpointLines(t, path); len(lines) != 3 {
+		t.Errorf("checkpoint has %d lines after resumed run, want 3", len(lines))
+	}
+
+	// Third run: everything is already done
+	third := &checkpointServer{}
+	if _, err := newCheckpointAnalyzer(t, third).AnalyzePRsWithCheckpoint(context.Background(), "org", "repo", []int{3, 1}, path, 1); err != nil {
+		t.Fatalf("AnalyzePRsWithCheckpoint() completed error = %v", err)
+	}
+	if len(third.fetches) != 0 {
+		t.Errorf("completed run fetched %v, want nothing", third.fetches)
+	}
+}
+
+func TestAnalyzePRsWithCheckpoint_DiscardsIncompleteLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	complete := `{"organization_name":"Org","repository_name":"repo","pr_number":1,"pr_title":"PR 1"}`
+	otherRepo := `{"organization_name":"org","repository_name":"other","pr_number":2,"pr_title":"Other 2"}`
+	partial := `{"organization_name":"org","repository_name":"repo","pr_number":3,"pr_ti`
+	if err := os.WriteFile(path, []byte(complete+"\n"+otherRepo+"\n"+partial), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	server := &checkpointServer{}
+	results, err := newCheckpointAnalyzer(t, server).AnalyzePRsWithCheckpoint(context.Background(), "org", "repo", []int{1, 2, 3}, path, 1)
+	if err != nil {
+		t.Fatalf("AnalyzePRsWithCheckpoint() error = %v", err)
+	}
+	if server.fetches[1] != 0 || server.fetches[2] != 1 || server.fetches[3] != 1 {
+		t.Errorf("AnalyzePRsWithCheckpoint() fetched %v, want PRs 2 and 3", server.fetches)
+	}
+	if results[1] == nil || results[1].PRTitle != "PR 2" {
+		t.Errorf("AnalyzePRsWithCheckpoint()[1] = %v, want PR 2 of org/repo rather than org/other", results[1])
+	}
+
+	lines := checkpointLines(t, path)
+	if len(lines) != 4 || lines[2].PRNumber+lines[3].PRNumber != 5 {
+		t.Errorf("checkpoint = %v, want the incomplete line replaced by PRs 2 and 3", lines)
+	}
+}
+
+func TestAnalyzePRsWithCheckpoint_CorruptCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	if err := os.WriteFile(path, []byte("not json\n{}\n"), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	server := &checkpointServer{}
+	if _, err := newCheckpointAnalyzer(t, server).AnalyzePRsWithCheckpoint(context.Background(), "org", "repo", []int{1}, path, 1); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("AnalyzePRsWithCheckpoint() error = %v, want error for line 1", err)
+	}
+	if len(server.fetches) != 0 {
+		t.Errorf("AnalyzePRsWithCheckpoint() fetched %v after a corrupt checkpoint, want nothing", server.fetches)
+	}
+}
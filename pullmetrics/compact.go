@@ -0,0 +1,97 @@
+package pullmetrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// prDetailsJSON has the fields of PRDetails without its MarshalJSON method
+type prDetailsJSON PRDetails
+
+// MarshalJSON writes the full set of fields unless the details were produced with
+// Config.CompactOutput, in which case empty arrays and maps are left out. Numbers are
+// always kept, since a count or duration of zero is a real measurement.
+func (d PRDetails) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(prDetailsJSON(d))
+	if err != nil || !d.compact {
+		return data, err
+	}
+	return compactJSON(data, reflect.TypeOf(PRDetails{}))
+}
+
+// compactJSON drops the members of a JSON object encoded from a struct of type t whose
+// fields are slices or maps that are empty. Members holding nested structs are compacted
+// the same way. Member order is preserved.
+func compactJSON(data []byte, t reflect.Type) ([]byte, error) {
+	droppable := make(map[string]bool)
+	nested := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonFieldName(field)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Struct {
+			nested[name] = fieldType.Elem()
+			continue
+		}
+		switch fieldType.Kind() {
+		case reflect.Slice, reflect.Map:
+			droppable[name] = true
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("compact output: expected a JSON object")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("compact output: %w", err)
+		}
+		name, _ := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("compact output: %w", err)
+		}
+
+		if droppable[name] && isEmptyJSONValue(value) {
+			continue
+		}
+		if nestedType, ok := nested[name]; ok && bytes.HasPrefix(value, []byte("{")) {
+			if value, err = compactJSON(value, nestedType); err != nil {
+				return nil, err
+			}
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, fmt.Errorf("compact output: %w", err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// isEmptyJSONValue reports whether value is null or an empty array or object
+func isEmptyJSONValue(value json.RawMessage) bool {
+	switch string(value) {
+	case "null", "[]", "{}":
+		return true
+	}
+	return false
+}
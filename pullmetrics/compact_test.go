@@ -0,0 +1,96 @@
+package pullmetrics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPRDetailsMarshalJSON_Compact(t *testing.T) {
+	deviation := 0
+	newDetails := func(compact bool) *PRDetails {
+		return &PRDetails{
+			OrganizationName:       "org",
+			RepositoryName:         "repo",
+			PRNumber:               12,
+			ApproverUsernames:      []string{},
+			CommenterUsernames:     []string{"user1"},
+			NumComments:            0,
+			NumCommenters:          1,
+			ReviewerCountDeviation: &deviation,
+			Metrics: &PRMetrics{
+				DraftTimeHours:         0,
+				TimeToFirstReviewHours: float64Ptr(1.5),
+			},
+			GeneratedAt: "2023-01-15T10:00:00Z",
+			compact:     compact,
+		}
+	}
+
+	full, err := json.Marshal(newDetails(false))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	compact, err := json.Marshal(newDetails(true))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	var fullFields, compactFields map[string]interface{}
+	if err := json.Unmarshal(full, &fullFields); err != nil {
+		t.Fatalf("json.Unmarshal(full) error = %v", err)
+	}
+	if err := json.Unmarshal(compact, &compactFields); err != nil {
+		t.Fatalf("json.Unmarshal(compact) error = %v", err)
+	}
+
+	for _, key := range []string{"approver_usernames", "assignees"} {
+		if _, ok := fullFields[key]; !ok {
+			t.Errorf("full output missing %q", key)
+		}
+		if _, ok := compactFields[key]; ok {
+			t.Errorf("compact output should not contain %q", key)
+		}
+	}
+	for _, key := range []string{"organization_name", "pr_number", "commenter_usernames", "num_commenters", "reviewer_count_deviation", "generated_at", "release_searched", "num_comments", "num_approvers", "change_requests_count", "engagement_index"} {
+		if _, ok := compactFields[key]; !ok {
+			t.Errorf("compact output missing %q", key)
+		}
+	}
+	// Zero counts are measurements, not missing values
+	for _, key := range []string{"reviewer_count_deviation", "num_comments", "num_approvers", "change_requests_count", "engagement_index"} {
+		if compactFields[key] != 0.0 {
+			t.Errorf("compact output %s = %v, want 0", key, compactFields[key])
+		}
+	}
+
+	metrics, ok := compactFields["metrics"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("compact output metrics = %v, want object", compactFields["metrics"])
+	}
+	if metrics["draft_time_hours"] != 0.0 {
+		t.Errorf("compact output metrics.draft_time_hours = %v, want 0", metrics["draft_time_hours"])
+	}
+	if metrics["time_to_first_review_hours"] != 1.5 {
+		t.Errorf("compact output metrics.time_to_first_review_hours = %v, want 1.5", metrics["time_to_first_review_hours"])
+	}
+
+	if len(compact) >= len(full) {
+		t.Errorf("compact output is %d bytes, want fewer than full output's %d", len(compact), len(full))
+	}
+	if !strings.HasPrefix(string(compact), `{"organization_name":"org","repository_name":"repo"`) {
+		t.Errorf("compact output doesn't keep field order: %s", compact)
+	}
+}
+
+func TestPRDetailsMarshalJSON_FullByDefault(t *testing.T) {
+	details := &PRDetails{ApproverUsernames: []string{}}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"approver_usernames":[]`) || !strings.Contains(string(data), `"num_approvers":0`) {
+		t.Errorf("json.Marshal() = %s, want empty and zero fields present", data)
+	}
+}
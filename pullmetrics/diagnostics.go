@@ -0,0 +1,86 @@
+package pullmetrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// diagnosticsKey is the context key for the diagnosticsRecorder of an analysis
+type diagnosticsKey struct{}
+
+// diagnosticsRecorder accumulates the API usage of a single analysis. Requests find it
+// through their context, so concurrent analyses sharing an Analyzer are kept apart.
+type diagnosticsRecorder struct {
+	mu                 sync.Mutex
+	calls              int
+	elapsed            time.Duration
+	rateLimitRemaining *int
+}
+
+// withDiagnostics attaches a recorder to ctx when Config.IncludeDiagnostics is set. A
+// context that already carries one is returned unchanged, so the calls an entry point
+// makes before handing over to analyze are counted once.
+func (a *Analyzer) withDiagnostics(ctx context.Context) context.Context {
+	if !a.config.IncludeDiagnostics || diagnosticsFromContext(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, diagnosticsKey{}, &diagnosticsRecorder{})
+}
+
+func diagnosticsFromContext(ctx context.Context) *diagnosticsRecorder {
+	recorder, _ := ctx.Value(diagnosticsKey{}).(*diagnosticsRecorder)
+	return recorder
+}
+
+func (r *diagnosticsRecorder) record(elapsed time.Duration, resp *http.Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+	r.elapsed += elapsed
+	if resp == nil {
+		return
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		r.rateLimitRemaining = &remaining
+	}
+}
+
+func (r *diagnosticsRecorder) snapshot() *AnalysisDiagnostics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &AnalysisDiagnostics{
+		APICalls:           r.calls,
+		APITimeSeconds:     r.elapsed.Seconds(),
+		RateLimitRemaining: r.rateLimitRemaining,
+	}
+}
+
+// diagnosticsTransport records every request made on behalf of an analysis whose context
+// carries a diagnosticsRecorder. Other requests pass through untouched.
+type diagnosticsTransport struct {
+	base http.RoundTripper
+}
+
+func newDiagnosticsTransport(base http.RoundTripper) *diagnosticsTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &diagnosticsTransport{base: base}
+}
+
+func (t *diagnosticsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := diagnosticsFromContext(req.Context())
+	if recorder == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	recorder.record(time.Since(start), resp)
+	return resp, err
+}
@@ -0,0 +1,99 @@
+package pullmetrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// newDiagnosticsTestAnalyzer returns a test analyzer whose client records diagnostics, and
+// a counter of the requests the server received
+func newDiagnosticsTestAnalyzer(t *testing.T, config Config) (*Analyzer, *int32) {
+	t.Helper()
+
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(5000-n))
+		fmt.Fprint(w, `{"number":1,"state":"open","title":"ABC-1 Fix","user":{"login":"author"}}`)
+	})
+	mux.HandleFunc("/repos/org/repo/", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(5000-n))
+		fmt.Fprint(w, "[]")
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	analyzer.config = config
+
+	client := github.NewClient(&http.Client{Transport: newDiagnosticsTransport(nil)})
+	client.BaseURL = analyzer.client.BaseURL
+	analyzer.client = client
+	return analyzer, &requests
+}
+
+func TestAnalyzePR_Diagnostics(t *testing.T) {
+	analyzer, requests := newDiagnosticsTestAnalyzer(t, Config{IncludeDiagnostics: true})
+
+	details, err := analyzer.AnalyzePR(context.Background(), "org", "repo", 1)
+	if err != nil {
+		t.Fatalf("AnalyzePR() error = %v", err)
+	}
+	if details.Diagnostics == nil {
+		t.Fatal("AnalyzePR().Diagnostics = nil, want diagnostics")
+	}
+
+	// The PR, reviews, comments, review comments, timeline, files and commits
+	if details.Diagnostics.APICalls != 7 || int32(details.Diagnostics.APICalls) != atomic.LoadInt32(requests) {
+		t.Errorf("AnalyzePR().Diagnostics.APICalls = %d, want 7 matching the %d requests served", details.Diagnostics.APICalls, atomic.LoadInt32(requests))
+	}
+	if details.Diagnostics.APITimeSeconds <= 0 {
+		t.Errorf("AnalyzePR().Diagnostics.APITimeSeconds = %v, want > 0", details.Diagnostics.APITimeSeconds)
+	}
+	if remaining := details.Diagnostics.RateLimitRemaining; remaining == nil || *remaining != 4993 {
+		t.Errorf("AnalyzePR().Diagnostics.RateLimitRemaining = %v, want 4993 from the last response", remaining)
+	}
+}
+
+func TestAnalyzeFromPR_DiagnosticsPerAnalysis(t *testing.T) {
+	analyzer, _ := newDiagnosticsTestAnalyzer(t, Config{IncludeDiagnostics: true})
+	pr := &github.PullRequest{Number: intPtr(1), Title: stringPtr("ABC-1 Fix"), User: &github.User{Login: stringPtr("author")}}
+
+	for i := 0; i < 2; i++ {
+		details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+		if err != nil {
+			t.Fatalf("AnalyzeFromPR() error = %v", err)
+		}
+		// The PR itself isn't fetched, and counts don't carry over between analyses
+		if details.Diagnostics == nil || details.Diagnostics.APICalls != 6 {
+			t.Errorf("AnalyzeFromPR() run %d Diagnostics = %+v, want 6 API calls", i+1, details.Diagnostics)
+		}
+	}
+}
+
+func TestAnalyzePR_DiagnosticsDisabled(t *testing.T) {
+	analyzer, _ := newDiagnosticsTestAnalyzer(t, Config{})
+
+	details, err := analyzer.AnalyzePR(context.Background(), "org", "repo", 1)
+	if err != nil {
+		t.Fatalf("AnalyzePR() error = %v", err)
+	}
+	if details.Diagnostics != nil {
+		t.Errorf("AnalyzePR().Diagnostics = %+v, want nil when disabled", details.Diagnostics)
+	}
+}
+
+func TestDiagnosticsRecorder_NoRateLimitHeader(t *testing.T) {
+	recorder := &diagnosticsRecorder{}
+	recorder.record(0, &http.Response{Header: http.Header{}})
+	recorder.record(0, nil)
+
+	snapshot := recorder.snapshot()
+	if snapshot.APICalls != 2 || snapshot.APITimeSeconds != 0 || snapshot.RateLimitRemaining != nil {
+		t.Errorf("snapshot() = %+v, want 2 calls, no time and no rate limit", snapshot)
+	}
+}
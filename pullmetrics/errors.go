@@ -0,0 +1,56 @@
+package pullmetrics
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrRepoNotFound is returned when the repository doesn't exist. GitHub also returns
+	// not found for private repositories the token can't see.
+	ErrRepoNotFound = errors.New("repository not found")
+
+	// ErrNoAccess is returned when the token is valid but not permitted to read the repository
+	ErrNoAccess = errors.New("token does not have access to the repository")
+
+	// ErrInvalidToken is returned when GitHub rejects the token itself
+	ErrInvalidToken = errors.New("GitHub token is invalid or expired")
+
+	// ErrSSOAuthorizationRequired is returned when the token hasn't been authorized for an
+	// organization that enforces SAML single sign-on. The error is an *SSOAuthorizationError
+	// carrying the URL to authorize the token at.
+	ErrSSOAuthorizationRequired = errors.New("token is not authorized for the organization's SAML single sign-on")
+
+	// ErrBatchAborted is returned by the batch functions when Config.BatchFailureThreshold
+	// consecutive PRs failed with authorization errors
+	ErrBatchAborted = errors.New("batch aborted")
+
+	// ErrSearchMatchedIssue is returned by AnalyzeSearch when the query matches issues as
+	// well as pull requests. Adding is:pr to the query restricts it to pull requests.
+	ErrSearchMatchedIssue = errors.New("search matched an issue that is not a pull request")
+
+	// ErrJiraIssueMissing is returned when Config.RequireJiraIssue is set and the PR has no Jira issue
+	ErrJiraIssueMissing = errors.New("no Jira issue found")
+
+	// ErrPRStillOpen is returned when Config.RequireClosed is set and the PR hasn't been
+	// merged or closed yet
+	ErrPRStillOpen = errors.New("pull request is still open")
+)
+
+// SSOAuthorizationError reports a request rejected because the token isn't SSO-authorized.
+// It matches ErrSSOAuthorizationRequired with errors.Is.
+type SSOAuthorizationError struct {
+	// URL is where the token owner can authorize the token for the organization
+	URL string
+}
+
+func (e *SSOAuthorizationError) Error() string {
+	if e.URL == "" {
+		return ErrSSOAuthorizationRequired.Error()
+	}
+	return fmt.Sprintf("%v: authorize the token at %s", ErrSSOAuthorizationRequired, e.URL)
+}
+
+func (e *SSOAuthorizationError) Unwrap() error {
+	return ErrSSOAuthorizationRequired
+}
@@ -0,0 +1,227 @@
+package pullmetrics
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimePoint is a single time-series sample, compatible with Grafana-style charting
+type TimePoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// PRDetailsToTimeSeries converts a set of analyzed PRs into time-series points for one
+// metric. The metric is selected by its JSON name in the metrics object (e.g.
+// "review_cycle_time_hours"). Each point is placed at the PR's merge time; PRs that
+// weren't merged or have no value for the metric are skipped. Points are sorted by time.
+func PRDetailsToTimeSeries(details []*PRDetails, metric string) ([]TimePoint, error) {
+	fieldIndex := -1
+	metricsType := reflect.TypeOf(PRMetrics{})
+	for i := 0; i < metricsType.NumField(); i++ {
+		if metricsType.Field(i).IsExported() && jsonFieldName(metricsType.Field(i)) == metric {
+			fieldIndex = i
+			break
+		}
+	}
+	if fieldIndex < 0 {
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+
+	points := []TimePoint{}
+	for _, d := range details {
+		if d == nil || d.Metrics == nil || d.Timestamps == nil || d.Timestamps.MergedAt == nil {
+			continue
+		}
+		mergedAt, err := time.Parse(time.RFC3339, *d.Timestamps.MergedAt)
+		if err != nil {
+			continue
+		}
+
+		value := reflect.ValueOf(d.Metrics).Elem().Field(fieldIndex)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		if !value.CanFloat() {
+			return nil, fmt.Errorf("metric %q is not numeric", metric)
+		}
+
+		points = append(points, TimePoint{Timestamp: mergedAt.UTC(), Value: value.Float()})
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points, nil
+}
+
+// FieldChange describes one field that differs between two analyses of a PR. Field is
+// the dotted key used by PRDetailsToFlatMap; Old or New is nil when the field is absent
+// on that side.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// DiffPRDetails returns the fields that differ between baseline and current, sorted by
+// field name. generated_at is ignored since it changes on every analysis.
+func DiffPRDetails(baseline, current *PRDetails) []FieldChange {
+	oldFields := PRDetailsToFlatMap(baseline)
+	newFields := PRDetailsToFlatMap(current)
+
+	keys := make(map[string]bool, len(newFields))
+	for key := range oldFields {
+		keys[key] = true
+	}
+	for key := range newFields {
+		keys[key] = true
+	}
+	delete(keys, "generated_at")
+
+	changes := []FieldChange{}
+	for key := range keys {
+		oldValue, newValue := oldFields[key], newFields[key]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, FieldChange{Field: key, Old: oldValue, New: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// PRDetailsToFlatMap converts PR details into a single-level map for tools that can't
+// navigate nested JSON. Keys use the JSON field names; nested objects such as metrics
+// and timestamps are flattened into dotted keys (e.g. "metrics.draft_time_hours").
+// Nil pointers are omitted, and pointer values are dereferenced.
+func PRDetailsToFlatMap(details *PRDetails) map[string]interface{} {
+	result := make(map[string]interface{})
+	if details == nil {
+		return result
+	}
+	flattenStruct(reflect.ValueOf(details).Elem(), "", result)
+	return result
+}
+
+// MetricsMap returns every PR metric keyed by its JSON name (e.g. "time_to_first_review_hours")
+// so text templates can range over the metrics without reflection. Every metric key is
+// present; metrics that couldn't be calculated, or all metrics when the metrics object is
+// absent, map to nil. draft_time_hours is returned as a pointer like the other metrics.
+func (d *PRDetails) MetricsMap() map[string]*float64 {
+	t := reflect.TypeOf(PRMetrics{})
+	result := make(map[string]*float64, t.NumField())
+
+	var v reflect.Value
+	if d != nil && d.Metrics != nil {
+		v = reflect.ValueOf(d.Metrics).Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		name := jsonFieldName(t.Field(i))
+		result[name] = nil
+		if !v.IsValid() {
+			continue
+		}
+
+		value := v.Field(i)
+		switch value.Kind() {
+		case reflect.Ptr:
+			if !value.IsNil() {
+				metric := value.Elem().Float()
+				result[name] = &metric
+			}
+		case reflect.Float64:
+			metric := value.Float()
+			result[name] = &metric
+		}
+	}
+	return result
+}
+
+// MissingMetrics returns the JSON names of the optional metrics that are nil, in field
+// order, or nil when every metric is present. Metrics that can't apply to the analyzed PR
+// aren't reported: hours_since_last_update once it is merged or closed,
+// review_cycle_time_hours while it is open, last_commit_to_merge_hours unless it was
+// merged, approval_spread_hours with fewer than two approvals, actual_draft_time_hours
+// when it was never a draft and time_in_workflow_label_hours without
+// Config.WorkflowLabel. That is only known for metrics returned by the analysis; for
+// metrics built or decoded by the caller, every optional metric is expected. A nil
+// PRMetrics is missing every optional metric.
+func (m *PRMetrics) MissingMetrics() []string {
+	t := reflect.TypeOf(PRMetrics{})
+
+	var v reflect.Value
+	if m != nil {
+		v = reflect.ValueOf(m).Elem()
+	}
+	var missing []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.Ptr {
+			continue
+		}
+		name := jsonFieldName(field)
+		if v.IsValid() && (!v.Field(i).IsNil() || m.inapplicable[name]) {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	return missing
+}
+
+// IsComplete reports whether every metric that applies to the PR was calculated, so
+// consumers can skip PRs whose data is partial. See MissingMetrics for the metrics that
+// aren't.
+func (m *PRMetrics) IsComplete() bool {
+	return m != nil && len(m.MissingMetrics()) == 0
+}
+
+func flattenStruct(v reflect.Value, prefix string, result map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+		key := prefix + name
+
+		value := v.Field(i)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+
+		if value.Kind() == reflect.Struct {
+			flattenStruct(value, key+".", result)
+			continue
+		}
+		result[key] = value.Interface()
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,299 @@
+package pullmetrics
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPRDetailsToFlatMap(t *testing.T) {
+	reviewHours := 1.5
+	details := &PRDetails{
+		OrganizationName:  "org",
+		PRNumber:          12,
+		ApproverUsernames: []string{"user1"},
+		Metrics: &PRMetrics{
+			DraftTimeHours:         0.5,
+			TimeToFirstReviewHours: &reviewHours,
+		},
+		Timestamps: &PRTimestamps{
+			CreatedAt: stringPtr("2023-01-15T10:00:00Z"),
+		},
+	}
+
+	flat := PRDetailsToFlatMap(details)
+
+	expected := map[string]interface{}{
+		"organization_name":                  "org",
+		"pr_number":                          12,
+		"metrics.draft_time_hours":           0.5,
+		"metrics.time_to_first_review_hours": 1.5,
+		"timestamps.created_at":              "2023-01-15T10:00:00Z",
+	}
+	for key, want := range expected {
+		got, ok := flat[key]
+		if !ok {
+			t.Errorf("PRDetailsToFlatMap() missing key %q", key)
+			continue
+		}
+		if got != want {
+			t.Errorf("PRDetailsToFlatMap()[%q] = %v, want %v", key, got, want)
+		}
+	}
+
+	for _, key := range []string{
+		"metrics",
+		"timestamps",
+		"release_name",
+		"metrics.review_cycle_time_hours",
+		"timestamps.merged_at",
+	} {
+		if _, ok := flat[key]; ok {
+			t.Errorf("PRDetailsToFlatMap() should not contain key %q", key)
+		}
+	}
+
+	if approvers, ok := flat["approver_usernames"].([]string); !ok || len(approvers) != 1 {
+		t.Errorf("PRDetailsToFlatMap()[approver_usernames] = %v, want [user1]", flat["approver_usernames"])
+	}
+}
+
+func TestPRDetailsToFlatMap_NilDetails(t *testing.T) {
+	if flat := PRDetailsToFlatMap(nil); len(flat) != 0 {
+		t.Errorf("PRDetailsToFlatMap(nil) = %v, want empty map", flat)
+	}
+}
+
+// metricNames returns the JSON names of the exported PRMetrics fields
+func metricNames() []string {
+	var names []string
+	metricsType := reflect.TypeOf(PRMetrics{})
+	for i := 0; i < metricsType.NumField(); i++ {
+		if metricsType.Field(i).IsExported() {
+			names = append(names, jsonFieldName(metricsType.Field(i)))
+		}
+	}
+	return names
+}
+
+func TestPRDetailsMetricsMap(t *testing.T) {
+	reviewHours := 1.5
+	details := &PRDetails{
+		Metrics: &PRMetrics{
+			DraftTimeHours:         0.5,
+			TimeToFirstReviewHours: &reviewHours,
+		},
+	}
+
+	metrics := details.MetricsMap()
+
+	names := metricNames()
+	if len(metrics) != len(names) {
+		t.Errorf("MetricsMap() has %d keys, want %d", len(metrics), len(names))
+	}
+	for _, name := range names {
+		if _, ok := metrics[name]; !ok {
+			t.Errorf("MetricsMap() missing key %q", name)
+		}
+	}
+
+	assertFloat64Ptr(t, "draft_time_hours", metrics["draft_time_hours"], float64Ptr(0.5))
+	assertFloat64Ptr(t, "time_to_first_review_hours", metrics["time_to_first_review_hours"], float64Ptr(1.5))
+	assertFloat64Ptr(t, "review_cycle_time_hours", metrics["review_cycle_time_hours"], nil)
+
+	// Values are copies, so templates can't modify the details
+	*metrics["time_to_first_review_hours"] = 3
+	if *details.Metrics.TimeToFirstReviewHours != 1.5 {
+		t.Errorf("MetricsMap() returned a pointer into the metrics")
+	}
+}
+
+func TestPRDetailsMetricsMap_NoMetrics(t *testing.T) {
+	for _, details := range []*PRDetails{nil, {}} {
+		metrics := details.MetricsMap()
+		if len(metrics) != len(metricNames()) {
+			t.Errorf("MetricsMap() has %d keys, want every metric", len(metrics))
+		}
+		for name, value := range metrics {
+			if value != nil {
+				t.Errorf("MetricsMap()[%q] = %v, want nil", name, *value)
+			}
+		}
+	}
+}
+
+func TestPRMetricsMissingMetrics(t *testing.T) {
+	// Every pointer metric set
+	complete := &PRMetrics{}
+	v := reflect.ValueOf(complete).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Kind() == reflect.Ptr && v.Field(i).CanSet() {
+			v.Field(i).Set(reflect.ValueOf(float64Ptr(1)))
+		}
+	}
+
+	partial := *complete
+	partial.TimeToFirstReviewHours = nil
+	partial.HoursSinceLastUpdate = nil
+
+	tests := []struct {
+		name     string
+		metrics  *PRMetrics
+		complete bool
+		missing  []string
+	}{
+		{
+			name:     "fully populated",
+			metrics:  complete,
+			complete: true,
+			missing:  nil,
+		},
+		{
+			name:     "partially populated",
+			metrics:  &partial,
+			complete: false,
+			missing:  []string{"time_to_first_review_hours", "hours_since_last_update"},
+		},
+		{
+			name:     "draft time alone",
+			metrics:  &PRMetrics{DraftTimeHours: 2},
+			complete: false,
+		},
+		{
+			name:     "nil metrics",
+			metrics:  nil,
+			complete: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.metrics.IsComplete(); got != tt.complete {
+				t.Errorf("IsComplete() = %v, want %v", got, tt.complete)
+			}
+			missing := tt.metrics.MissingMetrics()
+			if tt.missing != nil || tt.complete {
+				if !reflect.DeepEqual(missing, tt.missing) {
+					t.Errorf("MissingMetrics() = %v, want %v", missing, tt.missing)
+				}
+				return
+			}
+			// Without any pointer metric set, every metric but draft_time_hours is missing
+			if len(missing) != len(metricNames())-1 || slices.Contains(missing, "draft_time_hours") {
+				t.Errorf("MissingMetrics() = %v, want every optional metric", missing)
+			}
+		})
+	}
+}
+
+func TestPRDetailsToTimeSeries(t *testing.T) {
+	details := []*PRDetails{
+		{
+			Metrics:    &PRMetrics{DraftTimeHours: 1, ReviewCycleTimeHours: float64Ptr(24)},
+			Timestamps: &PRTimestamps{MergedAt: stringPtr("2023-01-20T12:00:00Z")},
+		},
+		{
+			Metrics:    &PRMetrics{DraftTimeHours: 2, ReviewCycleTimeHours: float64Ptr(6)},
+			Timestamps: &PRTimestamps{MergedAt: stringPtr("2023-01-15T12:00:00-05:00")},
+		},
+		{
+			// Not merged
+			Metrics:    &PRMetrics{DraftTimeHours: 3, ReviewCycleTimeHours: float64Ptr(12)},
+			Timestamps: &PRTimestamps{},
+		},
+		{
+			// Merged without a review cycle time
+			Metrics:    &PRMetrics{DraftTimeHours: 4},
+			Timestamps: &PRTimestamps{MergedAt: stringPtr("2023-01-18T12:00:00Z")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		metric   string
+		expected []TimePoint
+	}{
+		{
+			name:   "pointer metric skips nil values",
+			metric: "review_cycle_time_hours",
+			expected: []TimePoint{
+				{Timestamp: time.Date(2023, 1, 15, 17, 0, 0, 0, time.UTC), Value: 6},
+				{Timestamp: time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC), Value: 24},
+			},
+		},
+		{
+			name:   "non-pointer metric",
+			metric: "draft_time_hours",
+			expected: []TimePoint{
+				{Timestamp: time.Date(2023, 1, 15, 17, 0, 0, 0, time.UTC), Value: 2},
+				{Timestamp: time.Date(2023, 1, 18, 12, 0, 0, 0, time.UTC), Value: 4},
+				{Timestamp: time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC), Value: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := PRDetailsToTimeSeries(details, tt.metric)
+			if err != nil {
+				t.Fatalf("PRDetailsToTimeSeries() error = %v", err)
+			}
+			if len(points) != len(tt.expected) {
+				t.Fatalf("PRDetailsToTimeSeries() = %v, want %v", points, tt.expected)
+			}
+			for i := range points {
+				if !points[i].Timestamp.Equal(tt.expected[i].Timestamp) || points[i].Value != tt.expected[i].Value {
+					t.Errorf("PRDetailsToTimeSeries()[%d] = %v, want %v", i, points[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPRDetailsToTimeSeries_UnknownMetric(t *testing.T) {
+	if _, err := PRDetailsToTimeSeries(nil, "lines_of_fun"); err == nil {
+		t.Error("PRDetailsToTimeSeries() with unknown metric expected error, got nil")
+	}
+}
+
+func TestDiffPRDetails(t *testing.T) {
+	reviewHours := 2.0
+	baseline := &PRDetails{
+		PRNumber:          1,
+		State:             "open",
+		NumApprovers:      0,
+		ApproverUsernames: []string{},
+		Metrics:           &PRMetrics{DraftTimeHours: 1.0},
+		GeneratedAt:       "2024-01-01T10:00:00Z",
+	}
+	current := &PRDetails{
+		PRNumber:          1,
+		State:             "merged",
+		NumApprovers:      1,
+		ApproverUsernames: []string{"user1"},
+		Metrics:           &PRMetrics{DraftTimeHours: 1.0, ReviewCycleTimeHours: &reviewHours},
+		GeneratedAt:       "2024-01-02T10:00:00Z",
+	}
+
+	changes := DiffPRDetails(baseline, current)
+
+	expected := []FieldChange{
+		{Field: "approver_usernames", Old: []string{}, New: []string{"user1"}},
+		{Field: "metrics.review_cycle_time_hours", Old: nil, New: 2.0},
+		{Field: "num_approvers", Old: 0, New: 1},
+		{Field: "state", Old: "open", New: "merged"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("DiffPRDetails() returned %d changes, want %d: %+v", len(changes), len(expected), changes)
+	}
+	for i, change := range changes {
+		if !reflect.DeepEqual(change, expected[i]) {
+			t.Errorf("DiffPRDetails()[%d] = %+v, want %+v", i, change, expected[i])
+		}
+	}
+
+	if changes := DiffPRDetails(current, current); len(changes) != 0 {
+		t.Errorf("DiffPRDetails() of identical details = %+v, want none", changes)
+	}
+}
@@ -0,0 +1,214 @@
+package pullmetrics
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// The REST API does not expose review thread resolution or Projects (v2) items, so
+// that data is read from the GraphQL API. See graphQLEndpoint for how its URL is derived
+// from the REST base URL.
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+// reviewThread holds the resolution state of a single review comment thread
+type reviewThread struct {
+	IsResolved bool
+	ResolvedBy string
+}
+
+const reviewThreadsQuery = `query($owner: String!, $repo: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        nodes {
+          isResolved
+          resolvedBy { login }
+        }
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+      }
+    }
+  }
+}`
+
+type reviewThreadsResponse struct {
+	Data struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewThreads struct {
+					Nodes []struct {
+						IsResolved bool `json:"isResolved"`
+						ResolvedBy *struct {
+							Login string `json:"login"`
+						} `json:"resolvedBy"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+// graphQLEndpoint returns the GraphQL API URL for a REST base URL. GitHub Enterprise
+// Server serves REST under /api/v3/ and GraphQL at /api/graphql; elsewhere, as on
+// github.com (https://api.github.com/graphql), GraphQL sits next to the REST root.
+func graphQLEndpoint(baseURL *url.URL) string {
+	endpoint := *baseURL
+	if prefix, found := strings.CutSuffix(endpoint.Path, "/api/v3/"); found {
+		endpoint.Path = prefix + "/api/graphql"
+		return endpoint.String()
+	}
+	return endpoint.ResolveReference(&url.URL{Path: "graphql"}).String()
+}
+
+func (a *Analyzer) doGraphQL(ctx context.Context, query string, variables map[string]interface{}, v interface{}) error {
+	client := a.apiClient()
+	req, err := client.NewRequest("POST", graphQLEndpoint(client.BaseURL), &graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+	_, err = client.Do(ctx, req, v)
+	return err
+}
+
+func (a *Analyzer) fetchReviewThreads(ctx context.Context, org, repo string, prNumber int) ([]reviewThread, error) {
+	var allThreads []reviewThread
+	variables := map[string]interface{}{
+		"owner":  org,
+		"repo":   repo,
+		"number": prNumber,
+		"cursor": nil,
+	}
+
+	for {
+		var resp reviewThreadsResponse
+		if err := a.doGraphQL(ctx, reviewThreadsQuery, variables, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch review threads: %w", err)
+		}
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("failed to fetch review threads: %s", joinGraphQLErrors(resp.Errors))
+		}
+
+		threads := resp.Data.Repository.PullRequest.ReviewThreads
+		for _, node := range threads.Nodes {
+			thread := reviewThread{IsResolved: node.IsResolved}
+			if node.ResolvedBy != nil {
+				thread.ResolvedBy = node.ResolvedBy.Login
+			}
+			allThreads = append(allThreads, thread)
+		}
+
+		if !threads.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = threads.PageInfo.EndCursor
+	}
+
+	return allThreads, nil
+}
+
+const projectItemsQuery = `query($owner: String!, $repo: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      projectItems(first: 100, after: $cursor) {
+        nodes {
+          project { title }
+          fieldValueByName(name: "Status") {
+            ... on ProjectV2ItemFieldSingleSelectValue { name }
+          }
+        }
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+      }
+    }
+  }
+}`
+
+type projectItemsResponse struct {
+	Data struct {
+		Repository struct {
+			PullRequest struct {
+				ProjectItems struct {
+					Nodes []struct {
+						Project struct {
+							Title string `json:"title"`
+						} `json:"project"`
+						FieldValueByName *struct {
+							Name string `json:"name"`
+						} `json:"fieldValueByName"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"projectItems"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+// fetchProjectStatuses maps the title of each project the PR is on to the value of the
+// item's Status field, or an empty string when the item has no status. The map is empty
+// for PRs that aren't on any project.
+func (a *Analyzer) fetchProjectStatuses(ctx context.Context, org, repo string, prNumber int) (map[string]string, error) {
+	statuses := make(map[string]string)
+	variables := map[string]interface{}{
+		"owner":  org,
+		"repo":   repo,
+		"number": prNumber,
+		"cursor": nil,
+	}
+
+	for {
+		var resp projectItemsResponse
+		if err := a.doGraphQL(ctx, projectItemsQuery, variables, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch project items: %w", err)
+		}
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("failed to fetch project items: %s", joinGraphQLErrors(resp.Errors))
+		}
+
+		items := resp.Data.Repository.PullRequest.ProjectItems
+		for _, node := range items.Nodes {
+			status := ""
+			if node.FieldValueByName != nil {
+				status = node.FieldValueByName.Name
+			}
+			statuses[node.Project.Title] = status
+		}
+
+		if !items.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = items.PageInfo.EndCursor
+	}
+
+	return statuses, nil
+}
+
+func joinGraphQLErrors(errs []graphQLError) string {
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+	}
+	return strings.Join(messages, "; ")
+}
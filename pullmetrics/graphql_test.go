@@ -0,0 +1,259 @@
+package pullmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestCountThreadResolutions(t *testing.T) {
+	tests := []struct {
+		name               string
+		threads            []reviewThread
+		expectedByAuthor   int
+		expectedByReviewer int
+	}{
+		{
+			name: "mixed resolvers",
+			threads: []reviewThread{
+				{IsResolved: true, ResolvedBy: "author"},
+				{IsResolved: true, ResolvedBy: "reviewer1"},
+				{IsResolved: true, ResolvedBy: "reviewer2"},
+				{IsResolved: false},
+			},
+			expectedByAuthor:   1,
+			expectedByReviewer: 2,
+		},
+		{
+			name: "resolved thread with unknown resolver is skipped",
+			threads: []reviewThread{
+				{IsResolved: true, ResolvedBy: ""},
+				{IsResolved: true, ResolvedBy: "author"},
+			},
+			expectedByAuthor:   1,
+			expectedByReviewer: 0,
+		},
+		{
+			name:               "no threads",
+			threads:            nil,
+			expectedByAuthor:   0,
+			expectedByReviewer: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byAuthor, byReviewer := countThreadResolutions(tt.threads, "author")
+			if byAuthor != tt.expectedByAuthor {
+				t.Errorf("countThreadResolutions() byAuthor = %v, want %v", byAuthor, tt.expectedByAuthor)
+			}
+			if byReviewer != tt.expectedByReviewer {
+				t.Errorf("countThreadResolutions() byReviewer = %v, want %v", byReviewer, tt.expectedByReviewer)
+			}
+		})
+	}
+}
+
+func TestFetchReviewThreads_Pagination(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode GraphQL request: %v", err)
+		}
+		if req.Variables["cursor"] == nil {
+			fmt.Fprint(w, `{"data":{"repository":{"pullRequest":{"reviewThreads":{
+				"nodes":[{"isResolved":true,"resolvedBy":{"login":"author"}},{"isResolved":false,"resolvedBy":null}],
+				"pageInfo":{"hasNextPage":true,"endCursor":"c1"}}}}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"repository":{"pullRequest":{"reviewThreads":{
+			"nodes":[{"isResolved":true,"resolvedBy":{"login":"reviewer1"}}],
+			"pageInfo":{"hasNextPage":false,"endCursor":"c2"}}}}}}`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	threads, err := analyzer.fetchReviewThreads(context.Background(), "org", "repo", 1)
+	if err != nil {
+		t.Fatalf("fetchReviewThreads() error = %v", err)
+	}
+	if len(threads) != 3 {
+		t.Fatalf("fetchReviewThreads() returned %d threads, want 3", len(threads))
+	}
+	if threads[2].ResolvedBy != "reviewer1" {
+		t.Errorf("fetchReviewThreads()[2].ResolvedBy = %v, want reviewer1", threads[2].ResolvedBy)
+	}
+}
+
+func TestGraphQLEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		expected string
+	}{
+		{"github.com", "https://api.github.com/", "https://api.github.com/graphql"},
+		{"enterprise server", "https://github.example.com/api/v3/", "https://github.example.com/api/graphql"},
+		{"enterprise server under a path prefix", "https://example.com/github/api/v3/", "https://example.com/github/api/graphql"},
+		{"custom root", "http://127.0.0.1:8080/", "http://127.0.0.1:8080/graphql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseURL, err := url.Parse(tt.baseURL)
+			if err != nil {
+				t.Fatalf("url.Parse() error = %v", err)
+			}
+			if got := graphQLEndpoint(baseURL); got != tt.expected {
+				t.Errorf("graphQLEndpoint(%q) = %v, want %v", tt.baseURL, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFetchReviewThreads_EnterpriseBaseURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"repository":{"pullRequest":{"reviewThreads":{
+			"nodes":[{"isResolved":true,"resolvedBy":{"login":"author"}}],
+			"pageInfo":{"hasNextPage":false,"endCursor":"c1"}}}}}}`)
+	})
+	mux.HandleFunc("/api/v3/graphql", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("GraphQL request sent to the REST path /api/v3/graphql")
+		w.WriteHeader(http.StatusNotFound)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+	baseURL, err := analyzer.client.BaseURL.Parse("/api/v3/")
+	if err != nil {
+		t.Fatalf("failed to build Enterprise base URL: %v", err)
+	}
+	analyzer.client.BaseURL = baseURL
+
+	threads, err := analyzer.fetchReviewThreads(context.Background(), "org", "repo", 1)
+	if err != nil {
+		t.Fatalf("fetchReviewThreads() error = %v", err)
+	}
+	if len(threads) != 1 || threads[0].ResolvedBy != "author" {
+		t.Errorf("fetchReviewThreads() = %v, want one thread resolved by author", threads)
+	}
+}
+
+func TestFetchReviewThreads_GraphQLErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"errors":[{"message":"Resource not accessible by integration"}]}`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	if _, err := analyzer.fetchReviewThreads(context.Background(), "org", "repo", 1); err == nil {
+		t.Error("fetchReviewThreads() expected error, got nil")
+	}
+}
+
+func TestFetchProjectStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected map[string]string
+	}{
+		{
+			name: "items on several projects",
+			response: `{"data":{"repository":{"pullRequest":{"projectItems":{
+				"nodes":[
+					{"project":{"title":"Sprint Board"},"fieldValueByName":{"name":"In Review"}},
+					{"project":{"title":"Roadmap"},"fieldValueByName":{"name":"Done"}},
+					{"project":{"title":"Triage"},"fieldValueByName":null}
+				],
+				"pageInfo":{"hasNextPage":false,"endCursor":"c1"}}}}}}`,
+			expected: map[string]string{"Sprint Board": "In Review", "Roadmap": "Done", "Triage": ""},
+		},
+		{
+			name: "not on any board",
+			response: `{"data":{"repository":{"pullRequest":{"projectItems":{
+				"nodes":[],"pageInfo":{"hasNextPage":false,"endCursor":null}}}}}}`,
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.response)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+
+			statuses, err := analyzer.fetchProjectStatuses(context.Background(), "org", "repo", 1)
+			if err != nil {
+				t.Fatalf("fetchProjectStatuses() error = %v", err)
+			}
+			if len(statuses) != len(tt.expected) {
+				t.Fatalf("fetchProjectStatuses() = %v, want %v", statuses, tt.expected)
+			}
+			for project, status := range tt.expected {
+				if got, ok := statuses[project]; !ok || got != status {
+					t.Errorf("fetchProjectStatuses()[%q] = %q, want %q", project, got, status)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeFromPR_ProjectStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		response string
+		expected map[string]string
+	}{
+		{
+			name:   "statuses requested",
+			config: Config{IncludeProjectStatus: true},
+			response: `{"data":{"repository":{"pullRequest":{"projectItems":{
+				"nodes":[{"project":{"title":"Sprint Board"},"fieldValueByName":{"name":"In Review"}}],
+				"pageInfo":{"hasNextPage":false,"endCursor":"c1"}}}}}}`,
+			expected: map[string]string{"Sprint Board": "In Review"},
+		},
+		{
+			name:     "GraphQL errors omit the statuses",
+			config:   Config{IncludeProjectStatus: true},
+			response: `{"errors":[{"message":"Resource not accessible by integration"}]}`,
+			expected: nil,
+		},
+		{
+			name:     "statuses not requested",
+			config:   Config{},
+			response: `{"data":{}}`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.response)
+			})
+			analyzer := newTestAnalyzer(t, mux)
+			analyzer.config = tt.config
+
+			pr := &github.PullRequest{Number: intPtr(1), User: &github.User{Login: stringPtr("author")}}
+			details, err := analyzer.AnalyzeFromPR(context.Background(), "org", "repo", pr)
+			if err != nil {
+				t.Fatalf("AnalyzeFromPR() error = %v", err)
+			}
+			if len(details.ProjectStatuses) != len(tt.expected) {
+				t.Fatalf("AnalyzeFromPR().ProjectStatuses = %v, want %v", details.ProjectStatuses, tt.expected)
+			}
+			for project, status := range tt.expected {
+				if details.ProjectStatuses[project] != status {
+					t.Errorf("AnalyzeFromPR().ProjectStatuses[%q] = %q, want %q", project, details.ProjectStatuses[project], status)
+				}
+			}
+		})
+	}
+}
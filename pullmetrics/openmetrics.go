@@ -0,0 +1,134 @@
+package pullmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// openMetricsPrefix namespaces every metric family written by WriteOpenMetrics
+const openMetricsPrefix = "pullmetrics_"
+
+// openMetricsSample is one labelled value of a metric family
+type openMetricsSample struct {
+	details *PRDetails
+	value   float64
+}
+
+// openMetricsFamily is a gauge and its samples across PRs
+type openMetricsFamily struct {
+	name    string
+	help    string
+	samples []openMetricsSample
+}
+
+// WriteOpenMetrics writes the numeric fields of each PR in the OpenMetrics text
+// exposition format, one gauge family per field (e.g. pullmetrics_review_cycle_time_hours)
+// with organization, repository and pr_number labels. Count fields such as
+// lines_changed are included alongside the metrics object. Nil values and nil PRs are
+// skipped, and the output ends with "# EOF".
+func WriteOpenMetrics(w io.Writer, details []*PRDetails) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range collectOpenMetricsFamilies(details) {
+		fmt.Fprintf(bw, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", family.name)
+		for _, sample := range family.samples {
+			fmt.Fprintf(bw, "%s{organization=\"%s\",repository=\"%s\",pr_number=\"%d\"} %s\n",
+				family.name,
+				escapeLabelValue(sample.details.OrganizationName),
+				escapeLabelValue(sample.details.RepositoryName),
+				sample.details.PRNumber,
+				strconv.FormatFloat(sample.value, 'g', -1, 64))
+		}
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// collectOpenMetricsFamilies gathers the integer count fields of PRDetails and the
+// numeric fields of PRMetrics, keeping only families with at least one sample
+func collectOpenMetricsFamilies(details []*PRDetails) []openMetricsFamily {
+	var families []openMetricsFamily
+
+	detailsType := reflect.TypeOf(PRDetails{})
+	for i := 0; i < detailsType.NumField(); i++ {
+		field := detailsType.Field(i)
+		name := jsonFieldName(field)
+		if name == "pr_number" || !isNumericField(field.Type) {
+			continue
+		}
+		family := openMetricsFamily{name: openMetricsPrefix + name, help: "PR " + strings.ReplaceAll(name, "_", " ")}
+		for _, d := range details {
+			if d == nil {
+				continue
+			}
+			if value, ok := numericValue(reflect.ValueOf(d).Elem().Field(i)); ok {
+				family.samples = append(family.samples, openMetricsSample{details: d, value: value})
+			}
+		}
+		if len(family.samples) > 0 {
+			families = append(families, family)
+		}
+	}
+
+	metricsType := reflect.TypeOf(PRMetrics{})
+	for i := 0; i < metricsType.NumField(); i++ {
+		field := metricsType.Field(i)
+		if !isNumericField(field.Type) {
+			continue
+		}
+		name := jsonFieldName(field)
+		family := openMetricsFamily{name: openMetricsPrefix + name, help: "PR metric " + strings.ReplaceAll(name, "_", " ")}
+		for _, d := range details {
+			if d == nil || d.Metrics == nil {
+				continue
+			}
+			if value, ok := numericValue(reflect.ValueOf(d.Metrics).Elem().Field(i)); ok {
+				family.samples = append(family.samples, openMetricsSample{details: d, value: value})
+			}
+		}
+		if len(family.samples) > 0 {
+			families = append(families, family)
+		}
+	}
+
+	return families
+}
+
+// isNumericField reports whether t is an int or float, or a pointer to one
+func isNumericField(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// numericValue returns the value of a numeric field, or false for a nil pointer
+func numericValue(v reflect.Value) (float64, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// escapeLabelValue escapes a label value for the exposition format: backslash, double
+// quote and line feed are the only characters that need escaping
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,109 @@
+package pullmetrics
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var sampleLinePattern = regexp.MustCompile(`^([a-z_][a-z0-9_]*)\{organization="((?:[^"\\]|\\.)*)",repository="((?:[^"\\]|\\.)*)",pr_number="(\d+)"\} (\S+)$`)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	reviewHours := 2.5
+	details := []*PRDetails{
+		{
+			OrganizationName: "my-org.io",
+			RepositoryName:   `repo"with\quote`,
+			PRNumber:         12,
+			LinesChanged:     40,
+			Metrics:          &PRMetrics{DraftTimeHours: 0.5, ReviewCycleTimeHours: &reviewHours},
+		},
+		nil,
+		{
+			OrganizationName: "org",
+			RepositoryName:   "repo",
+			PRNumber:         13,
+			LinesChanged:     7,
+			Metrics:          &PRMetrics{DraftTimeHours: 1},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteOpenMetrics(&buf, details); err != nil {
+		t.Fatalf("WriteOpenMetrics() error = %v", err)
+	}
+	output := buf.String()
+
+	if !strings.HasSuffix(output, "# EOF\n") {
+		t.Errorf("WriteOpenMetrics() output does not end with # EOF:\n%s", output)
+	}
+
+	// Every line is a HELP/TYPE comment, a well-formed sample or the EOF marker, and
+	// each family is declared before its samples
+	declared := make(map[string]bool)
+	samples := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		switch {
+		case line == "# EOF":
+		case strings.HasPrefix(line, "# HELP "):
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(line)
+			if len(fields) != 4 || fields[3] != "gauge" {
+				t.Errorf("malformed TYPE line %q", line)
+				continue
+			}
+			declared[fields[2]] = true
+		default:
+			match := sampleLinePattern.FindStringSubmatch(line)
+			if match == nil {
+				t.Errorf("malformed sample line %q", line)
+				continue
+			}
+			if !declared[match[1]] {
+				t.Errorf("sample for %s before its TYPE line", match[1])
+			}
+			samples[match[1]+"/"+match[4]] = match[5]
+			if match[4] == "12" && match[3] != `repo\"with\\quote` {
+				t.Errorf("repository label = %q, want escaped value", match[3])
+			}
+		}
+	}
+
+	expected := map[string]string{
+		"pullmetrics_lines_changed/12":           "40",
+		"pullmetrics_lines_changed/13":           "7",
+		"pullmetrics_draft_time_hours/12":        "0.5",
+		"pullmetrics_draft_time_hours/13":        "1",
+		"pullmetrics_review_cycle_time_hours/12": "2.5",
+	}
+	for key, want := range expected {
+		if got := samples[key]; got != want {
+			t.Errorf("sample %s = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := samples["pullmetrics_review_cycle_time_hours/13"]; ok {
+		t.Error("nil metric was written as a sample")
+	}
+	if strings.Contains(output, "pullmetrics_pr_number") {
+		t.Error("pr_number was written as a metric, want it only as a label")
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"my-org.io", "my-org.io"},
+		{`a"b`, `a\"b`},
+		{`a\b`, `a\\b`},
+		{"a\nb", `a\nb`},
+	}
+
+	for _, tt := range tests {
+		if result := escapeLabelValue(tt.input); result != tt.expected {
+			t.Errorf("escapeLabelValue(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
@@ -29,6 +29,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+
+	"github.com/google/go-github/v66/github"
 )
 
 // AnalyzePRToJSON is a convenience function that analyzes a PR and returns JSON output
@@ -57,6 +59,30 @@ func AnalyzePRToJSONString(ctx context.Context, config Config, org, repo string,
 	if err != nil {
 		return "", err
 	}
-	
+
 	return string(jsonOutput), nil
-}
\ No newline at end of file
+}
+
+// AnalyzeFromFixtures analyzes a PR entirely from data the caller supplies, without any
+// network I/O, for offline or air-gapped analysis and for tests. It uses the default
+// Config, so optional lookups such as branch protection and user profiles are skipped.
+// The organization and repository names are taken from the PR's base repository. For a
+// merged PR the supplied releases are searched as with AnalyzePRWithReleases.
+func AnalyzeFromFixtures(pr *github.PullRequest, reviews []*github.PullRequestReview, comments []*github.IssueComment, reviewComments []*github.PullRequestComment, timeline []*github.Timeline, files []*github.CommitFile, commits []*github.RepositoryCommit, releases []*github.RepositoryRelease) (*PRDetails, error) {
+	analyzer := &Analyzer{}
+	org := pr.GetBase().GetRepo().GetOwner().GetLogin()
+	repo := pr.GetBase().GetRepo().GetName()
+
+	return analyzer.analyze(context.Background(), org, repo, pr, func(context.Context) (*prData, error) {
+		return &prData{
+			reviews:         reviews,
+			comments:        comments,
+			reviewComments:  reviewComments,
+			timeline:        timeline,
+			files:           files,
+			commits:         commits,
+			releases:        releases,
+			releaseSearched: isMerged(pr),
+		}, nil
+	})
+}
@@ -0,0 +1,142 @@
+package pullmetrics
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestAnalyzeFromFixtures(t *testing.T) {
+	created := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	at := func(hours float64) *github.Timestamp {
+		return timePtr(created.Add(time.Duration(hours * float64(time.Hour))))
+	}
+
+	pr := &github.PullRequest{
+		Number:    intPtr(42),
+		Title:     stringPtr("PROJ-123: Add caching"),
+		State:     stringPtr("closed"),
+		Merged:    boolPtr(true),
+		User:      &github.User{Login: stringPtr("author")},
+		CreatedAt: at(0),
+		MergedAt:  at(30),
+		ClosedAt:  at(30),
+		Base: &github.PullRequestBranch{
+			Ref: stringPtr("main"),
+			Repo: &github.Repository{
+				Name:  stringPtr("repo"),
+				Owner: &github.User{Login: stringPtr("org")},
+			},
+		},
+		Head: &github.PullRequestBranch{Ref: stringPtr("feature")},
+	}
+	reviews := []*github.PullRequestReview{
+		{User: &github.User{Login: stringPtr("reviewer1")}, State: stringPtr("CHANGES_REQUESTED"), SubmittedAt: at(4)},
+		{User: &github.User{Login: stringPtr("reviewer1")}, State: stringPtr("APPROVED"), SubmittedAt: at(26)},
+	}
+	comments := []*github.IssueComment{
+		{User: &github.User{Login: stringPtr("reviewer2")}, CreatedAt: at(3)},
+	}
+	reviewComments := []*github.PullRequestComment{
+		{ID: github.Int64(1), User: &github.User{Login: stringPtr("reviewer1")}, CreatedAt: at(4)},
+	}
+	timeline := []*github.Timeline{
+		reviewRequestEvent(EventReviewRequested, "reviewer1", created.Add(time.Hour)),
+	}
+	files := []*github.CommitFile{
+		{Filename: stringPtr("cache.go"), Additions: intPtr(40), Deletions: intPtr(10)},
+	}
+	commits := []*github.RepositoryCommit{
+		{Author: &github.User{Login: stringPtr("author")}, Commit: &github.Commit{Message: stringPtr("Add cache"), Author: &github.CommitAuthor{Date: at(0)}}},
+		{Author: &github.User{Login: stringPtr("author")}, Commit: &github.Commit{Message: stringPtr("Address review"), Author: &github.CommitAuthor{Date: at(24)}}},
+	}
+	releases := []*github.RepositoryRelease{
+		{Name: stringPtr("v1.2.0"), TagName: stringPtr("v1.2.0"), PublishedAt: at(48), CreatedAt: at(47)},
+	}
+
+	details, err := AnalyzeFromFixtures(pr, reviews, comments, reviewComments, timeline, files, commits, releases)
+	if err != nil {
+		t.Fatalf("AnalyzeFromFixtures() error = %v", err)
+	}
+
+	if details.OrganizationName != "org" || details.RepositoryName != "repo" || details.PRNumber != 42 {
+		t.Errorf("AnalyzeFromFixtures() identifies %s/%s#%d, want org/repo#42", details.OrganizationName, details.RepositoryName, details.PRNumber)
+	}
+	if details.State != "merged" {
+		t.Errorf("AnalyzeFromFixtures().State = %v, want merged", details.State)
+	}
+	if details.JiraIssue != "PROJ-123" {
+		t.Errorf("AnalyzeFromFixtures().JiraIssue = %v, want PROJ-123", details.JiraIssue)
+	}
+	if details.NumApprovers != 1 || details.ChangeRequestsCount != 1 || details.ChangeRequestResolutions != 1 {
+		t.Errorf("AnalyzeFromFixtures() approvers = %d, change requests = %d, resolutions = %d, want 1, 1, 1",
+			details.NumApprovers, details.ChangeRequestsCount, details.ChangeRequestResolutions)
+	}
+	if details.NumComments != 2 || details.NumCommenters != 2 {
+		t.Errorf("AnalyzeFromFixtures() comments = %d, commenters = %d, want 2, 2", details.NumComments, details.NumCommenters)
+	}
+	if details.LinesChanged != 50 || details.CommitsAfterFirstReview != 1 {
+		t.Errorf("AnalyzeFromFixtures() lines changed = %d, commits after first review = %d, want 50, 1", details.LinesChanged, details.CommitsAfterFirstReview)
+	}
+	if details.ReleaseName == nil || *details.ReleaseName != "v1.2.0" || !details.ReleaseSearched {
+		t.Errorf("AnalyzeFromFixtures().ReleaseName = %v (searched %v), want v1.2.0", details.ReleaseName, details.ReleaseSearched)
+	}
+	if details.Metrics == nil {
+		t.Fatal("AnalyzeFromFixtures().Metrics = nil, want metrics")
+	}
+	assertFloat64Ptr(t, "TimeToFirstReviewRequestHours", details.Metrics.TimeToFirstReviewRequestHours, float64Ptr(1))
+	assertFloat64Ptr(t, "TimeToFirstReviewHours", details.Metrics.TimeToFirstReviewHours, float64Ptr(2))
+
+	// A merged PR with one approval, no drafts and no workflow label has every metric that
+	// applies to it
+	if missing := details.Metrics.MissingMetrics(); missing != nil || !details.Metrics.IsComplete() {
+		t.Errorf("AnalyzeFromFixtures().Metrics.MissingMetrics() = %v, want none", missing)
+	}
+}
+
+func TestAnalyzeFromFixtures_OpenPRMissingMetrics(t *testing.T) {
+	created := time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)
+	pr := &github.PullRequest{
+		Number:    intPtr(43),
+		State:     stringPtr("open"),
+		User:      &github.User{Login: stringPtr("author")},
+		CreatedAt: timePtr(created),
+		UpdatedAt: timePtr(created.Add(2 * time.Hour)),
+		Base: &github.PullRequestBranch{
+			Ref: stringPtr("main"),
+			Repo: &github.Repository{
+				Name:  stringPtr("repo"),
+				Owner: &github.User{Login: stringPtr("org")},
+			},
+		},
+		Head: &github.PullRequestBranch{Ref: stringPtr("feature")},
+	}
+
+	details, err := AnalyzeFromFixtures(pr, nil, nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeFromFixtures() error = %v", err)
+	}
+
+	missing := details.Metrics.MissingMetrics()
+	if details.Metrics.IsComplete() {
+		t.Errorf("IsComplete() = true for an unreviewed PR, want false")
+	}
+	if !slices.Contains(missing, "time_to_first_review_hours") {
+		t.Errorf("MissingMetrics() = %v, want time_to_first_review_hours", missing)
+	}
+	// Metrics that need the PR to be merged, closed, approved twice, drafted or labelled
+	// don't apply to an open PR that was never any of those
+	for _, name := range []string{"review_cycle_time_hours", "last_commit_to_merge_hours", "approval_spread_hours", "actual_draft_time_hours", "time_in_workflow_label_hours"} {
+		if slices.Contains(missing, name) {
+			t.Errorf("MissingMetrics() = %v, shouldn't contain %s", missing, name)
+		}
+	}
+}
+
+func TestAnalyzeFromFixtures_NilPR(t *testing.T) {
+	if _, err := AnalyzeFromFixtures(nil, nil, nil, nil, nil, nil, nil, nil); err == nil {
+		t.Error("AnalyzeFromFixtures(nil) expected error, got nil")
+	}
+}
@@ -0,0 +1,122 @@
+package pullmetrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Renderer writes PR details to w in a particular output format
+type Renderer interface {
+	Render(w io.Writer, d *PRDetails) error
+}
+
+// RendererFunc adapts an ordinary function to the Renderer interface
+type RendererFunc func(w io.Writer, d *PRDetails) error
+
+// Render calls f(w, d)
+func (f RendererFunc) Render(w io.Writer, d *PRDetails) error {
+	return f(w, d)
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{
+		"json":     RendererFunc(renderJSON),
+		"csv":      RendererFunc(renderCSV),
+		"markdown": RendererFunc(renderMarkdown),
+	}
+)
+
+// RegisterRenderer makes a renderer available to RenderPR under name. Registering an
+// existing name, including a built-in one ("json", "csv", "markdown"), replaces it.
+func RegisterRenderer(name string, r Renderer) {
+	if r == nil {
+		panic("pullmetrics: RegisterRenderer renderer is nil")
+	}
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[name] = r
+}
+
+// RenderPR writes PR details to w using the renderer registered under name
+func RenderPR(name string, w io.Writer, d *PRDetails) error {
+	renderersMu.RLock()
+	r, ok := renderers[name]
+	renderersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown renderer %q", name)
+	}
+	return r.Render(w, d)
+}
+
+func renderJSON(w io.Writer, d *PRDetails) error {
+	return json.NewEncoder(w).Encode(d)
+}
+
+// renderCSV writes a header row of flattened field names followed by one row of values
+func renderCSV(w io.Writer, d *PRDetails) error {
+	keys, values := flatRows(d)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(keys); err != nil {
+		return err
+	}
+	if err := cw.Write(values); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// markdownLine joins line breaks into spaces so a value stays on one line of the output
+var markdownLine = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// renderMarkdown writes a two-column field/value table
+func renderMarkdown(w io.Writer, d *PRDetails) error {
+	if d == nil {
+		return fmt.Errorf("no PR details to render")
+	}
+	keys, values := flatRows(d)
+	var b strings.Builder
+	fmt.Fprintf(&b, "## PR #%d: %s\n\n", d.PRNumber, markdownLine.Replace(d.PRTitle))
+	b.WriteString("| Field | Value |\n|-------|-------|\n")
+	for i, key := range keys {
+		value := strings.ReplaceAll(markdownLine.Replace(values[i]), "|", "\\|")
+		fmt.Fprintf(&b, "| `%s` | %s |\n", key, value)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// flatRows returns the flattened field names of d in sorted order with their values
+// formatted as strings. Slices and maps are rendered as JSON.
+func flatRows(d *PRDetails) ([]string, []string) {
+	flat := PRDetailsToFlatMap(d)
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = formatFlatValue(flat[key])
+	}
+	return keys, values
+}
+
+func formatFlatValue(value interface{}) string {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Slice, reflect.Map:
+		encoded, err := json.Marshal(value)
+		if err == nil {
+			return string(encoded)
+		}
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,117 @@
+package pullmetrics
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenderPR_BuiltIns(t *testing.T) {
+	details := &PRDetails{
+		OrganizationName:  "org",
+		PRNumber:          12,
+		PRTitle:           "Fix | pipe",
+		ApproverUsernames: []string{"user1", "user2"},
+		Metrics:           &PRMetrics{DraftTimeHours: 0.5},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := RenderPR("json", &buf, details); err != nil {
+			t.Fatalf("RenderPR() error = %v", err)
+		}
+		var decoded PRDetails
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("RenderPR() produced invalid JSON: %v", err)
+		}
+		if decoded.PRNumber != 12 {
+			t.Errorf("RenderPR() pr_number = %d, want 12", decoded.PRNumber)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := RenderPR("csv", &buf, details); err != nil {
+			t.Fatalf("RenderPR() error = %v", err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("RenderPR() produced invalid CSV: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("RenderPR() wrote %d rows, want 2", len(records))
+		}
+		row := make(map[string]string)
+		for i, key := range records[0] {
+			row[key] = records[1][i]
+		}
+		if row["pr_number"] != "12" {
+			t.Errorf("RenderPR() pr_number = %q, want %q", row["pr_number"], "12")
+		}
+		if row["approver_usernames"] != `["user1","user2"]` {
+			t.Errorf("RenderPR() approver_usernames = %q, want JSON array", row["approver_usernames"])
+		}
+		if row["metrics.draft_time_hours"] != "0.5" {
+			t.Errorf("RenderPR() metrics.draft_time_hours = %q, want %q", row["metrics.draft_time_hours"], "0.5")
+		}
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := RenderPR("markdown", &buf, details); err != nil {
+			t.Fatalf("RenderPR() error = %v", err)
+		}
+		output := buf.String()
+		for _, want := range []string{"## PR #12: Fix | pipe", "| `pr_number` | 12 |", "| `pr_title` | Fix \\| pipe |"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("RenderPR() markdown missing %q:\n%s", want, output)
+			}
+		}
+	})
+}
+
+func TestRenderPR_MarkdownMultilineValues(t *testing.T) {
+	details := &PRDetails{PRNumber: 3, PRTitle: "Fix\r\nthe | build\rfor\nrelease"}
+
+	var buf bytes.Buffer
+	if err := RenderPR("markdown", &buf, details); err != nil {
+		t.Fatalf("RenderPR() error = %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{"## PR #3: Fix the | build for release\n", "| `pr_title` | Fix the \\| build for release |\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("RenderPR() markdown missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestRenderPR_MarkdownNilDetails(t *testing.T) {
+	if err := RenderPR("markdown", io.Discard, nil); err == nil {
+		t.Error("RenderPR() error = nil, want error for nil details")
+	}
+}
+
+func TestRenderPR_CustomRenderer(t *testing.T) {
+	RegisterRenderer("summary", RendererFunc(func(w io.Writer, d *PRDetails) error {
+		_, err := fmt.Fprintf(w, "%s#%d", d.OrganizationName, d.PRNumber)
+		return err
+	}))
+
+	var buf bytes.Buffer
+	if err := RenderPR("summary", &buf, &PRDetails{OrganizationName: "org", PRNumber: 7}); err != nil {
+		t.Fatalf("RenderPR() error = %v", err)
+	}
+	if buf.String() != "org#7" {
+		t.Errorf("RenderPR() = %q, want %q", buf.String(), "org#7")
+	}
+}
+
+func TestRenderPR_UnknownRenderer(t *testing.T) {
+	if err := RenderPR("yaml", io.Discard, &PRDetails{}); err == nil {
+		t.Error("RenderPR() error = nil, want error for unknown renderer")
+	}
+}
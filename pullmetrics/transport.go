@@ -0,0 +1,144 @@
+package pullmetrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxRetryAfterAttempts is how many times a request is retried after a Retry-After response
+	maxRetryAfterAttempts = 3
+
+	// maxRetryAfterWait caps how long a single Retry-After is honored; longer waits are
+	// returned to the caller as errors instead of blocking the analysis
+	maxRetryAfterWait = 5 * time.Minute
+)
+
+// retryAfterTransport retries requests that receive a 403 or 429 response carrying a
+// Retry-After header. Some enterprise proxies send Retry-After without GitHub's
+// rate-limit headers, so go-github doesn't recognize the response as rate limited.
+type retryAfterTransport struct {
+	base  http.RoundTripper
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRetryAfterTransport(base http.RoundTripper) *retryAfterTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryAfterTransport{base: base, sleep: sleepContext}
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || attempt >= maxRetryAfterAttempts {
+			return resp, err
+		}
+		if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+
+		wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok || wait > maxRetryAfterWait {
+			return resp, nil
+		}
+
+		// Requests with a body can only be retried if the body can be recreated
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		resp.Body.Close()
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// ssoTransport turns 403 responses carrying an X-GitHub-SSO header into an
+// *SSOAuthorizationError, so every fetch reports why the request was refused instead of a
+// generic forbidden error.
+type ssoTransport struct {
+	base http.RoundTripper
+}
+
+func newSSOTransport(base http.RoundTripper) *ssoTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &ssoTransport{base: base}
+}
+
+func (t *ssoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusForbidden {
+		return resp, err
+	}
+
+	authURL, ok := parseSSOHeader(resp.Header.Get("X-GitHub-SSO"))
+	if !ok {
+		return resp, nil
+	}
+	resp.Body.Close()
+	return nil, &SSOAuthorizationError{URL: authURL}
+}
+
+// parseSSOHeader parses an X-GitHub-SSO header of the form "required; url=<url>". Other
+// values, such as the "partial-results" GitHub sends for listings, are not failures.
+func parseSSOHeader(value string) (string, bool) {
+	parts := strings.Split(value, ";")
+	if strings.TrimSpace(parts[0]) != "required" {
+		return "", false
+	}
+	for _, part := range parts[1:] {
+		if authURL, ok := strings.CutPrefix(strings.TrimSpace(part), "url="); ok {
+			return authURL, true
+		}
+	}
+	return "", true
+}
+
+// parseRetryAfter parses a Retry-After header given either as delay seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
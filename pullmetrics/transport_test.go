@@ -0,0 +1,178 @@
+package pullmetrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestRetryAfterTransport_RetriesWithOnlyRetryAfterHeader(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			// Proxy-style response: Retry-After without any X-RateLimit-* headers
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, "[]")
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+	transport := newRetryAfterTransport(http.DefaultTransport)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Get() status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+	if attempts != 2 {
+		t.Errorf("server attempts = %v, want 2", attempts)
+	}
+	if len(slept) != 1 || slept[0] != 7*time.Second {
+		t.Errorf("slept = %v, want [7s]", slept)
+	}
+}
+
+func TestRetryAfterTransport_GivesUp(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           int
+		retryAfter       string
+		expectedAttempts int
+	}{
+		{name: "403 without Retry-After is not retried", status: http.StatusForbidden, retryAfter: "", expectedAttempts: 1},
+		{name: "500 with Retry-After is not retried", status: http.StatusInternalServerError, retryAfter: "1", expectedAttempts: 1},
+		{name: "wait above cap is not retried", status: http.StatusTooManyRequests, retryAfter: "3600", expectedAttempts: 1},
+		{name: "persistent 429 stops after max attempts", status: http.StatusTooManyRequests, retryAfter: "1", expectedAttempts: maxRetryAfterAttempts + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			transport := newRetryAfterTransport(http.DefaultTransport)
+			transport.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+			client := &http.Client{Transport: transport}
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("Get() status = %v, want %v", resp.StatusCode, tt.status)
+			}
+			if attempts != tt.expectedAttempts {
+				t.Errorf("server attempts = %v, want %v", attempts, tt.expectedAttempts)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		value        string
+		expectedWait time.Duration
+		expectedOK   bool
+	}{
+		{name: "delay seconds", value: "30", expectedWait: 30 * time.Second, expectedOK: true},
+		{name: "HTTP date", value: "Sun, 15 Jan 2023 12:01:00 GMT", expectedWait: time.Minute, expectedOK: true},
+		{name: "HTTP date in the past", value: "Sun, 15 Jan 2023 11:00:00 GMT", expectedWait: 0, expectedOK: true},
+		{name: "empty", value: "", expectedOK: false},
+		{name: "negative", value: "-5", expectedOK: false},
+		{name: "garbage", value: "soon", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.expectedOK {
+				t.Fatalf("parseRetryAfter() ok = %v, want %v", ok, tt.expectedOK)
+			}
+			if wait != tt.expectedWait {
+				t.Errorf("parseRetryAfter() = %v, want %v", wait, tt.expectedWait)
+			}
+		})
+	}
+}
+
+func TestSSOTransport_AuthorizationRequired(t *testing.T) {
+	authURL := "https://github.com/orgs/org/sso?authorization_request=abc123"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-SSO", "required; url="+authURL)
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Resource protected by organization SAML enforcement."}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(&http.Client{Transport: newSSOTransport(http.DefaultTransport)})
+	baseURL, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	client.BaseURL = baseURL
+	analyzer := &Analyzer{client: client}
+
+	_, err = analyzer.AnalyzePR(context.Background(), "org", "repo", 1)
+	if !errors.Is(err, ErrSSOAuthorizationRequired) {
+		t.Fatalf("AnalyzePR() error = %v, want %v", err, ErrSSOAuthorizationRequired)
+	}
+	var ssoErr *SSOAuthorizationError
+	if !errors.As(err, &ssoErr) || ssoErr.URL != authURL {
+		t.Errorf("AnalyzePR() error = %v, want authorization URL %s", err, authURL)
+	}
+}
+
+func TestParseSSOHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expectedURL string
+		expectedOK  bool
+	}{
+		{name: "required with url", value: "required; url=https://github.com/orgs/org/sso?authorization_request=abc", expectedURL: "https://github.com/orgs/org/sso?authorization_request=abc", expectedOK: true},
+		{name: "required without url", value: "required", expectedURL: "", expectedOK: true},
+		{name: "partial results", value: "partial-results; organizations=123,456", expectedURL: "", expectedOK: false},
+		{name: "missing header", value: "", expectedURL: "", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotURL, gotOK := parseSSOHeader(tt.value)
+			if gotURL != tt.expectedURL || gotOK != tt.expectedOK {
+				t.Errorf("parseSSOHeader(%q) = (%q, %v), want (%q, %v)", tt.value, gotURL, gotOK, tt.expectedURL, tt.expectedOK)
+			}
+		})
+	}
+}
@@ -3,35 +3,138 @@
 package pullmetrics
 
 import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/google/go-github/v66/github"
 )
 
 // PRDetails represents the complete analysis of a GitHub Pull Request
 type PRDetails struct {
-	OrganizationName           string        `json:"organization_name"`
-	RepositoryName             string        `json:"repository_name"`
-	PRNumber                   int           `json:"pr_number"`
-	PRTitle                    string        `json:"pr_title"`
-	PRWebURL                   string        `json:"pr_web_url"`
-	PRNodeID                   string        `json:"pr_node_id"`
-	AuthorUsername             string        `json:"author_username"`
-	ApproverUsernames          []string      `json:"approver_usernames"`
-	CommenterUsernames         []string      `json:"commenter_usernames"`
-	State                      string        `json:"state"`
-	NumComments                int           `json:"num_comments"`
-	NumCommenters              int           `json:"num_commenters"`
-	NumApprovers               int           `json:"num_approvers"`
-	NumRequestedReviewers      int           `json:"num_requested_reviewers"`
-	ChangeRequestsCount        int           `json:"change_requests_count"`
-	LinesChanged               int           `json:"lines_changed"`
-	FilesChanged               int           `json:"files_changed"`
-	CommitsAfterFirstReview    int           `json:"commits_after_first_review"`
-	JiraIssue                  string        `json:"jira_issue"`
-	IsBot                      bool          `json:"is_bot"`
-	Metrics                    *PRMetrics    `json:"metrics,omitempty"`
-	ReleaseName                *string       `json:"release_name,omitempty"`
-	Timestamps                 *PRTimestamps `json:"timestamps,omitempty"`
-	GeneratedAt                string        `json:"generated_at"`
+	OrganizationName                  string                 `json:"organization_name"`
+	RepositoryName                    string                 `json:"repository_name"`
+	RepoArchived                      *bool                  `json:"repo_archived,omitempty"`
+	PRNumber                          int                    `json:"pr_number"`
+	PRTitle                           string                 `json:"pr_title"`
+	PRWebURL                          string                 `json:"pr_web_url"`
+	PRNodeID                          string                 `json:"pr_node_id"`
+	BaseBranch                        string                 `json:"base_branch"`
+	HeadBranch                        string                 `json:"head_branch"`
+	CrossRepo                         bool                   `json:"cross_repo"`
+	HeadRepoFullName                  string                 `json:"head_repo_full_name,omitempty"`
+	BaseBranchMissing                 bool                   `json:"base_branch_missing,omitempty"`
+	StackedOnPR                       *int                   `json:"stacked_on_pr,omitempty"`
+	StackedPRs                        []int                  `json:"stacked_prs,omitempty"`
+	AuthorUsername                    string                 `json:"author_username"`
+	ApproverUsernames                 []string               `json:"approver_usernames"`
+	ApproversWhoCommitted             []string               `json:"approvers_who_committed,omitempty"`
+	FirstApprover                     *string                `json:"first_approver,omitempty"`
+	CommenterUsernames                []string               `json:"commenter_usernames"`
+	Assignees                         []string               `json:"assignees"`
+	UserProfiles                      map[string]UserProfile `json:"user_profiles,omitempty"`
+	ReviewerStats                     []ReviewerStat         `json:"reviewer_stats,omitempty"`
+	ReviewCommentsByReviewer          map[string]int         `json:"review_comments_by_reviewer,omitempty"`
+	State                             string                 `json:"state"`
+	NumComments                       int                    `json:"num_comments"`
+	EditedComments                    int                    `json:"edited_comments"`
+	MaxThreadLength                   int                    `json:"max_thread_length"`
+	CommentsWithoutFollowupCommit     int                    `json:"comments_without_followup_commit"`
+	NumCommenters                     int                    `json:"num_commenters"`
+	NumApprovers                      int                    `json:"num_approvers"`
+	ApprovalsBeforeLastCommit         int                    `json:"approvals_before_last_commit"`
+	ApprovalsAfterLastCommit          int                    `json:"approvals_after_last_commit"`
+	PostMergeApprovals                int                    `json:"post_merge_approvals"`
+	NumRequestedReviewers             int                    `json:"num_requested_reviewers"`
+	TotalReviewersEverRequested       int                    `json:"total_reviewers_ever_requested"`
+	UnfulfilledReviewRequests         int                    `json:"unfulfilled_review_requests"`
+	NeverRequestedReview              bool                   `json:"never_requested_review"`
+	ReviewerCountDeviation            *int                   `json:"reviewer_count_deviation,omitempty"`
+	DistinctReviewRequestRounds       int                    `json:"distinct_review_request_rounds"`
+	ChangeRequestsCount               int                    `json:"change_requests_count"`
+	DistinctChangeRequesters          int                    `json:"distinct_change_requesters"`
+	ChangeRequestResolutions          int                    `json:"change_request_resolutions"`
+	EngagementIndex                   float64                `json:"engagement_index"`
+	LinesChanged                      int                    `json:"lines_changed"`
+	FilesChanged                      int                    `json:"files_changed"`
+	IsEmpty                           bool                   `json:"is_empty"`
+	ExcludedFilesCount                int                    `json:"excluded_files_count"`
+	SizeBucket                        string                 `json:"size_bucket"`
+	TouchesSensitivePaths             bool                   `json:"touches_sensitive_paths"`
+	SensitiveFilesTouched             []string               `json:"sensitive_files_touched,omitempty"`
+	TotalPatchBytes                   *int                   `json:"total_patch_bytes,omitempty"`
+	CommitsAfterFirstReview           int                    `json:"commits_after_first_review"`
+	NumPushes                         int                    `json:"num_pushes"`
+	ReopenCount                       int                    `json:"reopen_count"`
+	CommitTypes                       map[string]int         `json:"commit_types,omitempty"`
+	PrimaryChangeType                 string                 `json:"primary_change_type,omitempty"`
+	JiraIssue                         string                 `json:"jira_issue"`
+	JiraMissing                       bool                   `json:"jira_missing,omitempty"`
+	IsBot                             bool                   `json:"is_bot"`
+	IsRevert                          bool                   `json:"is_revert"`
+	IsAutoGenerated                   bool                   `json:"is_auto_generated"`
+	IsHotfix                          bool                   `json:"is_hotfix"`
+	OpenedOnWeekend                   bool                   `json:"opened_on_weekend"`
+	MergedOnWeekend                   bool                   `json:"merged_on_weekend"`
+	MergedOverUnresolvedChangeRequest bool                   `json:"merged_over_unresolved_change_request"`
+	MergeCommitSHA                    string                 `json:"merge_commit_sha,omitempty"`
+	AutoMergeEnabled                  bool                   `json:"auto_merge_enabled"`
+	AutoMergeMethod                   string                 `json:"auto_merge_method,omitempty"`
+	ThreadsResolvedByAuthor           *int                   `json:"threads_resolved_by_author,omitempty"`
+	ThreadsResolvedByReviewer         *int                   `json:"threads_resolved_by_reviewer,omitempty"`
+	ProjectStatuses                   map[string]string      `json:"project_statuses,omitempty"`
+	RequiredReviewsFromConfig         *int                   `json:"required_reviews_from_config,omitempty"`
+	RequiresCodeownerReview           *bool                  `json:"requires_codeowner_review,omitempty"`
+	RequiresLinearHistory             *bool                  `json:"requires_linear_history,omitempty"`
+	MetBranchProtection               *bool                  `json:"met_branch_protection,omitempty"`
+	SLAMet                            *bool                  `json:"sla_met,omitempty"`
+	SLABreachHours                    *float64               `json:"sla_breach_hours,omitempty"`
+	Metrics                           *PRMetrics             `json:"metrics,omitempty"`
+	MetricsSuppressed                 bool                   `json:"metrics_suppressed,omitempty"`
+	ReleaseName                       *string                `json:"release_name,omitempty"`
+	ReleaseIsPrerelease               bool                   `json:"release_is_prerelease"`
+	Deployments                       []DeploymentInfo       `json:"deployments,omitempty"`
+	LabelEvents                       []LabelEvent           `json:"label_events,omitempty"`
+	Commits                           []CommitEntry          `json:"commits,omitempty"`
+	CommitsTruncated                  bool                   `json:"commits_truncated,omitempty"`
+	ReleaseSearched                   bool                   `json:"release_searched"`
+	Truncated                         bool                   `json:"truncated,omitempty"`
+	TruncatedData                     []string               `json:"truncated_data,omitempty"`
+	Diagnostics                       *AnalysisDiagnostics   `json:"diagnostics,omitempty"`
+	Timestamps                        *PRTimestamps          `json:"timestamps,omitempty"`
+	GeneratedAt                       string                 `json:"generated_at,omitempty"`
+
+	// compact is set from Config.CompactOutput and read by MarshalJSON
+	compact bool
+}
+
+// UserProfile holds display information for a GitHub user
+type UserProfile struct {
+	Name    string `json:"name,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Company string `json:"company,omitempty"`
+}
+
+// AnalysisDiagnostics describes the API usage of a single analysis
+type AnalysisDiagnostics struct {
+	// APICalls counts the HTTP requests made, including retries
+	APICalls int `json:"api_calls"`
+	// APITimeSeconds is the total time spent waiting on those requests
+	APITimeSeconds float64 `json:"api_time_seconds"`
+	// RateLimitRemaining is the X-RateLimit-Remaining value of the last response that had
+	// one. Nil when no response reported it.
+	RateLimitRemaining *int `json:"rate_limit_remaining,omitempty"`
+}
+
+// ReviewerStat summarizes one reviewer's reviews of a PR
+type ReviewerStat struct {
+	Username   string `json:"username"`
+	NumReviews int    `json:"num_reviews"`
+	// TimeToApprovalHours is the time from the first review request targeting the reviewer
+	// to their first approval after it. Nil when the reviewer didn't approve or was never
+	// explicitly requested.
+	TimeToApprovalHours *float64 `json:"time_to_approval_hours,omitempty"`
 }
 
 // PRSize represents the size metrics of a Pull Request
@@ -47,9 +150,14 @@ type Timestamps struct {
 	FirstReviewRequest *string
 	FirstComment       *string
 	FirstApproval      *string
+	FirstApprover      *string
 	SecondApproval     *string
+	LastApproval       *string
 	MergedAt           *string
 	ClosedAt           *string
+	// ReviewStart is the start of the review clock chosen by Config.ReviewStartAnchor.
+	// When nil, the clock starts at FirstReviewRequest.
+	ReviewStart *string
 }
 
 // PRTimestamps represents the JSON output structure for PR timestamps
@@ -68,25 +176,346 @@ type PRTimestamps struct {
 // PRMetrics represents calculated performance metrics for the PR review process
 type PRMetrics struct {
 	DraftTimeHours                float64  `json:"draft_time_hours"`
+	ActualDraftTimeHours          *float64 `json:"actual_draft_time_hours,omitempty"`
 	TimeToFirstReviewRequestHours *float64 `json:"time_to_first_review_request_hours,omitempty"`
 	TimeToFirstReviewHours        *float64 `json:"time_to_first_review_hours,omitempty"`
+	MedianReviewerResponseHours   *float64 `json:"median_reviewer_response_hours,omitempty"`
+	PickupTimeHours               *float64 `json:"pickup_time_hours,omitempty"`
 	ReviewCycleTimeHours          *float64 `json:"review_cycle_time_hours,omitempty"`
+	LastCommitToMergeHours        *float64 `json:"last_commit_to_merge_hours,omitempty"`
+	ActiveReviewHours             *float64 `json:"active_review_hours,omitempty"`
+	LongestIdleHours              *float64 `json:"longest_idle_hours,omitempty"`
+	HoursSinceLastUpdate          *float64 `json:"hours_since_last_update,omitempty"`
+	TimeInWorkflowLabelHours      *float64 `json:"time_in_workflow_label_hours,omitempty"`
+	TimeToFirstApprovalHours      *float64 `json:"time_to_first_approval_hours,omitempty"`
+	ApprovalSpreadHours           *float64 `json:"approval_spread_hours,omitempty"`
+	AvgTimeToAddressCommentHours  *float64 `json:"avg_time_to_address_comment_hours,omitempty"`
 	BlockingNonBlockingRatio      *float64 `json:"blocking_non_blocking_ratio,omitempty"`
 	ReviewerParticipationRatio    *float64 `json:"reviewer_participation_ratio,omitempty"`
+	ReviewCommentDensity          *float64 `json:"review_comment_density,omitempty"`
+
+	// inapplicable names the metrics that can't be calculated for the PR given its state
+	// and the Config; set by the analysis and read by MissingMetrics
+	inapplicable map[string]bool
 }
 
 // ReleaseInfo holds both the name and creation timestamp of a release
 type ReleaseInfo struct {
-	Name      string
-	CreatedAt string
+	Name       string
+	CreatedAt  string
+	Prerelease bool
+}
+
+// DeploymentInfo describes a GitHub Deployment of a PR's head branch
+type DeploymentInfo struct {
+	Environment string `json:"environment"`
+	CreatedAt   string `json:"created_at"`
+	State       string `json:"state,omitempty"`
+}
+
+// LabelEvent is a label being added to or removed from a PR
+type LabelEvent struct {
+	Label string `json:"label"`
+	// Action is LabelAdded or LabelRemoved
+	Action    string `json:"action"`
+	CreatedAt string `json:"created_at"`
+}
+
+// LabelEvent actions
+const (
+	LabelAdded   = "added"
+	LabelRemoved = "removed"
+)
+
+// CommitEntry describes one commit of a PR, for changelog tooling
+type CommitEntry struct {
+	SHA string `json:"sha"`
+	// AuthorLogin is empty when the commit's author email isn't linked to a GitHub account
+	AuthorLogin string `json:"author_login,omitempty"`
+	AuthoredAt  string `json:"authored_at,omitempty"`
+	// Subject is the first line of the commit message
+	Subject string `json:"subject"`
 }
 
 // Config represents the configuration for the PR analysis
 type Config struct {
 	GitHubToken string
+	// GitHubTokens are additional tokens to spread API calls across, so that large batches
+	// aren't held back by a single token's rate limit. Every token needs read access to
+	// the repositories analyzed. GitHubToken may be left empty when these are set.
+	GitHubTokens []string
+	// OutputTimezone is an IANA timezone name (e.g. "America/New_York") used to format
+	// output timestamps. Duration metrics are unaffected. Defaults to UTC when empty.
+	OutputTimezone string
+	// IncludeThreadResolution fetches review thread resolution data from the GraphQL API
+	// to attribute resolved threads to the author or a reviewer.
+	IncludeThreadResolution bool
+	// IncludeProjectStatus fetches the PR's GitHub Projects items from the GraphQL API to
+	// populate PRDetails.ProjectStatuses. The token needs read access to the projects.
+	IncludeProjectStatus bool
+	// IncludeBranchProtection fetches the protection rules of the PR's base branch
+	// and compares the PR's approvals against them.
+	IncludeBranchProtection bool
+	// ApprovalTimeFromCreation measures TimeToFirstApprovalHours from PR creation
+	// instead of from the first review request.
+	ApprovalTimeFromCreation bool
+	// ReviewStartAnchor is the event that starts the review clock for
+	// TimeToFirstReviewHours, ReviewCycleTimeHours and TimeToFirstApprovalHours.
+	// Defaults to ReviewStartFirstReviewRequest when empty; NewAnalyzer rejects any
+	// other value.
+	ReviewStartAnchor ReviewStartAnchor
+	// SkipReleaseLookup disables the release fetch for repositories that don't use GitHub releases.
+	SkipReleaseLookup bool
+	// SizeBuckets overrides the LinesChanged thresholds used for SizeBucket.
+	// The zero value uses DefaultSizeBuckets.
+	SizeBuckets SizeBuckets
+	// EngagementWeights overrides the weights used for EngagementIndex.
+	// The zero value uses DefaultEngagementWeights.
+	EngagementWeights EngagementWeights
+	// IncludePatchStats sums the size of each file's diff patch into TotalPatchBytes
+	IncludePatchStats bool
+	// IncludeRepoMetadata fetches the repository to report whether it is archived
+	IncludeRepoMetadata bool
+	// AutoGeneratedPatterns are regular expressions matched against the PR title to flag
+	// auto-generated PRs. Defaults to DefaultAutoGeneratedPatterns when empty.
+	AutoGeneratedPatterns []string
+	// SensitivePathPatterns are glob patterns, such as ".github/workflows/**" or "*.tf",
+	// matched against the changed files to populate TouchesSensitivePaths and
+	// SensitiveFilesTouched. "*" and "?" don't match "/", "**" matches any number of
+	// directories, and patterns without a "/" are matched against the file name alone.
+	SensitivePathPatterns []string
+	// ExcludeFileGlobs are glob patterns, such as "**/*_test.go" or "vendor/**", for
+	// changed files to leave out of the analysis entirely, with the same rules as
+	// SensitivePathPatterns. Matching files don't count towards FilesChanged, LinesChanged,
+	// TotalPatchBytes or any other file-based field; ExcludedFilesCount reports how many
+	// were left out.
+	ExcludeFileGlobs []string
+	// HotfixBranchPatterns are glob patterns, such as "release/*" or "hotfix/*", matched
+	// against the PR's base branch to set IsHotfix, with the same rules as
+	// SensitivePathPatterns.
+	HotfixBranchPatterns []string
+	// HotfixLabels are label names, compared case-insensitively, that mark a PR as a
+	// hotfix regardless of its base branch.
+	HotfixLabels []string
+	// WorkflowLabel is a label name, compared case-insensitively, whose total time on the
+	// PR is reported in PRMetrics.TimeInWorkflowLabelHours, e.g. "needs-review". Empty
+	// disables the metric.
+	WorkflowLabel string
+	// IgnoredTimelineEvents are timeline event types that don't count as activity in the
+	// activity-based metrics such as LongestIdleHours. Defaults to
+	// DefaultIgnoredTimelineEvents when empty.
+	IgnoredTimelineEvents []string
+	// ReviewSLAHours is the time allowed between the first review request and the first
+	// human review. Zero disables SLA evaluation.
+	ReviewSLAHours float64
+	// EditedCommentThreshold is how long after it was posted a comment must have been
+	// updated to count towards PRDetails.EditedComments, so quick typo fixes are ignored.
+	// Defaults to DefaultEditedCommentThreshold when zero.
+	EditedCommentThreshold time.Duration
+	// ResolveUserProfiles fetches the profile of each approver and commenter to populate
+	// UserProfiles. Profiles are cached per Analyzer.
+	ResolveUserProfiles bool
+	// ResolveBotViaAPI looks up the PR author's account type so bots without the
+	// "[bot]" suffix are also reported as bots. Lookups share the UserProfiles cache.
+	ResolveBotViaAPI bool
+	// ReviewerFilter limits reviews, comments and review requests to those by or for the
+	// listed users, so approver, change request, requested reviewer, participation and
+	// comment counts reflect only them.
+	// Empty considers everyone.
+	ReviewerFilter []string
+	// DraftTimeIncludesReopens adds to DraftTimeHours the time spent back in draft after
+	// the first review request (convert_to_draft to ready_for_review).
+	DraftTimeIncludesReopens bool
+	// ExcludeClosedIntervals subtracts from ReviewCycleTimeHours the time a PR spent
+	// closed before it was reopened (closed to reopened timeline events).
+	ExcludeClosedIntervals bool
+	// ExpectedReviewers is the number of approvals a team's policy expects. When set,
+	// PRDetails.ReviewerCountDeviation reports NumApprovers minus this value.
+	ExpectedReviewers int
+	// ExcludePostMergeApprovals leaves approvals submitted after the PR was merged out of
+	// NumApprovers and ApproverUsernames. They are still counted in PostMergeApprovals.
+	ExcludePostMergeApprovals bool
+	// MinLinesForMetrics omits the timing metrics of PRs with fewer lines changed, since
+	// trivial PRs such as typo fixes skew cycle-time data. Counts are still reported and
+	// PRDetails.MetricsSuppressed is set. Zero reports metrics for every PR.
+	MinLinesForMetrics int
+	// CompactOutput leaves empty arrays and maps out of the JSON encoding of PRDetails to
+	// shrink batch output. Counts and durations are kept even when zero. The default full
+	// output always has every required field of the output schema.
+	CompactOutput bool
+	// OmitGeneratedAt leaves PRDetails.GeneratedAt empty so it is omitted from the JSON,
+	// making the output byte-identical across runs for a PR whose state hasn't changed.
+	OmitGeneratedAt bool
+	// ExpandTeamReviewers counts the members of requested teams as requested reviewers in
+	// NumRequestedReviewers and UnfulfilledReviewRequests. Team membership is fetched once
+	// per Analyzer and requires a token that can read the organization's teams.
+	ExpandTeamReviewers bool
+	// BatchFailureThreshold aborts AnalyzePRs and AnalyzeOpenPRs once this many PRs in a
+	// row fail with a 401 or 403 response. Zero never aborts.
+	BatchFailureThreshold int
+	// MaxAnalysisDuration bounds the time spent fetching a single PR's reviews, comments,
+	// timeline, files, commits and releases. Once it passes, pagination stops and the PR is
+	// analyzed from the pages fetched so far, with PRDetails.Truncated set. Zero disables it.
+	MaxAnalysisDuration time.Duration
+	// IncludeDiagnostics reports the API calls made for each PR, the time spent on them and
+	// the rate limit left afterwards in PRDetails.Diagnostics
+	IncludeDiagnostics bool
+	// IncludeDeployments reports the deployments of a PR's head branch (head commit for
+	// PRs from forks) when its timeline has deployed or deployment_environment_changed
+	// events. Costs one API call per deployment to read its latest status.
+	IncludeDeployments bool
+	// IncludeLabelTimeline reports the PR's labeled and unlabeled timeline events in
+	// LabelEvents, for computing time spent in workflow labels
+	IncludeLabelTimeline bool
+	// IncludeCommits lists the PR's commits in PRDetails.Commits. The commits are always
+	// fetched for the analysis; this only controls the output.
+	IncludeCommits bool
+	// MaxCommits caps PRDetails.Commits at the PR's first MaxCommits commits, setting
+	// CommitsTruncated when more were left out. Zero lists every commit.
+	MaxCommits int
+	// DetectStackedPRs lists the repository's open PRs whose head branch is the PR's base
+	// branch, or whose base branch is the PR's head branch, to populate StackedOnPR and
+	// StackedPRs. This costs two extra list calls per PR, more when over 100 PRs match.
+	DetectStackedPRs bool
+	// IncludePreReleases lets pre-releases and draft releases be matched as the release
+	// containing a merged PR. By default only full releases are considered.
+	IncludePreReleases bool
+	// RequireJiraIssue makes analysis fail with ErrJiraIssueMissing when a PR by a
+	// non-bot author has no Jira issue reference.
+	RequireJiraIssue bool
+	// JiraMissingAsFlag reports a missing Jira issue through PRDetails.JiraMissing
+	// instead of failing when RequireJiraIssue is set.
+	JiraMissingAsFlag bool
+	// RequireClosed makes analysis fail with ErrPRStillOpen for PRs that are still open,
+	// including drafts, so datasets of completed PRs don't pick up in-flight numbers
+	RequireClosed bool
+	// JiraUnknownValue replaces the PRDetails.JiraIssue value for PRs with no Jira issue
+	// reference. Nil uses DefaultJiraUnknownValue; a pointer to "" reports an empty string.
+	JiraUnknownValue *string
+	// JiraBotValue replaces the PRDetails.JiraIssue value for bot PRs with no Jira issue
+	// reference. Nil uses DefaultJiraBotValue; a pointer to "" reports an empty string.
+	JiraBotValue *string
+}
+
+// DefaultAutoGeneratedPatterns match the titles of revert and merge-back PRs
+var DefaultAutoGeneratedPatterns = []string{
+	`^Revert "`,
+	`^Merge branch `,
+	`^Merge remote-tracking branch `,
+}
+
+// DefaultIgnoredTimelineEvents are cosmetic timeline events that don't indicate work on a PR
+var DefaultIgnoredTimelineEvents = []string{
+	string(EventLabeled),
+	string(EventUnlabeled),
+	string(EventRenamed),
+	string(EventMentioned),
+	string(EventSubscribed),
+}
+
+// SizeBuckets holds the exclusive upper bounds of LinesChanged for each size bucket.
+// PRs with at least L lines changed are classified as XL.
+type SizeBuckets struct {
+	XS int
+	S  int
+	M  int
+	L  int
+}
+
+// EngagementWeights are the weights PRDetails.EngagementIndex gives each count:
+//
+//	EngagementIndex = Comments*NumComments + ChangeRequests*ChangeRequestsCount +
+//	                  Approvers*NumApprovers + Commenters*NumCommenters
+type EngagementWeights struct {
+	Comments       float64
+	ChangeRequests float64
+	Approvers      float64
+	Commenters     float64
 }
 
+// DefaultEngagementWeights count each comment, approver and commenter once and each
+// change request twice
+var DefaultEngagementWeights = EngagementWeights{Comments: 1, ChangeRequests: 2, Approvers: 1, Commenters: 1}
+
+// DefaultEditedCommentThreshold is the default Config.EditedCommentThreshold
+const DefaultEditedCommentThreshold = 5 * time.Minute
+
+// DefaultSizeBuckets are the commonly used PR size thresholds
+var DefaultSizeBuckets = SizeBuckets{XS: 10, S: 50, M: 250, L: 1000}
+
+// Default PRDetails.JiraIssue values for PRs without a Jira issue reference
+const (
+	DefaultJiraUnknownValue = "UNKNOWN"
+	DefaultJiraBotValue     = "BOT"
+)
+
+// DeletedUserLogin stands in for the username of a deleted (null) GitHub user
+const DeletedUserLogin = "(deleted)"
+
+// ReviewState is the state of a pull request review as reported by GitHub
+type ReviewState string
+
+// Review states
+const (
+	ReviewApproved         ReviewState = "APPROVED"
+	ReviewChangesRequested ReviewState = "CHANGES_REQUESTED"
+	ReviewCommented        ReviewState = "COMMENTED"
+	ReviewDismissed        ReviewState = "DISMISSED"
+	ReviewPending          ReviewState = "PENDING"
+)
+
+// ReviewStartAnchor selects the event the review clock starts at
+type ReviewStartAnchor string
+
+// Review clock anchors
+const (
+	ReviewStartFirstReviewRequest ReviewStartAnchor = "first_review_request"
+	ReviewStartCreation           ReviewStartAnchor = "creation"
+	ReviewStartReadyForReview     ReviewStartAnchor = "ready_for_review"
+)
+
+// TimelineEvent is the type of an issue timeline event as reported by GitHub
+type TimelineEvent string
+
+// Timeline event types used by the analysis
+const (
+	EventReviewRequested      TimelineEvent = "review_requested"
+	EventReviewRequestRemoved TimelineEvent = "review_request_removed"
+	EventReviewed             TimelineEvent = "reviewed"
+	EventReadyForReview       TimelineEvent = "ready_for_review"
+	EventConvertToDraft       TimelineEvent = "convert_to_draft"
+	EventCommitted            TimelineEvent = "committed"
+	EventLabeled              TimelineEvent = "labeled"
+	EventUnlabeled            TimelineEvent = "unlabeled"
+	EventClosed               TimelineEvent = "closed"
+	EventReopened             TimelineEvent = "reopened"
+	EventMerged               TimelineEvent = "merged"
+	EventRenamed              TimelineEvent = "renamed"
+	EventMentioned            TimelineEvent = "mentioned"
+	EventSubscribed           TimelineEvent = "subscribed"
+	EventHeadRefForcePushed   TimelineEvent = "head_ref_force_pushed"
+	EventDeployed             TimelineEvent = "deployed"
+	EventDeploymentEnvChanged TimelineEvent = "deployment_environment_changed"
+)
+
 // Analyzer provides the core functionality for analyzing GitHub Pull Requests
 type Analyzer struct {
-	client *github.Client
-}
\ No newline at end of file
+	client                *github.Client
+	clients               []*github.Client
+	nextClient            atomic.Uint64
+	config                Config
+	location              *time.Location
+	autoGeneratedPatterns []*regexp.Regexp
+	sensitivePathPatterns []*regexp.Regexp
+	hotfixBranchPatterns  []*regexp.Regexp
+	excludeFilePatterns   []*regexp.Regexp
+
+	// now is the clock the analysis measures open PRs against; time.Now when nil
+	now func() time.Time
+
+	usersMu sync.Mutex
+	users   map[string]*github.User
+
+	teamsMu     sync.Mutex
+	teamMembers map[string][]string
+}
@@ -0,0 +1,47 @@
+package pullmetrics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// AnalyzeWebhookEvent analyzes the Pull Request carried by a `pull_request` webhook event.
+//
+// The webhook payload already contains the full PR object, so the PR itself is not
+// re-fetched. Fields available synchronously from the payload include the title, body,
+// web URL, node ID, author, state (open/closed/draft/merged), head and base branches,
+// currently requested reviewers, and the created/merged/closed timestamps.
+//
+// The payload does not include reviews, conversation comments, review comments,
+// timeline events, changed files, commits or releases; those are still fetched
+// from the GitHub API via AnalyzeFromPR.
+func AnalyzeWebhookEvent(ctx context.Context, a *Analyzer, event *github.PullRequestEvent) (*PRDetails, error) {
+	if a == nil {
+		return nil, fmt.Errorf("analyzer is required")
+	}
+	if event == nil || event.PullRequest == nil {
+		return nil, fmt.Errorf("webhook event does not contain a pull request")
+	}
+
+	org := event.GetRepo().GetOwner().GetLogin()
+	repo := event.GetRepo().GetName()
+	if org == "" || repo == "" {
+		return nil, fmt.Errorf("webhook event does not contain repository information")
+	}
+
+	pr := event.PullRequest
+	if pr.Number == nil {
+		// The event carries the number at the top level as well
+		if event.Number == nil {
+			return nil, fmt.Errorf("webhook event does not contain a pull request number")
+		}
+		// Copy so the caller's event is left untouched
+		prCopy := *event.PullRequest
+		prCopy.Number = event.Number
+		pr = &prCopy
+	}
+
+	return a.AnalyzeFromPR(ctx, org, repo, pr)
+}
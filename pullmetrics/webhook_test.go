@@ -0,0 +1,110 @@
+package pullmetrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestAnalyzeWebhookEvent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octo-org/hello-world/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("AnalyzeWebhookEvent() should not re-fetch the PR")
+	})
+	mux.HandleFunc("/repos/octo-org/hello-world/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"user":{"login":"reviewer1"},"created_at":"2023-01-15T11:00:00Z"}]`)
+	})
+	analyzer := newTestAnalyzer(t, mux)
+
+	event := &github.PullRequestEvent{
+		Action: stringPtr("closed"),
+		Number: intPtr(7),
+		Repo: &github.Repository{
+			Name:  stringPtr("hello-world"),
+			Owner: &github.User{Login: stringPtr("octo-org")},
+		},
+		PullRequest: &github.PullRequest{
+			Title:     stringPtr("Webhook PR"),
+			HTMLURL:   stringPtr("https://github.com/octo-org/hello-world/pull/7"),
+			NodeID:    stringPtr("PR_node7"),
+			State:     stringPtr("closed"),
+			Merged:    boolPtr(false),
+			User:      &github.User{Login: stringPtr("author")},
+			CreatedAt: timePtr(time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	details, err := AnalyzeWebhookEvent(context.Background(), analyzer, event)
+	if err != nil {
+		t.Fatalf("AnalyzeWebhookEvent() error = %v", err)
+	}
+
+	if details.OrganizationName != "octo-org" || details.RepositoryName != "hello-world" {
+		t.Errorf("AnalyzeWebhookEvent() org/repo = %v/%v, want octo-org/hello-world", details.OrganizationName, details.RepositoryName)
+	}
+	if details.PRNumber != 7 {
+		t.Errorf("AnalyzeWebhookEvent().PRNumber = %v, want 7", details.PRNumber)
+	}
+	if details.NumComments != 1 {
+		t.Errorf("AnalyzeWebhookEvent().NumComments = %v, want 1", details.NumComments)
+	}
+	if details.State != "closed" {
+		t.Errorf("AnalyzeWebhookEvent().State = %v, want closed", details.State)
+	}
+	if event.PullRequest.Number != nil {
+		t.Errorf("AnalyzeWebhookEvent() set the event's PullRequest.Number to %d, want it left nil", *event.PullRequest.Number)
+	}
+}
+
+func TestAnalyzeWebhookEvent_InvalidEvents(t *testing.T) {
+	analyzer := newTestAnalyzer(t, http.NewServeMux())
+
+	tests := []struct {
+		name  string
+		event *github.PullRequestEvent
+	}{
+		{
+			name:  "nil event",
+			event: nil,
+		},
+		{
+			name: "missing pull request",
+			event: &github.PullRequestEvent{
+				Number: intPtr(7),
+				Repo: &github.Repository{
+					Name:  stringPtr("hello-world"),
+					Owner: &github.User{Login: stringPtr("octo-org")},
+				},
+			},
+		},
+		{
+			name: "missing repository",
+			event: &github.PullRequestEvent{
+				Number:      intPtr(7),
+				PullRequest: &github.PullRequest{Number: intPtr(7)},
+			},
+		},
+		{
+			name: "missing number",
+			event: &github.PullRequestEvent{
+				Repo: &github.Repository{
+					Name:  stringPtr("hello-world"),
+					Owner: &github.User{Login: stringPtr("octo-org")},
+				},
+				PullRequest: &github.PullRequest{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := AnalyzeWebhookEvent(context.Background(), analyzer, tt.event); err == nil {
+				t.Error("AnalyzeWebhookEvent() expected error, got nil")
+			}
+		})
+	}
+}